@@ -1,10 +1,18 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 	"carcassonne-ws/internal/api"
+	"carcassonne-ws/internal/logging"
+	"carcassonne-ws/internal/room"
 	"carcassonne-ws/internal/websocket"
 )
 
@@ -15,20 +23,122 @@ func main() {
 		port = "8080"
 	}
 
+	// Log at info level unless LOG_LEVEL asks for more or less
+	level := logging.LevelInfo
+	if name := os.Getenv("LOG_LEVEL"); name != "" {
+		parsed, err := logging.ParseLevel(name)
+		if err != nil {
+			log.Fatalf("Invalid LOG_LEVEL %q", name)
+		}
+		level = parsed
+	}
+	logger := logging.New(level)
+
 	// Create WebSocket hub
-	hub := websocket.NewHub()
+	hub := websocket.NewHub(websocket.DefaultBotSweepInterval)
+	hub.SetLogger(logger)
+	
+	// Sign session tokens with a stable secret so they survive restarts
+	if secret := os.Getenv("SESSION_SECRET"); secret != "" {
+		hub.SetSessionSecret([]byte(secret))
+	}
+	
+	// Only let browsers connect from the listed origins
+	if origins := os.Getenv("ALLOWED_ORIGINS"); origins != "" {
+		hub.SetAllowedOrigins(strings.Split(origins, ","))
+	} else {
+		logger.Warnf("ALLOWED_ORIGINS is not set, accepting WebSocket connections from any origin")
+	}
+	
+	// Limit the size of client messages
+	if size := os.Getenv("MAX_MESSAGE_SIZE"); size != "" {
+		limit, err := strconv.ParseInt(size, 10, 64)
+		if err != nil || limit <= 0 {
+			log.Fatalf("Invalid MAX_MESSAGE_SIZE %q", size)
+		}
+		hub.SetMaxMessageSize(limit)
+	}
+	
+	// Remove rooms nobody sat down in or whose game is over after a while
+	if ttl := os.Getenv("IDLE_ROOM_TTL"); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			log.Fatalf("Invalid IDLE_ROOM_TTL %q", ttl)
+		}
+		hub.SetRoomSweep(room.DefaultSweepInterval, d)
+	}
+	
+	// Cap the number of rooms so the lobby can't be flooded
+	if rooms := os.Getenv("MAX_ROOMS"); rooms != "" {
+		limit, err := strconv.Atoi(rooms)
+		if err != nil || limit <= 0 {
+			log.Fatalf("Invalid MAX_ROOMS %q", rooms)
+		}
+		hub.SetRoomLimits(limit, room.DefaultRoomsPerMinute)
+	}
+	
+	// Restore the games saved on the last shutdown
+	stateDir := os.Getenv("STATE_DIR")
+	if stateDir != "" {
+		count, err := hub.LoadRooms(stateDir)
+		if err != nil {
+			logger.Errorf("Error loading saved rooms: %v", err)
+		}
+		logger.Infof("Restored %d rooms from %s", count, stateDir)
+	}
+	
 	go hub.Run()
 
 	// Create HTTP server
 	server := api.NewServer(hub)
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		server.SetCORSOrigins(strings.Split(origins, ","))
+	}
+	if token := os.Getenv("DEBUG_TOKEN"); token != "" {
+		server.SetDebugToken(token)
+	}
 	router := server.SetupRoutes()
 
-	log.Printf("Carcassonne WebSocket server starting on port %s", port)
-	log.Printf("WebSocket endpoint: ws://localhost:%s/ws", port)
-	log.Printf("Health check: http://localhost:%s/health", port)
+	logger.Infof("Carcassonne WebSocket server starting on port %s", port)
+	logger.Infof("WebSocket endpoint: ws://localhost:%s/ws", port)
+	logger.Infof("Health check: http://localhost:%s/health", port)
+	
+	httpServer := &http.Server{
+		Addr:    ":" + port,
+		Handler: router,
+	}
+	
+	// Drain running games, save them and tell every client before exiting
+	// on SIGINT or SIGTERM
+	stopped := make(chan struct{})
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		<-sigs
+		
+		logger.Infof("Draining games before shutdown...")
+		if !hub.Drain(30 * time.Second) {
+			logger.Warnf("Drain timed out with games mid-turn")
+		}
+		if stateDir != "" {
+			if err := hub.SaveRooms(stateDir); err != nil {
+				logger.Errorf("Error saving rooms: %v", err)
+			}
+		}
+		hub.Shutdown()
+		
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.Errorf("Error shutting down HTTP server: %v", err)
+		}
+		close(stopped)
+	}()
 	
 	// Start the server
-	if err := http.ListenAndServe(":"+port, router); err != nil {
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatal("Server failed to start:", err)
 	}
+	<-stopped
+	logger.Infof("Server stopped")
 }
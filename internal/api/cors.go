@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetCORSOrigins sets the origins browsers may call the HTTP API from. Any
+// origin is allowed while the list is empty. Must be called before
+// SetupRoutes.
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			s.corsOrigins[strings.ToLower(origin)] = true
+		}
+	}
+}
+
+// corsMiddleware adds the CORS headers for allowed origins and answers
+// preflight requests itself, so handlers don't deal with CORS
+func (s *Server) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if allowed := s.corsOrigin(r.Header.Get("Origin")); allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		}
+		w.Header().Add("Vary", "Origin")
+		
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsOrigin returns the Access-Control-Allow-Origin value for a request
+// origin, or "" when the origin may not call the API
+func (s *Server) corsOrigin(origin string) string {
+	switch {
+	case origin == "":
+		return ""
+	case len(s.corsOrigins) == 0:
+		return "*"
+	case s.corsOrigins[strings.ToLower(origin)]:
+		return origin
+	}
+	return ""
+}
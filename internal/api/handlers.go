@@ -1,15 +1,32 @@
 package api
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"strings"
+	"carcassonne-ws/internal/room"
 	"carcassonne-ws/internal/websocket"
 	"github.com/gorilla/mux"
 )
 
+// Page sizes of the player game history endpoint
+const (
+	defaultGamesPageSize = 20
+	maxGamesPageSize     = 100
+)
+
 // Server represents the HTTP server
 type Server struct {
 	hub *websocket.Hub
+	
+	// debugToken guards the debug endpoints, which are off while it is empty
+	debugToken string
+	
+	// corsOrigins may call the HTTP API from a browser, any origin when empty
+	corsOrigins map[string]bool
 }
 
 // NewServer creates a new HTTP server
@@ -19,15 +36,33 @@ func NewServer(hub *websocket.Hub) *Server {
 	}
 }
 
+// SetDebugToken enables the debug endpoints for requests carrying the token
+// as a bearer token. Must be called before SetupRoutes.
+func (s *Server) SetDebugToken(token string) {
+	s.debugToken = token
+}
+
 // SetupRoutes sets up the HTTP routes
 func (s *Server) SetupRoutes() *mux.Router {
 	router := mux.NewRouter()
 	
-	// Health check endpoint
-	router.HandleFunc("/health", s.healthHandler).Methods("GET")
+	// Health check endpoint, callable from browsers like the API
+	router.Handle("/health", s.corsMiddleware(http.HandlerFunc(s.healthHandler))).Methods("GET", "OPTIONS")
+	router.HandleFunc("/ready", s.readyHandler).Methods("GET")
+	router.HandleFunc("/metrics", s.metricsHandler).Methods("GET")
+	
+	// Room management endpoints (HTTP fallback). Preflight requests are
+	// answered by the CORS middleware.
+	api := router.PathPrefix("/api").Subrouter()
+	api.Use(s.corsMiddleware)
+	api.HandleFunc("/rooms", s.listRoomsHandler).Methods("GET", "OPTIONS")
+	api.HandleFunc("/rooms/{id}", s.roomHandler).Methods("GET", "OPTIONS")
+	api.HandleFunc("/players/{id}/games", s.playerGamesHandler).Methods("GET", "OPTIONS")
 	
-	// Room management endpoints (HTTP fallback)
-	router.HandleFunc("/api/rooms", s.listRoomsHandler).Methods("GET")
+	// Debug endpoints, only with a debug token
+	if s.debugToken != "" {
+		router.HandleFunc("/debug/clients", s.debugClientsHandler).Methods("GET")
+	}
 	
 	// WebSocket endpoint
 	router.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
@@ -40,42 +75,157 @@ func (s *Server) SetupRoutes() *mux.Router {
 	return router
 }
 
-// healthHandler handles health check requests
+// healthHandler handles health check requests, failing when the hub stopped
+// responding
 func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
 	
+	status := "healthy"
+	if s.hub.Healthy() {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		status = "unhealthy"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	
+	metrics := s.hub.GetMetrics()
 	response := map[string]interface{}{
-		"status": "healthy",
+		"status": status,
 		"service": "carcassonne-ws",
 		"version": "1.0.0",
+		"clients": metrics.ConnectedClients,
+		"rooms":   metrics.ActiveRooms,
 	}
 	
 	json.NewEncoder(w).Encode(response)
 }
 
+// readyHandler handles readiness probes, failing while the server drains
+func (s *Server) readyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	status := "ready"
+	if s.hub.IsDraining() {
+		status = "draining"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	
+	response := map[string]interface{}{
+		"status":   status,
+		"draining": s.hub.IsDraining(),
+	}
+	
+	json.NewEncoder(w).Encode(response)
+}
+
+// metricsHandler reports connection, room and game counts for monitoring
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	
+	json.NewEncoder(w).Encode(s.hub.GetMetrics())
+}
+
 // listRoomsHandler handles room listing requests (HTTP fallback)
 func (s *Server) listRoomsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
+	response := map[string]interface{}{
+		"rooms": s.hub.ListActiveRooms(),
+	}
+	
+	json.NewEncoder(w).Encode(response)
+}
+
+// roomHandler returns a single room with its game state, e.g. for a page
+// showing a game without a WebSocket. Private rooms need their password as
+// the password query parameter.
+func (s *Server) roomHandler(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.hub.GetRoomSnapshot(mux.Vars(r)["id"], r.URL.Query().Get("password"))
+	switch {
+	case errors.Is(err, room.ErrRoomNotFound):
+		http.Error(w, "Room not found", http.StatusNotFound)
+		return
+	case errors.Is(err, room.ErrIncorrectPassword):
+		http.Error(w, "Incorrect password", http.StatusForbidden)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	
-	// This is a simplified version - in a real implementation,
-	// you'd need to access the room manager through the hub
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+// playerGamesHandler handles requests for a player's finished games
+func (s *Server) playerGamesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		http.Error(w, "Invalid offset", http.StatusBadRequest)
+		return
+	}
+	
+	limit, err := queryInt(r, "limit", defaultGamesPageSize)
+	if err != nil || limit < 1 || limit > maxGamesPageSize {
+		http.Error(w, "Invalid limit", http.StatusBadRequest)
+		return
+	}
+	
+	playerID := mux.Vars(r)["id"]
+	games, total := s.hub.GetPlayerGames(playerID, offset, limit)
+	
 	response := map[string]interface{}{
-		"rooms": []interface{}{},
-		"message": "Use WebSocket connection for full functionality",
+		"playerId": playerID,
+		"games":    games,
+		"offset":   offset,
+		"limit":    limit,
+		"total":    total,
 	}
 	
 	json.NewEncoder(w).Encode(response)
 }
 
+// debugClientsHandler lists the connected clients with their latency
+func (s *Server) debugClientsHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeDebug(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	w.Header().Set("Content-Type", "application/json")
+	
+	clients := s.hub.ClientStats()
+	response := map[string]interface{}{
+		"clients": clients,
+		"total":   len(clients),
+	}
+	
+	json.NewEncoder(w).Encode(response)
+}
+
+// authorizeDebug checks the request's bearer token against the debug token
+func (s *Server) authorizeDebug(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || s.debugToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.debugToken)) == 1
+}
+
+// queryInt reads an integer query parameter, falling back to def when absent
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	return strconv.Atoi(value)
+}
+
 // startGameHandler handles game start requests
 func (s *Server) startGameHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
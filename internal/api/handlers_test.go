@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"carcassonne-ws/internal/websocket"
+)
+
+func TestPlayerGamesPaging(t *testing.T) {
+	hub := websocket.NewHub(time.Hour)
+	defer hub.Stop()
+	router := NewServer(hub).SetupRoutes()
+
+	tests := []struct {
+		query     string
+		status    int
+		wantLimit int
+	}{
+		{query: "", status: http.StatusOK, wantLimit: defaultGamesPageSize},
+		{query: "?offset=10&limit=5", status: http.StatusOK, wantLimit: 5},
+		{query: "?offset=-1", status: http.StatusBadRequest},
+		{query: "?offset=x", status: http.StatusBadRequest},
+		{query: "?limit=0", status: http.StatusBadRequest},
+		{query: "?limit=101", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/players/alice/games"+tt.query, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != tt.status {
+			t.Fatalf("GET games%s = %d, want %d", tt.query, rec.Code, tt.status)
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+
+		var response struct {
+			PlayerID string `json:"playerId"`
+			Limit    int    `json:"limit"`
+			Total    int    `json:"total"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if response.PlayerID != "alice" || response.Limit != tt.wantLimit || response.Total != 0 {
+			t.Fatalf("GET games%s = %+v, want an empty page of %d for alice", tt.query, response, tt.wantLimit)
+		}
+	}
+}
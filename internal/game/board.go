@@ -1,23 +1,123 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 )
 
 // Board represents the game board
 type Board struct {
-	Tiles        map[Position]*PlacedTile
+	Tiles        TileMap
 	TileDeck     []*Tile
 	CurrentTile  *Tile
+	TotalTiles   int // Size of the tile set, including the starting tile
+	Seed         int64 // Seed the deck was shuffled with
 	Players      []*Player
-	CurrentPlayer int
+	CurrentPlayer int // Index in Players of the player whose turn it is
 	GameStarted  bool
 	GameEnded    bool
 	Scores       map[string]int
+	
+	// TurnOrder lists the IDs of the players in the order they take turns,
+	// set when the game starts
+	TurnOrder []string
+	
+	// RandomizeSeating shuffles TurnOrder when the game starts instead of
+	// keeping the order players joined in
+	RandomizeSeating bool
+	
+	// scoreDetails breaks each player's score down by feature type, see
+	// GetScoreDetails
+	scoreDetails map[string]PlayerScoreDetail
+	
+	// LastPlacedTile is the tile placed during the current turn, nil until
+	// the current player places their tile
+	LastPlacedTile *PlacedTile
+	
+	// DiscardedTiles are the unplaceable tiles put back under the deck
+	// while drawing the current tile
+	DiscardedTiles []*Tile
+	
+	// DrawnTile is the tile drawn by the last DrawNextTile, nil if the deck
+	// ran out
+	DrawnTile *Tile
+	
+	// ScoringEvents are the features scored during the current turn, by
+	// placing its tile and figure, oldest first
+	ScoringEvents []ScoringEvent
+	
+	// MoveHistory records every tile placed and the figure placed on it,
+	// oldest first, so the game can be replayed
+	MoveHistory []MoveRecord
+	
+	// graph links the features of neighboring tiles, see featureGraph
+	graph *FeatureGraph
+	
+	// Version counts the changes made to the board. Every change to the
+	// game state increments it, so clients can tell a stale state apart.
+	Version int
+	
+	// tileVersions is the Version at which each tile last changed
+	tileVersions map[Position]int
+	
+	// meeplePlaced is set once a figure was placed on LastPlacedTile
+	meeplePlaced bool
+	
+	// scoredFeatures are the features of LastPlacedTile that were held by
+	// someone and scored as it was placed, so they can't be claimed again
+	scoredFeatures []int
+	
+	// Builders enables the Traders & Builders builder figure
+	Builders bool
+	
+	// MeeplesPerPlayer is the number of meeples each player starts with
+	MeeplesPerPlayer int
+	
+	// Builder extra turn state: builderTriggered is set when the current
+	// player extends a feature holding their builder, bonusTurn while the
+	// extra turn is being played so it can't chain into another one
+	builderTriggered bool
+	bonusTurn        bool
+	
+	// StrictPlacement enforces edge matching on tile placement. When false,
+	// a tile may be placed at any open adjacent position; scoring still only
+	// follows matching edges, so mismatched neighbors break feature continuity.
+	StrictPlacement bool
 }
 
+var (
+	// ErrNoTilePlaced is returned for meeple actions before a tile was placed this turn
+	ErrNoTilePlaced = errors.New("no tile placed this turn")
+	
+	// ErrFeatureClaimed is returned for a meeple on a feature already occupied
+	// anywhere along its connected extent
+	ErrFeatureClaimed = errors.New("feature already claimed")
+	
+	// ErrMeepleAlreadyPlaced is returned for a second figure on the tile placed this turn
+	ErrMeepleAlreadyPlaced = errors.New("meeple already placed this turn")
+	
+	// ErrTileNotFound is returned when no tile is placed at a position
+	ErrTileNotFound = errors.New("no tile at position")
+	
+	// ErrInvalidFeature is returned for a figure on a feature the tile placed
+	// this turn doesn't have
+	ErrInvalidFeature = errors.New("invalid feature ID")
+	
+	// ErrInvalidPlacement is returned for a tile placed where it doesn't fit
+	ErrInvalidPlacement = errors.New("invalid tile placement")
+	
+	// ErrInvalidRotation is returned for a tile rotation that isn't a
+	// multiple of 90 degrees
+	ErrInvalidRotation = errors.New("invalid rotation")
+	
+	// ErrNoMeeplePlaced is returned when undoing a figure while none of the
+	// player's figures is on the tile placed this turn
+	ErrNoMeeplePlaced = errors.New("no meeple placed this turn")
+)
+
 // Player represents a player in the game
 type Player struct {
 	ID       string
@@ -26,24 +126,44 @@ type Player struct {
 	Meeples  int
 	IsBot    bool
 	Score    int
+	HasBuilder bool // Builder figure still in supply, only used with builders
+	TimeBank time.Duration // Remaining game clock, only used in timed rooms
 }
 
-// NewBoard creates a new game board
+// DefaultMeeplesPerPlayer is the number of meeples each player starts with
+// in standard Carcassonne
+const DefaultMeeplesPerPlayer = 7
+
+// MaxPlayers is the most players a game can seat, as in the base game with
+// the Inns & Cathedrals expansion
+const MaxPlayers = 6
+
+// NewBoard creates a new game board with a randomly shuffled deck
 func NewBoard() *Board {
+	return NewBoardWithSeed(time.Now().UnixNano())
+}
+
+// NewBoardWithSeed creates a new game board whose deck order is fully
+// determined by the seed, so games can be reproduced
+func NewBoardWithSeed(seed int64) *Board {
 	tiles := CreateStandardTileSet()
+	rng := rand.New(rand.NewSource(seed))
 	
-	// Shuffle the deck
-	rand.Seed(time.Now().UnixNano())
-	for i := len(tiles) - 1; i > 0; i-- {
-		j := rand.Intn(i + 1)
+	// Shuffle the deck, keeping the starting tile in front
+	for i := len(tiles) - 1; i > 1; i-- {
+		j := 1 + rng.Intn(i)
 		tiles[i], tiles[j] = tiles[j], tiles[i]
 	}
 
 	board := &Board{
 		Tiles:    make(map[Position]*PlacedTile),
 		TileDeck: tiles[1:], // Skip the starting tile
+		TotalTiles: len(tiles),
+		Seed:     seed,
 		Players:  make([]*Player, 0),
 		Scores:   make(map[string]int),
+		StrictPlacement: true,
+		MeeplesPerPlayer: DefaultMeeplesPerPlayer,
 	}
 
 	// Place the starting tile at (0, 0)
@@ -54,24 +174,97 @@ func NewBoard() *Board {
 		Meeples:  make([]PlacedMeeple, 0),
 	}
 	board.Tiles[Position{X: 0, Y: 0}] = startingTile
+	
+	// Build the feature graph right away, so reading the board never has to
+	board.featureGraph()
 
 	return board
 }
 
+// Clone returns a deep copy of the board that can be played on without
+// affecting the original, e.g. to look ahead at a move. Tile definitions are
+// never changed once created and are shared.
+func (b *Board) Clone() *Board {
+	clone := *b
+	
+	clone.Tiles = b.copyTiles()
+	if b.LastPlacedTile != nil {
+		clone.LastPlacedTile = clone.Tiles[b.LastPlacedTile.Position]
+	}
+	
+	clone.TileDeck = append([]*Tile{}, b.TileDeck...)
+	clone.scoredFeatures = append([]int(nil), b.scoredFeatures...)
+	if b.DiscardedTiles != nil {
+		clone.DiscardedTiles = append([]*Tile{}, b.DiscardedTiles...)
+	}
+	
+	if b.ScoringEvents != nil {
+		clone.ScoringEvents = append([]ScoringEvent{}, b.ScoringEvents...)
+	}
+	
+	clone.Players = b.copyPlayers()
+	clone.TurnOrder = b.copyTurnOrder()
+	clone.Scores = b.copyScores()
+	clone.scoreDetails = b.copyScoreDetails()
+	if b.tileVersions != nil {
+		clone.tileVersions = make(map[Position]int, len(b.tileVersions))
+		for pos, version := range b.tileVersions {
+			clone.tileVersions[pos] = version
+		}
+	}
+	if b.MoveHistory != nil {
+		clone.MoveHistory = b.GetMoveHistory()
+	}
+	
+	// Placing tiles on a shared feature graph would change the original
+	// board, so the clone rebuilds its own when first needed
+	clone.graph = nil
+	
+	return &clone
+}
+
+// copyTiles returns a copy of the placed tiles, meeples included. Tile
+// definitions are shared.
+func (b *Board) copyTiles() TileMap {
+	tiles := make(TileMap, len(b.Tiles))
+	for pos, tile := range b.Tiles {
+		tiles[pos] = tile.copy()
+	}
+	return tiles
+}
+
+// copy returns a copy of the placed tile with its own meeples
+func (pt *PlacedTile) copy() *PlacedTile {
+	placed := *pt
+	placed.Meeples = append(make([]PlacedMeeple, 0, len(pt.Meeples)), pt.Meeples...)
+	return &placed
+}
+
+// copyPlayers returns a copy of the players in seating order
+func (b *Board) copyPlayers() []*Player {
+	players := make([]*Player, len(b.Players))
+	for i, player := range b.Players {
+		copied := *player
+		players[i] = &copied
+	}
+	return players
+}
+
 // AddPlayer adds a player to the game
 func (b *Board) AddPlayer(player *Player) error {
-	if len(b.Players) >= 5 {
-		return fmt.Errorf("maximum 5 players allowed")
+	if len(b.Players) >= MaxPlayers {
+		return fmt.Errorf("maximum %d players allowed", MaxPlayers)
 	}
 	
 	if b.GameStarted {
 		return fmt.Errorf("game already started")
 	}
 
-	player.Meeples = 7 // Each player starts with 7 meeples
+	player.Meeples = b.MeeplesPerPlayer
 	player.Score = 0
 	b.Players = append(b.Players, player)
 	b.Scores[player.ID] = 0
+	b.Version++
 	
 	return nil
 }
@@ -87,22 +280,53 @@ func (b *Board) StartGame() error {
 	}
 
 	b.GameStarted = true
-	b.CurrentPlayer = 0
+	b.TurnOrder = b.seatOrder()
+	if b.RandomizeSeating {
+		b.shuffleTurnOrder()
+	}
+	b.CurrentPlayer = b.playerIndex(b.TurnOrder[0])
+	for _, player := range b.Players {
+		player.HasBuilder = b.Builders
+	}
 	b.DrawNextTile()
 	
 	return nil
 }
 
 // DrawNextTile draws the next tile from the deck
+// Tiles that fit nowhere on the board are discarded to the bottom of the
+// deck and recorded in DiscardedTiles. If no remaining tile fits, the deck
+// counts as empty.
 func (b *Board) DrawNextTile() bool {
-	if len(b.TileDeck) == 0 {
-		b.GameEnded = true
-		return false
+	b.DiscardedTiles = nil
+	b.DrawnTile = nil
+	b.Version++
+	
+	for len(b.DiscardedTiles) < len(b.TileDeck) {
+		b.CurrentTile = b.TileDeck[0]
+		b.TileDeck = b.TileDeck[1:]
+		if len(b.GetValidPlacements()) > 0 {
+			b.DrawnTile = b.CurrentTile
+			return true
+		}
+		
+		b.DiscardedTiles = append(b.DiscardedTiles, b.CurrentTile)
+		b.TileDeck = append(b.TileDeck, b.CurrentTile)
+		b.CurrentTile = nil
 	}
 	
-	b.CurrentTile = b.TileDeck[0]
-	b.TileDeck = b.TileDeck[1:]
-	return true
+	b.GameEnded = true
+	return false
+}
+
+// DiscardCurrentTile puts the current tile back under the deck
+func (b *Board) DiscardCurrentTile() {
+	if b.CurrentTile == nil {
+		return
+	}
+	b.TileDeck = append(b.TileDeck, b.CurrentTile)
+	b.CurrentTile = nil
+	b.Version++
 }
 
 // GetValidPlacements returns all valid positions and rotations for the current tile
@@ -124,7 +348,7 @@ func (b *Board) GetValidPlacements() []PlacementOption {
 				Rotation: rotation,
 			}
 			
-			if placedTile.CanPlaceAt(b.Tiles, pos) {
+			if b.canPlace(placedTile, pos) {
 				validPlacements = append(validPlacements, PlacementOption{
 					Position: pos,
 					Rotation: rotation,
@@ -142,6 +366,48 @@ type PlacementOption struct {
 	Rotation int
 }
 
+// PositionMoves groups the valid placements of the current tile at one position
+type PositionMoves struct {
+	Position          Position      `json:"position"`
+	Rotations         []int         `json:"rotations"`
+	ClaimableFeatures map[int][]int `json:"claimableFeatures"` // by rotation, features a meeple could go on
+}
+
+// GetValidMovesDetailed returns the valid placements of the current tile
+// grouped by position, with the features that would be claimable for each
+// rotation. Positions come in the order of GetValidPlacements.
+func (b *Board) GetValidMovesDetailed() []PositionMoves {
+	placements := b.GetValidPlacements()
+	if placements == nil {
+		return nil
+	}
+	
+	moves := make([]PositionMoves, 0)
+	index := make(map[Position]int)
+	for _, option := range placements {
+		i, ok := index[option.Position]
+		if !ok {
+			i = len(moves)
+			index[option.Position] = i
+			moves = append(moves, PositionMoves{
+				Position:          option.Position,
+				ClaimableFeatures: make(map[int][]int),
+			})
+		}
+		
+		claimable := make([]int, 0)
+		for featureID := range b.CurrentTile.Features {
+			if b.IsFeatureClaimableAt(option, featureID) {
+				claimable = append(claimable, featureID)
+			}
+		}
+		moves[i].Rotations = append(moves[i].Rotations, option.Rotation)
+		moves[i].ClaimableFeatures[option.Rotation] = claimable
+	}
+	
+	return moves
+}
+
 // getPossiblePositions returns all positions adjacent to existing tiles
 func (b *Board) getPossiblePositions() []Position {
 	positions := make(map[Position]bool)
@@ -167,32 +433,114 @@ func (b *Board) getPossiblePositions() []Position {
 		result = append(result, pos)
 	}
 	
+	// Map order is random, seeded games must see the same placements
+	sortPositions(result)
 	return result
 }
 
+// sortPositions sorts positions in board order, row by row from the top
+func sortPositions(positions []Position) {
+	sort.Slice(positions, func(i, j int) bool {
+		if positions[i].Y != positions[j].Y {
+			return positions[i].Y < positions[j].Y
+		}
+		return positions[i].X < positions[j].X
+	})
+}
+
+// canPlace checks a placement according to the board's strictness mode
+func (b *Board) canPlace(placedTile *PlacedTile, pos Position) bool {
+	if b.StrictPlacement {
+		return placedTile.CanPlaceAt(b.Tiles, pos)
+	}
+	return placedTile.IsOpenAdjacentAt(b.Tiles, pos)
+}
+
+// placementError returns why a placement isn't allowed in the board's
+// strictness mode, or nil if it is
+func (b *Board) placementError(placedTile *PlacedTile, pos Position) error {
+	if b.StrictPlacement {
+		return placedTile.CanPlaceAtWithReason(b.Tiles, pos)
+	}
+	if !placedTile.IsOpenAdjacentAt(b.Tiles, pos) {
+		return fmt.Errorf("%w: position (%d, %d) is occupied or has no tile next to it", ErrInvalidPlacement, pos.X, pos.Y)
+	}
+	return nil
+}
+
 // PlaceTile places a tile on the board
 func (b *Board) PlaceTile(pos Position, rotation int) error {
 	if b.CurrentTile == nil {
 		return fmt.Errorf("no current tile to place")
 	}
 	
+	rotation, ok := normalizeRotation(rotation)
+	if !ok {
+		return ErrInvalidRotation
+	}
+	
 	placedTile := &PlacedTile{
 		Tile:     b.CurrentTile,
 		Position: pos,
 		Rotation: rotation,
 		Meeples:  make([]PlacedMeeple, 0),
 	}
+	if currentPlayer := b.GetCurrentPlayer(); currentPlayer != nil {
+		placedTile.PlacedBy = currentPlayer.ID
+	}
 	
-	if !placedTile.CanPlaceAt(b.Tiles, pos) {
-		return fmt.Errorf("invalid tile placement")
+	if err := b.placementError(placedTile, pos); err != nil {
+		return err
 	}
 	
 	b.Tiles[pos] = placedTile
+	b.markTileChanged(pos)
+	b.featureGraph().AddTile(b.Tiles, placedTile)
 	b.CurrentTile = nil
+	b.LastPlacedTile = placedTile
+	b.meeplePlaced = false
+	
+	if b.Builders && !b.bonusTurn && placedTile.PlacedBy != "" {
+		b.builderTriggered = b.extendsOwnBuilder(placedTile)
+	}
+	
+	// Score before the meeple phase so returned meeples can be placed again
+	scoresBefore := b.copyScores()
+	b.ScoringEvents = nil
+	b.scoredFeatures = b.claimedCompletedFeatures(placedTile)
+	b.ScoreCompletedFeatures(pos)
+	b.recordTile(placedTile, scoresBefore)
 	
 	return nil
 }
 
+// extendsOwnBuilder checks if the placed tile extends a road or city holding
+// the placing player's builder
+func (b *Board) extendsOwnBuilder(placedTile *PlacedTile) bool {
+	for i, feature := range placedTile.Tile.Features {
+		if feature.Type != RoadFeature && feature.Type != CityFeature {
+			continue
+		}
+		connected := b.GetConnectedFeature(placedTile.Position, i)
+		if b.hasBuilder(connected, placedTile.PlacedBy, placedTile.Position) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTile returns a copy of the tile placed at the given position
+func (b *Board) GetTile(pos Position) (PlacedTile, error) {
+	placedTile, exists := b.Tiles[pos]
+	if !exists {
+		return PlacedTile{}, ErrTileNotFound
+	}
+	
+	tileCopy := *placedTile
+	tileCopy.Meeples = append([]PlacedMeeple(nil), placedTile.Meeples...)
+	return tileCopy, nil
+}
+
 // PlaceMeeple places a meeple on the last placed tile
 func (b *Board) PlaceMeeple(playerID string, featureID int) error {
 	player := b.GetPlayer(playerID)
@@ -204,29 +552,27 @@ func (b *Board) PlaceMeeple(playerID string, featureID int) error {
 		return fmt.Errorf("no meeples available")
 	}
 	
-	// Find the last placed tile (this is simplified - in a real game you'd track this better)
-	var lastTile *PlacedTile
-	for _, tile := range b.Tiles {
-		if len(tile.Meeples) == 0 { // Assuming the last placed tile has no meeples yet
-			lastTile = tile
-			break
-		}
+	// Meeples can only go on the tile placed this turn, never on the
+	// starting tile or an older one
+	lastTile := b.LastPlacedTile
+	if lastTile == nil {
+		return ErrNoTilePlaced
 	}
 	
-	if lastTile == nil {
-		return fmt.Errorf("no tile to place meeple on")
+	if b.meeplePlaced {
+		return ErrMeepleAlreadyPlaced
 	}
 	
-	// Check if feature is valid and not already occupied
-	if featureID >= len(lastTile.Tile.Features) {
-		return fmt.Errorf("invalid feature ID")
+	// Check if feature is valid and not already occupied. Feature IDs
+	// always refer to the tile placed this turn.
+	if featureID < 0 || featureID >= len(lastTile.Tile.Features) {
+		return fmt.Errorf("%w: tile at (%d, %d) has no feature %d", ErrInvalidFeature, lastTile.Position.X, lastTile.Position.Y, featureID)
 	}
 	
-	// Check if feature is already occupied (simplified check)
-	for _, meeple := range lastTile.Meeples {
-		if meeple.FeatureID == featureID {
-			return fmt.Errorf("feature already occupied")
-		}
+	// A meeple anywhere on the connected road, city or field claims it, and
+	// a feature scored for its holders when the tile was placed stays taken
+	if b.isFeatureClaimed(lastTile.Position, featureID) || b.scoredWhenPlaced(lastTile.Position, featureID) {
+		return ErrFeatureClaimed
 	}
 	
 	meeple := PlacedMeeple{
@@ -236,22 +582,219 @@ func (b *Board) PlaceMeeple(playerID string, featureID int) error {
 	}
 	
 	lastTile.Meeples = append(lastTile.Meeples, meeple)
+	b.markTileChanged(lastTile.Position)
 	player.Meeples--
+	b.meeplePlaced = true
+	
+	// A meeple placed on a feature this tile just completed scores right away
+	scoresBefore := b.copyScores()
+	b.scoreIfComplete(lastTile.Position, featureID)
+	b.recordFigure(featureID, NormalMeeple, scoresBefore)
+	
+	return nil
+}
+
+// PlaceBuilder places the player's builder on a road or city of the last
+// placed tile that the player already occupies with a meeple
+func (b *Board) PlaceBuilder(playerID string, featureID int) error {
+	if !b.Builders {
+		return fmt.Errorf("builders are not enabled")
+	}
+	
+	player := b.GetPlayer(playerID)
+	if player == nil {
+		return fmt.Errorf("player not found")
+	}
+	
+	if !player.HasBuilder {
+		return fmt.Errorf("builder already placed")
+	}
+	
+	if b.LastPlacedTile == nil {
+		return ErrNoTilePlaced
+	}
+	
+	if b.meeplePlaced {
+		return ErrMeepleAlreadyPlaced
+	}
+	
+	if featureID < 0 || featureID >= len(b.LastPlacedTile.Tile.Features) {
+		return fmt.Errorf("%w: tile at (%d, %d) has no feature %d", ErrInvalidFeature, b.LastPlacedTile.Position.X, b.LastPlacedTile.Position.Y, featureID)
+	}
+	
+	featureType := b.LastPlacedTile.Tile.Features[featureID].Type
+	if featureType != RoadFeature && featureType != CityFeature {
+		return fmt.Errorf("builder must be placed on a road or city")
+	}
+	
+	occupied := false
+	for _, claimant := range b.featureClaimants(b.GetConnectedFeature(b.LastPlacedTile.Position, featureID)) {
+		if claimant == playerID {
+			occupied = true
+			break
+		}
+	}
+	if !occupied {
+		return fmt.Errorf("builder requires one of your meeples on the feature")
+	}
+	
+	b.LastPlacedTile.Meeples = append(b.LastPlacedTile.Meeples, PlacedMeeple{
+		PlayerID:  playerID,
+		FeatureID: featureID,
+		Color:     player.Color,
+		Type:      BuilderMeeple,
+	})
+	b.markTileChanged(b.LastPlacedTile.Position)
+	player.HasBuilder = false
+	b.meeplePlaced = true
+	b.recordFigure(featureID, BuilderMeeple, b.copyScores())
 	
 	return nil
 }
 
-// NextTurn advances to the next player's turn
+// UndoMeeple takes back the figure the player placed on the last placed tile
+// this turn, so it can be placed again. It is only possible until the turn
+// ends, and not once the figure scored.
+func (b *Board) UndoMeeple(playerID string) error {
+	player := b.GetPlayer(playerID)
+	if player == nil {
+		return fmt.Errorf("player not found")
+	}
+	
+	if b.LastPlacedTile == nil {
+		return ErrNoTilePlaced
+	}
+	
+	// A meeple on a feature completed by the tile scored and went back
+	// already, so it's no longer on the tile
+	tile := b.LastPlacedTile
+	for i, meeple := range tile.Meeples {
+		if meeple.PlayerID != playerID {
+			continue
+		}
+		
+		tile.Meeples = append(tile.Meeples[:i], tile.Meeples[i+1:]...)
+		b.markTileChanged(tile.Position)
+		if meeple.Type == BuilderMeeple {
+			player.HasBuilder = true
+		} else {
+			player.Meeples++
+		}
+		b.meeplePlaced = false
+		b.unrecordFigure()
+		return nil
+	}
+	
+	return ErrNoMeeplePlaced
+}
+
+// NextTurn advances to the next player's turn, following TurnOrder. A
+// player who extended a feature holding their builder plays one extra turn
+// first.
 func (b *Board) NextTurn() {
-	b.CurrentPlayer = (b.CurrentPlayer + 1) % len(b.Players)
+	if b.builderTriggered {
+		b.builderTriggered = false
+		b.bonusTurn = true
+	} else {
+		b.bonusTurn = false
+		if current := b.GetCurrentPlayer(); current != nil {
+			if next := b.playerIndex(b.nextInTurnOrder(current.ID)); next >= 0 {
+				b.CurrentPlayer = next
+			}
+		}
+	}
+	b.LastPlacedTile = nil
+	b.meeplePlaced = false
+	b.scoredFeatures = nil
 	if !b.DrawNextTile() {
 		b.EndGame()
 	}
 }
 
+// RemovePlayer takes a player out of a running game. Their figures are
+// taken off the board, their tiles stay. If it was their turn, the next
+// player in turn order takes over: the drawn tile passes on, or a new one is
+// drawn if the tile was already placed.
+func (b *Board) RemovePlayer(playerID string) error {
+	index := -1
+	for i, player := range b.Players {
+		if player.ID == playerID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("player not in game")
+	}
+	
+	for pos, tile := range b.Tiles {
+		remaining := tile.Meeples[:0]
+		for _, meeple := range tile.Meeples {
+			if meeple.PlayerID != playerID {
+				remaining = append(remaining, meeple)
+			}
+		}
+		if len(remaining) != len(tile.Meeples) {
+			b.markTileChanged(pos)
+		}
+		tile.Meeples = remaining
+	}
+	
+	// Keep the turn with the same player, or pass it on in turn order if the
+	// removed player was playing
+	wasCurrent := index == b.CurrentPlayer
+	current := ""
+	if player := b.GetCurrentPlayer(); player != nil {
+		current = player.ID
+	}
+	if wasCurrent {
+		current = b.nextInTurnOrder(playerID)
+	}
+	
+	b.Players = append(b.Players[:index], b.Players[index+1:]...)
+	b.removeFromTurnOrder(playerID)
+	delete(b.Scores, playerID)
+	b.Version++
+	
+	b.CurrentPlayer = b.playerIndex(current)
+	if b.CurrentPlayer < 0 {
+		b.CurrentPlayer = 0
+	}
+	
+	if wasCurrent && b.GameStarted && !b.GameEnded {
+		b.builderTriggered = false
+		b.bonusTurn = false
+		b.meeplePlaced = false
+		if b.LastPlacedTile != nil {
+			b.LastPlacedTile = nil
+			b.scoredFeatures = nil
+			if !b.DrawNextTile() {
+				b.EndGame()
+			}
+		}
+	}
+	
+	return nil
+}
+
+// CanPlaceMeeple checks if the player has any figure left to place
+func (b *Board) CanPlaceMeeple(playerID string) bool {
+	player := b.GetPlayer(playerID)
+	if player == nil {
+		return false
+	}
+	
+	return player.Meeples > 0 || (b.Builders && player.HasBuilder)
+}
+
+// MeeplePlaced checks if a figure was placed on the tile placed this turn
+func (b *Board) MeeplePlaced() bool {
+	return b.meeplePlaced
+}
+
 // GetCurrentPlayer returns the current player
 func (b *Board) GetCurrentPlayer() *Player {
-	if len(b.Players) == 0 {
+	if b.CurrentPlayer < 0 || b.CurrentPlayer >= len(b.Players) {
 		return nil
 	}
 	return b.Players[b.CurrentPlayer]
@@ -270,45 +813,85 @@ func (b *Board) GetPlayer(playerID string) *Player {
 // EndGame ends the game and calculates final scores
 func (b *Board) EndGame() {
 	b.GameEnded = true
+	b.Version++
 	// Calculate final scores for incomplete features
 	b.calculateFinalScores()
 }
 
-// calculateFinalScores calculates final scores for incomplete features
+// GetWinners returns the players with the highest score, in seating order.
+// More than one player means a tie.
+func (b *Board) GetWinners() []string {
+	winners := make([]string, 0)
+	best := 0
+	for _, player := range b.Players {
+		switch {
+		case len(winners) == 0 || player.Score > best:
+			winners = []string{player.ID}
+			best = player.Score
+		case player.Score == best:
+			winners = append(winners, player.ID)
+		}
+	}
+	return winners
+}
+
+// calculateFinalScores scores the features still held at game end:
+// incomplete roads, cities and monasteries, then the farmers' fields
 func (b *Board) calculateFinalScores() {
-	// This is a simplified implementation
-	// In a full game, you'd need to:
-	// 1. Find all incomplete roads, cities, and monasteries
-	// 2. Score them at reduced points
-	// 3. Score farms based on completed cities they supply
+	b.scoreIncompleteFeatures()
+	b.scoreIncompleteMonasteries()
+	b.scoreFields()
 	
 	for _, player := range b.Players {
 		b.Scores[player.ID] = player.Score
 	}
 }
 
-// GetGameState returns the current game state
+// RemainingTileCounts returns how many tiles of each kind are left in the
+// deck, keyed by the kind's tile ID (see Tile.KindID). The drawn tile is not
+// included. Being counts, they don't tell the order tiles will be drawn in.
+func (b *Board) RemainingTileCounts() map[int]int {
+	counts := make(map[int]int)
+	for _, tile := range b.TileDeck {
+		counts[tile.KindID()]++
+	}
+	return counts
+}
+
+// GetGameState returns a copy of the current game state, which stays
+// unchanged as the game goes on
 func (b *Board) GetGameState() GameState {
 	return GameState{
-		Tiles:         b.Tiles,
+		Tiles:         b.copyTiles(),
 		CurrentTile:   b.CurrentTile,
-		Players:       b.Players,
+		Players:       b.copyPlayers(),
 		CurrentPlayer: b.CurrentPlayer,
+		TurnOrder:     b.copyTurnOrder(),
 		GameStarted:   b.GameStarted,
 		GameEnded:     b.GameEnded,
-		Scores:        b.Scores,
+		Scores:        b.copyScores(),
+		ScoreDetails:  b.GetScoreDetails(),
 		TilesLeft:     len(b.TileDeck),
+		RemainingTiles: b.RemainingTileCounts(),
+		MeeplesPerPlayer: b.MeeplesPerPlayer,
+		Version:       b.Version,
 	}
 }
 
 // GameState represents the current state of the game
 type GameState struct {
-	Tiles         map[Position]*PlacedTile `json:"tiles"`
+	Tiles         TileMap                  `json:"tiles"`
 	CurrentTile   *Tile                    `json:"currentTile"`
 	Players       []*Player                `json:"players"`
 	CurrentPlayer int                      `json:"currentPlayer"`
+	TurnOrder     []string                 `json:"turnOrder"` // player IDs in the order they take turns, empty before the game started
 	GameStarted   bool                     `json:"gameStarted"`
 	GameEnded     bool                     `json:"gameEnded"`
 	Scores        map[string]int           `json:"scores"`
+	ScoreDetails  map[string]PlayerScoreDetail `json:"scoreDetails"`
 	TilesLeft     int                      `json:"tilesLeft"`
+	RemainingTiles map[int]int             `json:"remainingTiles"` // tiles left in the deck per kind, see RemainingTileCounts
+	MeeplesPerPlayer int                   `json:"meeplesPerPlayer"`
+	Phase         string                   `json:"phase,omitempty"` // turn phase, set by the room while the game runs
+	Version       int                      `json:"version"`         // board version the state was taken at
 }
@@ -0,0 +1,335 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestStrictPlacement(t *testing.T) {
+	// A monastery has only fields, so it can't go next to the starting
+	// tile's road on the east
+	mismatched := Position{1, 0}
+
+	for _, strict := range []bool{true, false} {
+		b := newStartedBoard(t)
+		b.StrictPlacement = strict
+		drawKind(t, b, kindMonastery)
+
+		offered := false
+		for _, placement := range b.GetValidPlacements() {
+			if placement.Position == mismatched {
+				offered = true
+			}
+		}
+		if offered == strict {
+			t.Fatalf("strict %v: mismatched placement offered = %v", strict, offered)
+		}
+
+		// Tiles still have to touch the board
+		if err := b.PlaceTile(Position{5, 5}, 0); err == nil {
+			t.Fatalf("strict %v: PlaceTile away from the board succeeded", strict)
+		}
+		err := b.PlaceTile(mismatched, 0)
+		if (err == nil) == strict {
+			t.Fatalf("strict %v: PlaceTile on a mismatched edge = %v", strict, err)
+		}
+	}
+}
+
+func TestBuilderExtraTurn(t *testing.T) {
+	b := newTestBoard(t)
+	b.Builders = true
+	if err := b.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	expectTurn := func(playerID string) {
+		t.Helper()
+		if current := b.GetCurrentPlayer().ID; current != playerID {
+			t.Fatalf("current player = %q, want %q", current, playerID)
+		}
+	}
+
+	// "a" builds a road east of the starting tile, "b" plays monasteries
+	// below it
+	playMeeple(t, b, kindStraightRoad, Position{1, 0}, 90, 0)
+	if err := b.PlaceBuilder("a", 0); err == nil {
+		t.Fatalf("PlaceBuilder in the same turn as a meeple succeeded")
+	}
+	b.NextTurn()
+	play(t, b, kindMonastery, Position{0, 1}, 0)
+	b.NextTurn()
+
+	play(t, b, kindStraightRoad, Position{2, 0}, 90)
+	if err := b.PlaceBuilder("a", 1); err == nil {
+		t.Fatalf("PlaceBuilder on a field succeeded")
+	}
+	if err := b.PlaceBuilder("a", 0); err != nil {
+		t.Fatalf("PlaceBuilder: %v", err)
+	}
+	if b.GetPlayer("a").HasBuilder {
+		t.Fatalf("builder is still in the supply")
+	}
+	b.NextTurn()
+	expectTurn("b")
+	play(t, b, kindMonastery, Position{-1, 1}, 0)
+	if err := b.PlaceBuilder("b", 0); err == nil {
+		t.Fatalf("PlaceBuilder on a monastery succeeded")
+	}
+	b.NextTurn()
+
+	// Extending the road with the builder grants one extra turn, not two
+	play(t, b, kindStraightRoad, Position{3, 0}, 90)
+	b.NextTurn()
+	expectTurn("a")
+	play(t, b, kindStraightRoad, Position{4, 0}, 90)
+	b.NextTurn()
+	expectTurn("b")
+}
+
+func TestSeededDeck(t *testing.T) {
+	order := func(b *Board) []int {
+		ids := make([]int, len(b.TileDeck))
+		for i, tile := range b.TileDeck {
+			ids[i] = tile.ID
+		}
+		return ids
+	}
+
+	first, again, other := NewBoardWithSeed(7), NewBoardWithSeed(7), NewBoardWithSeed(8)
+	if !reflect.DeepEqual(order(first), order(again)) {
+		t.Fatalf("boards of the same seed have different decks")
+	}
+	if reflect.DeepEqual(order(first), order(other)) {
+		t.Fatalf("boards of different seeds have the same deck")
+	}
+	if len(first.TileDeck) != 71 || first.Tiles[Position{0, 0}].Tile.ID != 0 {
+		t.Fatalf("deck of %d tiles, want the starting tile on the board and 71 in the deck", len(first.TileDeck))
+	}
+}
+
+func TestRemovePlayerKeepsCurrentPlayer(t *testing.T) {
+	tests := []struct {
+		name    string
+		turns   int // turns played before the removal
+		removed string
+		want    string
+	}{
+		{name: "player before the current one", turns: 1, removed: "a", want: "b"},
+		{name: "player after the current one", turns: 1, removed: "c", want: "b"},
+		{name: "current player", turns: 1, removed: "b", want: "c"},
+		{name: "current player last in turn order", turns: 2, removed: "c", want: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBoard(t)
+			if err := b.AddPlayer(&Player{ID: "c", Name: "c"}); err != nil {
+				t.Fatalf("AddPlayer: %v", err)
+			}
+			if err := b.StartGame(); err != nil {
+				t.Fatalf("StartGame: %v", err)
+			}
+			for i := 0; i < tt.turns; i++ {
+				b.NextTurn()
+			}
+
+			if err := b.RemovePlayer(tt.removed); err != nil {
+				t.Fatalf("RemovePlayer: %v", err)
+			}
+			if current := b.GetCurrentPlayer(); current == nil || current.ID != tt.want {
+				t.Fatalf("current player = %v, want %q", current, tt.want)
+			}
+			if b.CurrentTile == nil {
+				t.Fatalf("no tile drawn for the current player")
+			}
+		})
+	}
+}
+
+func TestRemovePlayerAfterPlacingTile(t *testing.T) {
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindMonastery, Position{0, 1}, 0, 0)
+
+	if err := b.RemovePlayer("a"); err != nil {
+		t.Fatalf("RemovePlayer: %v", err)
+	}
+	if current := b.GetCurrentPlayer(); current.ID != "b" || b.CurrentTile == nil || b.LastPlacedTile != nil {
+		t.Fatalf("after removing a mid-turn: current player %q, want b with a new tile", current.ID)
+	}
+	if meeples := b.Tiles[Position{0, 1}].Meeples; len(meeples) != 0 {
+		t.Fatalf("removed player's meeples stayed on the board: %v", meeples)
+	}
+	if _, exists := b.Tiles[Position{0, 1}]; !exists {
+		t.Fatalf("removed player's tile was taken off the board")
+	}
+}
+
+func TestClone(t *testing.T) {
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 2)
+	b.NextTurn()
+	original := b.GetGameState()
+
+	// Playing on the clone, up to the end of the game, leaves the board as
+	// it was. Completing the road takes the meeple off the clone's tiles.
+	clone := b.Clone()
+	playMeeple(t, clone, kindRoadJunction, Position{-1, 0}, 0, 1)
+	clone.GetPlayer("a").Name = "changed"
+	clone.TurnOrder[0], clone.TurnOrder[1] = clone.TurnOrder[1], clone.TurnOrder[0]
+	clone.NextTurn()
+	clone.EndGame()
+
+	if got := b.GetGameState(); !reflect.DeepEqual(got, original) {
+		t.Fatalf("playing on the clone changed the board:\n got %+v\nwant %+v", got, original)
+	}
+	if _, exists := b.Tiles[Position{-1, 0}]; exists || len(b.Tiles[Position{1, 0}].Meeples) != 1 {
+		t.Fatalf("the move on the clone changed the board's tiles")
+	}
+
+	// And the other way around
+	play(t, b, kindMonastery, Position{0, 1}, 0)
+	if _, exists := clone.Tiles[Position{0, 1}]; exists {
+		t.Fatalf("the tile placed on the board is on the clone")
+	}
+}
+
+func TestUndoMeeple(t *testing.T) {
+	b := newStartedBoard(t)
+	if err := b.UndoMeeple("a"); err != ErrNoTilePlaced {
+		t.Fatalf("UndoMeeple before placing a tile = %v, want ErrNoTilePlaced", err)
+	}
+
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 1)
+	if err := b.UndoMeeple("b"); err != ErrNoMeeplePlaced {
+		t.Fatalf("UndoMeeple of another player = %v, want ErrNoMeeplePlaced", err)
+	}
+	if err := b.UndoMeeple("a"); err != nil {
+		t.Fatalf("UndoMeeple: %v", err)
+	}
+	if a := b.GetPlayer("a"); a.Meeples != DefaultMeeplesPerPlayer || len(b.LastPlacedTile.Meeples) != 0 {
+		t.Fatalf("after undoing a has %d meeples and the tile holds %v", a.Meeples, b.LastPlacedTile.Meeples)
+	}
+	if err := b.UndoMeeple("a"); err != ErrNoMeeplePlaced {
+		t.Fatalf("second UndoMeeple = %v, want ErrNoMeeplePlaced", err)
+	}
+
+	// The meeple can go somewhere else, and stays once the turn ended
+	if err := b.PlaceMeeple("a", 2); err != nil {
+		t.Fatalf("PlaceMeeple after undoing: %v", err)
+	}
+	b.NextTurn()
+	if err := b.UndoMeeple("a"); err == nil {
+		t.Fatalf("UndoMeeple after the turn ended succeeded")
+	}
+	if a := b.GetPlayer("a"); a.Meeples != DefaultMeeplesPerPlayer-1 {
+		t.Fatalf("a has %d meeples after the turn ended, want one on the board", a.Meeples)
+	}
+}
+
+func TestUndoScoredMeeple(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// The meeple on the city the cap completes scores right away
+	playMeeple(t, b, kindCityCap, Position{0, -1}, 180, 0)
+	if err := b.UndoMeeple("a"); err != ErrNoMeeplePlaced {
+		t.Fatalf("UndoMeeple of a scored meeple = %v, want ErrNoMeeplePlaced", err)
+	}
+	if a := b.GetPlayer("a"); a.Score != 4 || a.Meeples != DefaultMeeplesPerPlayer {
+		t.Fatalf("a has %d points and %d meeples, want 4 and all meeples", a.Score, a.Meeples)
+	}
+}
+
+func TestPlaceTileRotation(t *testing.T) {
+	tests := []struct {
+		rotation int
+		want     int // stored rotation, -1 when rejected
+	}{
+		{rotation: 270, want: 270},
+		{rotation: 90, want: 90},
+		{rotation: -90, want: 270},
+		{rotation: 450, want: 90},
+		{rotation: 45, want: -1},
+		{rotation: 100, want: -1},
+	}
+
+	for _, tt := range tests {
+		b := newStartedBoard(t)
+
+		// A straight road running east-west continues the starting tile's
+		drawKind(t, b, kindStraightRoad)
+		err := b.PlaceTile(Position{1, 0}, tt.rotation)
+		if tt.want < 0 {
+			if !errors.Is(err, ErrInvalidRotation) || len(b.Tiles) != 1 {
+				t.Fatalf("PlaceTile with rotation %d = %v, want ErrInvalidRotation and no tile placed", tt.rotation, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("PlaceTile with rotation %d: %v", tt.rotation, err)
+		}
+		if got := b.Tiles[Position{1, 0}].Rotation; got != tt.want {
+			t.Fatalf("rotation %d was stored as %d, want %d", tt.rotation, got, tt.want)
+		}
+	}
+}
+
+func TestAddPlayerLimit(t *testing.T) {
+	b := NewBoardWithSeed(1)
+	for i := 1; i <= MaxPlayers; i++ {
+		if err := b.AddPlayer(&Player{ID: fmt.Sprint(i), Name: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("AddPlayer %d: %v", i, err)
+		}
+	}
+	if err := b.AddPlayer(&Player{ID: "extra", Name: "extra"}); err == nil {
+		t.Fatalf("AddPlayer beyond %d players succeeded", MaxPlayers)
+	}
+	if err := b.StartGame(); err != nil || len(b.TurnOrder) != MaxPlayers {
+		t.Fatalf("StartGame = %v with turn order %v, want all %d players", err, b.TurnOrder, MaxPlayers)
+	}
+}
+
+// seatedBoard returns a started board with the seed and players "a" to "d"
+func seatedBoard(t *testing.T, seed int64, randomize bool) *Board {
+	t.Helper()
+
+	b := NewBoardWithSeed(seed)
+	b.RandomizeSeating = randomize
+	for _, id := range []string{"a", "b", "c", "d"} {
+		if err := b.AddPlayer(&Player{ID: id, Name: id}); err != nil {
+			t.Fatalf("AddPlayer(%q): %v", id, err)
+		}
+	}
+	if err := b.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	return b
+}
+
+func TestRandomizeSeating(t *testing.T) {
+	tests := []struct {
+		randomize bool
+		want      []string
+	}{
+		{randomize: false, want: []string{"a", "b", "c", "d"}},
+		// Seed 2 shuffles the first player to the end
+		{randomize: true, want: []string{"b", "c", "d", "a"}},
+	}
+
+	for _, tt := range tests {
+		b := seatedBoard(t, 2, tt.randomize)
+		if !reflect.DeepEqual(b.TurnOrder, tt.want) {
+			t.Fatalf("randomize %v: turn order %v, want %v", tt.randomize, b.TurnOrder, tt.want)
+		}
+
+		// Turns follow the order, starting over after the last player
+		for i := 0; i <= len(tt.want); i++ {
+			if id := b.GetCurrentPlayer().ID; id != tt.want[i%len(tt.want)] {
+				t.Fatalf("randomize %v: turn %d went to %s, want %s", tt.randomize, i, id, tt.want[i%len(tt.want)])
+			}
+			b.NextTurn()
+		}
+	}
+}
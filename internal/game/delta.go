@@ -0,0 +1,57 @@
+package game
+
+// GameStateDelta is the part of the game state that changed since a board
+// version. Only the tiles placed or whose figures changed are included; the
+// rest of the turn state is small and always sent whole.
+type GameStateDelta struct {
+	FromVersion   int            `json:"fromVersion"` // version the delta applies on top of
+	Version       int            `json:"version"`     // version after applying the delta
+	Tiles         TileMap        `json:"tiles"`
+	CurrentTile   *Tile          `json:"currentTile"`
+	Players       []*Player      `json:"players"`
+	CurrentPlayer int            `json:"currentPlayer"`
+	TurnOrder     []string       `json:"turnOrder"`
+	GameStarted   bool           `json:"gameStarted"`
+	GameEnded     bool           `json:"gameEnded"`
+	Scores        map[string]int `json:"scores"`
+	ScoreDetails  map[string]PlayerScoreDetail `json:"scoreDetails"`
+	TilesLeft     int            `json:"tilesLeft"`
+	RemainingTiles map[int]int   `json:"remainingTiles"`
+	Phase         string         `json:"phase,omitempty"` // turn phase, set by the room while the game runs
+}
+
+// markTileChanged bumps the board version and stamps the tile at pos with it
+func (b *Board) markTileChanged(pos Position) {
+	if b.tileVersions == nil {
+		b.tileVersions = make(map[Position]int)
+	}
+	b.Version++
+	b.tileVersions[pos] = b.Version
+}
+
+// StateSince returns a copy of the changes made to the board after the given
+// version. Version 0 includes every tile, like a full state.
+func (b *Board) StateSince(version int) GameStateDelta {
+	tiles := make(TileMap)
+	for pos, tile := range b.Tiles {
+		if version == 0 || b.tileVersions[pos] > version {
+			tiles[pos] = tile.copy()
+		}
+	}
+	
+	return GameStateDelta{
+		FromVersion:   version,
+		Version:       b.Version,
+		Tiles:         tiles,
+		CurrentTile:   b.CurrentTile,
+		Players:       b.copyPlayers(),
+		CurrentPlayer: b.CurrentPlayer,
+		TurnOrder:     b.copyTurnOrder(),
+		GameStarted:   b.GameStarted,
+		GameEnded:     b.GameEnded,
+		Scores:        b.copyScores(),
+		ScoreDetails:  b.GetScoreDetails(),
+		TilesLeft:     len(b.TileDeck),
+		RemainingTiles: b.RemainingTileCounts(),
+	}
+}
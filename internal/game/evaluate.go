@@ -0,0 +1,457 @@
+package game
+
+// PlacementEffect describes what placing the current tile at a position does
+// to the roads and cities around it, from one player's point of view
+type PlacementEffect struct {
+	CompletedFeatures int `json:"completedFeatures"` // roads and cities the placement completes
+	CompletedOwn      int `json:"completedOwn"`      // completed features the player wins
+	ExtendedOwn       int `json:"extendedOwn"`       // incomplete features of the player it extends
+	Points            int `json:"points"`            // points the player scores right away
+}
+
+// EvaluatePlacement works out the effect of placing the current tile without
+// touching the board
+func (b *Board) EvaluatePlacement(option PlacementOption, playerID string) PlacementEffect {
+	effect := PlacementEffect{}
+	if b.CurrentTile == nil {
+		return effect
+	}
+	
+	candidate := &PlacedTile{
+		Tile:     b.CurrentTile,
+		Position: option.Position,
+		Rotation: option.Rotation,
+	}
+	
+	// Segments of the candidate joined through placed tiles are one feature
+	counted := make(map[int]bool)
+	for i, feature := range candidate.Tile.Features {
+		if (feature.Type != RoadFeature && feature.Type != CityFeature) || counted[i] {
+			continue
+		}
+	
+		existing := b.joinedFeatures(candidate, i)
+		for _, id := range b.candidateSegments(candidate, i, existing) {
+			counted[id] = true
+		}
+		claimants := b.featureClaimants(existing)
+		owned := false
+		for _, claimant := range majorityPlayers(claimants) {
+			if claimant == playerID {
+				owned = true
+			}
+		}
+	
+		if !b.isJoinedFeatureComplete(candidate, i, existing) {
+			if owned {
+				effect.ExtendedOwn++
+			}
+			continue
+		}
+	
+		effect.CompletedFeatures++
+		if owned {
+			effect.CompletedOwn++
+			effect.Points += b.joinedFeatureValue(candidate, i, existing)
+		}
+	}
+	
+	return effect
+}
+
+// IsFeatureClaimableAt checks if a meeple could go on a feature of the
+// current tile once it is placed as given, i.e. no meeple sits anywhere on
+// the features it would join
+func (b *Board) IsFeatureClaimableAt(option PlacementOption, featureID int) bool {
+	if b.CurrentTile == nil || featureID < 0 || featureID >= len(b.CurrentTile.Features) {
+		return false
+	}
+	
+	candidate := &PlacedTile{
+		Tile:     b.CurrentTile,
+		Position: option.Position,
+		Rotation: option.Rotation,
+	}
+	return len(b.featureClaimants(b.joinedFeatures(candidate, featureID))) == 0
+}
+
+// joinedFeatures returns the placed segments a feature of a candidate tile
+// would join. The placed segments can lead back to another segment of the
+// candidate, whose neighbors then join the feature too.
+func (b *Board) joinedFeatures(candidate *PlacedTile, featureID int) []FeatureRef {
+	seen := make(map[FeatureRef]bool)
+	joined := make([]FeatureRef, 0)
+	done := make(map[int]bool)
+	
+	for {
+		added := false
+		for _, id := range b.candidateSegments(candidate, featureID, joined) {
+			if done[id] {
+				continue
+			}
+			done[id] = true
+			added = true
+			
+			for _, neighbor := range adjacentSegments(b.Tiles, candidate, id) {
+				for _, ref := range b.GetConnectedFeature(neighbor.Pos, neighbor.FeatureID) {
+					if !seen[ref] {
+						seen[ref] = true
+						joined = append(joined, ref)
+					}
+				}
+			}
+		}
+		if !added {
+			return joined
+		}
+	}
+}
+
+// candidateSegments returns the features of a candidate tile that are part
+// of the same feature as featureID once placed: itself, and those next to
+// one of the joined placed segments
+func (b *Board) candidateSegments(candidate *PlacedTile, featureID int, joined []FeatureRef) []int {
+	inFeature := make(map[FeatureRef]bool, len(joined))
+	for _, ref := range joined {
+		inFeature[ref] = true
+	}
+	
+	segments := []int{featureID}
+	for i := range candidate.Tile.Features {
+		if i == featureID {
+			continue
+		}
+		for _, neighbor := range adjacentSegments(b.Tiles, candidate, i) {
+			if inFeature[neighbor] {
+				segments = append(segments, i)
+				break
+			}
+		}
+	}
+	return segments
+}
+
+// isJoinedFeatureComplete checks if a candidate segment and the placed
+// segments it joins would form a complete feature
+func (b *Board) isJoinedFeatureComplete(candidate *PlacedTile, featureID int, joined []FeatureRef) bool {
+	return b.joinedOpenEdges(candidate, featureID, joined) == 0
+}
+
+// joinedOpenEdges counts the edges of a candidate segment, the other
+// candidate segments it loops back to and the placed segments it joins that
+// don't continue into a neighboring tile yet
+func (b *Board) joinedOpenEdges(candidate *PlacedTile, featureID int, joined []FeatureRef) int {
+	featureType := candidate.Tile.Features[featureID].Type
+	
+	// tileAt sees the board as if the candidate were placed
+	tileAt := func(pos Position) *PlacedTile {
+		if pos == candidate.Position {
+			return candidate
+		}
+		return b.Tiles[pos]
+	}
+	
+	edgeClosed := func(pt *PlacedTile, dir Direction) bool {
+		neighbor := tileAt(pt.Position.neighbor(dir))
+		return neighbor != nil && neighbor.featureAtEdge(dir.opposite(), featureType) >= 0
+	}
+	
+	open := 0
+	for _, id := range b.candidateSegments(candidate, featureID, joined) {
+		for _, dir := range candidate.FeatureEdges(id) {
+			if !edgeClosed(candidate, dir) {
+				open++
+			}
+		}
+	}
+	for _, ref := range joined {
+		for _, dir := range b.Tiles[ref.Pos].FeatureEdges(ref.FeatureID) {
+			if !edgeClosed(b.Tiles[ref.Pos], dir) {
+				open++
+			}
+		}
+	}
+	
+	return open
+}
+
+// OpenEdgesAt returns how many open ends a feature of the current tile would
+// have once placed as given, counting the features it joins. For a
+// monastery it is the number of empty positions around it. Fields never
+// complete and return -1.
+func (b *Board) OpenEdgesAt(option PlacementOption, featureID int) int {
+	if b.CurrentTile == nil || featureID < 0 || featureID >= len(b.CurrentTile.Features) {
+		return -1
+	}
+	
+	switch b.CurrentTile.Features[featureID].Type {
+	case MonasteryFeature:
+		return 8 - b.countMonasteryNeighbors(option.Position)
+	case FieldFeature:
+		return -1
+	}
+	
+	candidate := &PlacedTile{
+		Tile:     b.CurrentTile,
+		Position: option.Position,
+		Rotation: option.Rotation,
+	}
+	return b.joinedOpenEdges(candidate, featureID, b.joinedFeatures(candidate, featureID))
+}
+
+// joinedFeatureValue returns the points a candidate segment and the placed
+// segments it joins would be worth once complete
+func (b *Board) joinedFeatureValue(candidate *PlacedTile, featureID int, joined []FeatureRef) int {
+	tiles := countTiles(joined) + 1
+	if candidate.Tile.Features[featureID].Type == RoadFeature {
+		return tiles
+	}
+	
+	shields := b.countShields(joined)
+	if candidate.Tile.HasShield || candidate.Tile.Features[featureID].HasShield {
+		shields++
+	}
+	return 2*tiles + 2*shields
+}
+
+// FeatureValueAt returns the points a road or city of the current tile would
+// be worth once placed as given and completed, counting the features it
+// joins. Other features return 0.
+func (b *Board) FeatureValueAt(option PlacementOption, featureID int) int {
+	if b.CurrentTile == nil || featureID < 0 || featureID >= len(b.CurrentTile.Features) {
+		return 0
+	}
+	
+	featureType := b.CurrentTile.Features[featureID].Type
+	if featureType != RoadFeature && featureType != CityFeature {
+		return 0
+	}
+	
+	candidate := &PlacedTile{
+		Tile:     b.CurrentTile,
+		Position: option.Position,
+		Rotation: option.Rotation,
+	}
+	return b.joinedFeatureValue(candidate, featureID, b.joinedFeatures(candidate, featureID))
+}
+
+// FieldCitiesAt returns the number of completed and incomplete cities a
+// field of the current tile would border once placed as given, counting the
+// fields it joins
+func (b *Board) FieldCitiesAt(option PlacementOption, featureID int) (completed, incomplete int) {
+	if b.CurrentTile == nil || featureID < 0 || featureID >= len(b.CurrentTile.Features) ||
+		b.CurrentTile.Features[featureID].Type != FieldFeature {
+		return 0, 0
+	}
+	
+	view := b.withCandidate(option)
+	return view.borderedCities(view.GetConnectedFeature(option.Position, featureID))
+}
+
+// ScoreSimulation is the outcome of placing the current tile at a position
+type ScoreSimulation struct {
+	Points       map[string]int `json:"points"`       // points each player scores right away
+	FreedMeeples map[string]int `json:"freedMeeples"` // meeples each player gets back
+	FieldPoints  map[string]int `json:"fieldPoints"`  // points each player's farmers would score at game end
+}
+
+// SimulateScore works out what placing the current tile as given would
+// score, using the same rules as ScoreCompletedFeatures and FieldScores but
+// without touching the board
+func (b *Board) SimulateScore(option PlacementOption) ScoreSimulation {
+	sim := ScoreSimulation{
+		Points:       make(map[string]int),
+		FreedMeeples: make(map[string]int),
+		FieldPoints:  make(map[string]int),
+	}
+	if b.CurrentTile == nil {
+		return sim
+	}
+	
+	view := b.withCandidate(option)
+	award := func(awards map[string]int, claimants []string) {
+		for playerID, points := range awards {
+			sim.Points[playerID] += points
+		}
+		for _, playerID := range claimants {
+			sim.FreedMeeples[playerID]++
+		}
+	}
+	
+	scored := make(map[FeatureRef]bool)
+	for i, feature := range b.CurrentTile.Features {
+		if feature.Type != RoadFeature && feature.Type != CityFeature {
+			continue
+		}
+	
+		connected := view.GetConnectedFeature(option.Position, i)
+		key := featureKey(connected)
+		if scored[key] || !view.isFeatureComplete(connected) {
+			continue
+		}
+		scored[key] = true
+		award(view.featureAwards(connected, view.featureValue(connected)), view.featureClaimants(connected))
+	}
+	
+	for _, pos := range surroundingPositions(option.Position) {
+		tile, exists := view.Tiles[pos]
+		if !exists || view.countMonasteryNeighbors(pos) < 8 {
+			continue
+		}
+		for i, feature := range tile.Tile.Features {
+			if feature.Type != MonasteryFeature {
+				continue
+			}
+			claimants := view.featureClaimants([]FeatureRef{{Pos: pos, FeatureID: i}})
+			if len(claimants) > 0 {
+				award(map[string]int{claimants[0]: 9}, claimants)
+			}
+		}
+	}
+	
+	sim.FieldPoints = view.FieldScores()
+	return sim
+}
+
+// BlockedFeaturesAt counts the incomplete roads and cities held by other
+// players that placing the current tile as given would hem in: one of their
+// open ends would lead into an empty position closed in on three or more
+// sides, which few tiles can fill
+func (b *Board) BlockedFeaturesAt(option PlacementOption, playerID string) int {
+	if b.CurrentTile == nil {
+		return 0
+	}
+	
+	view := b.withCandidate(option)
+	blocked := make(map[FeatureRef]bool)
+	for dir := North; dir <= West; dir++ {
+		hole := option.Position.neighbor(dir)
+		if _, exists := view.Tiles[hole]; exists {
+			continue
+		}
+	
+		sides := make(map[Direction]*PlacedTile)
+		for side := North; side <= West; side++ {
+			if neighbor, exists := view.Tiles[hole.neighbor(side)]; exists {
+				sides[side] = neighbor
+			}
+		}
+		if len(sides) < 3 {
+			continue
+		}
+	
+		for side, neighbor := range sides {
+			for _, featureType := range []FeatureType{RoadFeature, CityFeature} {
+				featureID := neighbor.featureAtEdge(side.opposite(), featureType)
+				if featureID < 0 {
+					continue
+				}
+	
+				feature := view.GetConnectedFeature(neighbor.Position, featureID)
+				key := featureKey(feature)
+				if blocked[key] || view.isFeatureComplete(feature) {
+					continue
+				}
+	
+				owners := majorityPlayers(view.featureClaimants(feature))
+				held := len(owners) > 0
+				for _, owner := range owners {
+					if owner == playerID {
+						held = false
+					}
+				}
+				if held {
+					blocked[key] = true
+				}
+			}
+		}
+	}
+	
+	return len(blocked)
+}
+
+// StrandedFeaturesAt counts the incomplete roads and cities claimed by the
+// player that placing the current tile as given would make impossible to
+// complete: one of their open ends would lead into an empty position that
+// no tile left in the deck fits, while one did before
+func (b *Board) StrandedFeaturesAt(option PlacementOption, playerID string) int {
+	if b.CurrentTile == nil {
+		return 0
+	}
+	
+	view := b.withCandidate(option)
+	stranded := make(map[FeatureRef]bool)
+	for dir := North; dir <= West; dir++ {
+		hole := option.Position.neighbor(dir)
+		if _, exists := view.Tiles[hole]; exists {
+			continue
+		}
+		if b.canFillFromDeck(view.Tiles, hole) || !b.canFillFromDeck(b.Tiles, hole) {
+			continue
+		}
+		
+		for side := North; side <= West; side++ {
+			neighbor, exists := view.Tiles[hole.neighbor(side)]
+			if !exists {
+				continue
+			}
+			for _, featureType := range []FeatureType{RoadFeature, CityFeature} {
+				featureID := neighbor.featureAtEdge(side.opposite(), featureType)
+				if featureID < 0 {
+					continue
+				}
+				
+				feature := view.GetConnectedFeature(neighbor.Position, featureID)
+				for _, claimant := range view.featureClaimants(feature) {
+					if claimant == playerID {
+						stranded[featureKey(feature)] = true
+					}
+				}
+			}
+		}
+	}
+	
+	return len(stranded)
+}
+
+// canFillFromDeck checks if any tile left in the deck fits the empty
+// position in some rotation
+func (b *Board) canFillFromDeck(tiles TileMap, pos Position) bool {
+	tried := make(map[[4]TileEdge]bool)
+	for _, tile := range b.TileDeck {
+		edges := [4]TileEdge{tile.North, tile.East, tile.South, tile.West}
+		if tried[edges] {
+			continue
+		}
+		tried[edges] = true
+		
+		for rotation := 0; rotation < 360; rotation += 90 {
+			candidate := &PlacedTile{Tile: tile, Position: pos, Rotation: rotation}
+			if candidate.CanPlaceAt(tiles, pos) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withCandidate returns a view of the board with the current tile placed as
+// given. The view shares everything but its tile map with the board and must
+// only be read.
+func (b *Board) withCandidate(option PlacementOption) *Board {
+	tiles := make(TileMap, len(b.Tiles)+1)
+	for pos, tile := range b.Tiles {
+		tiles[pos] = tile
+	}
+	tiles[option.Position] = &PlacedTile{
+		Tile:     b.CurrentTile,
+		Position: option.Position,
+		Rotation: option.Rotation,
+	}
+	
+	return &Board{
+		Tiles:   tiles,
+		Players: b.Players,
+		Scores:  b.Scores,
+	}
+}
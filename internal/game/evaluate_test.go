@@ -0,0 +1,60 @@
+package game
+
+import "testing"
+
+func TestIsFeatureClaimableAtThroughTile(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// The monastery's field joins the starting tile's fields around the
+	// road, and "a" farms a field below that only touches its west
+	playTurns(t, b,
+		tilePlay{kindMonasteryRoad, Position{1, 0}, 90},
+		tilePlay{kindCityCap, Position{0, 1}, 270},
+	)
+	playMeeple(t, b, kindCityCap, Position{-1, 1}, 90, 1)
+	b.NextTurn()
+
+	// West of the starting tile, a road's north field only touches the
+	// unclaimed field, but its south field joins it to the farmer's
+	drawKind(t, b, kindStraightRoad)
+	option := PlacementOption{Position: Position{-1, 0}, Rotation: 90}
+	for featureID := 1; featureID <= 2; featureID++ {
+		if b.IsFeatureClaimableAt(option, featureID) {
+			t.Fatalf("IsFeatureClaimableAt(%d) = true for a field joined to a claimed one", featureID)
+		}
+	}
+
+	if err := b.PlaceTile(option.Position, option.Rotation); err != nil {
+		t.Fatalf("PlaceTile: %v", err)
+	}
+	if err := b.PlaceMeeple("b", 2); err != ErrFeatureClaimed {
+		t.Fatalf("PlaceMeeple = %v, want ErrFeatureClaimed", err)
+	}
+}
+
+func TestSimulateScore(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// "a" claims the starting tile's road, ended by a junction on the east,
+	// and "b" farms above the city it closes
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 2)
+	b.NextTurn()
+	playMeeple(t, b, kindCityCap, Position{0, -1}, 180, 1)
+	b.NextTurn()
+
+	// A junction on the west would complete the road
+	drawKind(t, b, kindRoadJunction)
+	tiles := len(b.Tiles)
+	sim := b.SimulateScore(PlacementOption{Position: Position{-1, 0}, Rotation: 0})
+	if sim.Points["a"] != 3 || sim.FreedMeeples["a"] != 1 || len(sim.Points) != 1 {
+		t.Fatalf("SimulateScore() = %+v, want 3 points and a meeple back for a", sim)
+	}
+	if sim.FieldPoints["b"] != 3 || sim.FieldPoints["a"] != 0 {
+		t.Fatalf("field points = %v, want 3 for b's farmer", sim.FieldPoints)
+	}
+
+	a := b.GetPlayer("a")
+	if len(b.Tiles) != tiles || a.Score != 0 || a.Meeples != DefaultMeeplesPerPlayer-1 {
+		t.Fatalf("SimulateScore changed the board")
+	}
+}
@@ -0,0 +1,318 @@
+package game
+
+// FeatureRef identifies a feature on a placed tile
+type FeatureRef struct {
+	Pos       Position `json:"position"`
+	FeatureID int      `json:"featureId"`
+}
+
+// rotateDirection returns the board direction of a tile edge after rotation
+func rotateDirection(dir Direction, rotation int) Direction {
+	return Direction((int(dir) + rotation/90) % 4)
+}
+
+// opposite returns the opposite direction
+func (d Direction) opposite() Direction {
+	return Direction((int(d) + 2) % 4)
+}
+
+// neighbor returns the position next to pos in the given direction
+func (pos Position) neighbor(dir Direction) Position {
+	switch dir {
+	case North:
+		return Position{pos.X, pos.Y - 1}
+	case East:
+		return Position{pos.X + 1, pos.Y}
+	case South:
+		return Position{pos.X, pos.Y + 1}
+	default:
+		return Position{pos.X - 1, pos.Y}
+	}
+}
+
+// FeatureEdges returns the board directions a feature touches, taking the
+// tile rotation into account
+func (pt *PlacedTile) FeatureEdges(featureID int) []Direction {
+	if featureID < 0 || featureID >= len(pt.Tile.Features) {
+		return nil
+	}
+
+	edges := make([]Direction, 0, len(pt.Tile.Features[featureID].Edges))
+	for _, dir := range pt.Tile.Features[featureID].Edges {
+		edges = append(edges, rotateDirection(dir, pt.Rotation))
+	}
+	return edges
+}
+
+// featureAtEdge returns the ID of the feature of the given type touching the
+// board direction, or -1 if there is none
+func (pt *PlacedTile) featureAtEdge(dir Direction, featureType FeatureType) int {
+	for i, feature := range pt.Tile.Features {
+		if feature.Type != featureType {
+			continue
+		}
+		for _, edge := range pt.FeatureEdges(i) {
+			if edge == dir {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// FieldHalves returns the board half edges a field touches, both halves of
+// each side it covers plus the halves of road sides, taking the tile
+// rotation into account
+func (pt *PlacedTile) FieldHalves(featureID int) []HalfEdge {
+	if featureID < 0 || featureID >= len(pt.Tile.Features) {
+		return nil
+	}
+	
+	feature := pt.Tile.Features[featureID]
+	halves := make([]HalfEdge, 0, 2*len(feature.Edges)+len(feature.HalfEdges))
+	for _, dir := range pt.FeatureEdges(featureID) {
+		halves = append(halves, halvesOf(dir)...)
+	}
+	for _, half := range feature.HalfEdges {
+		halves = append(halves, half.rotate(pt.Rotation))
+	}
+	return halves
+}
+
+// fieldAtHalf returns the ID of the field touching the board half edge, or
+// -1 if there is none
+func (pt *PlacedTile) fieldAtHalf(half HalfEdge) int {
+	for i, feature := range pt.Tile.Features {
+		if feature.Type != FieldFeature {
+			continue
+		}
+		for _, h := range pt.FieldHalves(i) {
+			if h == half {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// adjacentSegments returns the segments of neighboring tiles a feature of pt
+// continues into. Only segments of the same type meeting at an edge count;
+// fields meet at half edges.
+func adjacentSegments(tiles map[Position]*PlacedTile, pt *PlacedTile, featureID int) []FeatureRef {
+	segments := make([]FeatureRef, 0)
+	
+	if pt.Tile.Features[featureID].Type == FieldFeature {
+		for _, half := range pt.FieldHalves(featureID) {
+			neighbor, exists := tiles[pt.Position.neighbor(half.direction())]
+			if !exists {
+				continue
+			}
+			if neighborField := neighbor.fieldAtHalf(half.opposite()); neighborField >= 0 {
+				segments = append(segments, FeatureRef{Pos: neighbor.Position, FeatureID: neighborField})
+			}
+		}
+		return segments
+	}
+	
+	featureType := pt.Tile.Features[featureID].Type
+	for _, dir := range pt.FeatureEdges(featureID) {
+		neighbor, exists := tiles[pt.Position.neighbor(dir)]
+		if !exists {
+			continue
+		}
+		if neighborFeature := neighbor.featureAtEdge(dir.opposite(), featureType); neighborFeature >= 0 {
+			segments = append(segments, FeatureRef{Pos: neighbor.Position, FeatureID: neighborFeature})
+		}
+	}
+	return segments
+}
+
+// FeatureGraph links the feature segments of neighboring tiles that share
+// an edge, so a road or city spanning many tiles can be walked as one feature
+type FeatureGraph struct {
+	links map[FeatureRef][]FeatureRef
+}
+
+// NewFeatureGraph creates an empty feature graph
+func NewFeatureGraph() *FeatureGraph {
+	return &FeatureGraph{
+		links: make(map[FeatureRef][]FeatureRef),
+	}
+}
+
+// AddTile links the features of a placed tile to the matching features of
+// the tiles around it. Only segments of the same type meeting at an edge are
+// linked, so mismatched neighbors in relaxed placement stay separate.
+func (g *FeatureGraph) AddTile(tiles map[Position]*PlacedTile, pt *PlacedTile) {
+	for i := range pt.Tile.Features {
+		ref := FeatureRef{Pos: pt.Position, FeatureID: i}
+		for _, neighbor := range adjacentSegments(tiles, pt, i) {
+			g.link(ref, neighbor)
+		}
+	}
+}
+
+// link joins two feature segments in both directions
+func (g *FeatureGraph) link(a, b FeatureRef) {
+	for _, existing := range g.links[a] {
+		if existing == b {
+			return
+		}
+	}
+	g.links[a] = append(g.links[a], b)
+	g.links[b] = append(g.links[b], a)
+}
+
+// Connected returns every segment linked to start, including start itself
+func (g *FeatureGraph) Connected(start FeatureRef) []FeatureRef {
+	visited := map[FeatureRef]bool{start: true}
+	queue := []FeatureRef{start}
+	result := make([]FeatureRef, 0)
+	
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		result = append(result, current)
+		
+		for _, ref := range g.links[current] {
+			if !visited[ref] {
+				visited[ref] = true
+				queue = append(queue, ref)
+			}
+		}
+	}
+	
+	return result
+}
+
+// featureGraph returns the board's feature graph, building it from the
+// placed tiles the first time it is needed
+func (b *Board) featureGraph() *FeatureGraph {
+	if b.graph == nil {
+		b.graph = NewFeatureGraph()
+		for _, tile := range b.Tiles {
+			b.graph.AddTile(b.Tiles, tile)
+		}
+	}
+	return b.graph
+}
+
+// GetConnectedFeature returns every feature segment connected to the given
+// feature, including itself. Monasteries never connect to other tiles.
+func (b *Board) GetConnectedFeature(pos Position, featureID int) []FeatureRef {
+	start, exists := b.Tiles[pos]
+	if !exists || featureID < 0 || featureID >= len(start.Tile.Features) {
+		return nil
+	}
+	
+	return b.featureGraph().Connected(FeatureRef{Pos: pos, FeatureID: featureID})
+}
+
+// featureClaimants returns the IDs of the players with a meeple anywhere on
+// the given connected feature, one entry per meeple. Builders don't claim.
+func (b *Board) featureClaimants(feature []FeatureRef) []string {
+	claimants := make([]string, 0)
+	for _, ref := range feature {
+		for _, meeple := range b.Tiles[ref.Pos].Meeples {
+			if meeple.FeatureID == ref.FeatureID && meeple.Type == NormalMeeple {
+				claimants = append(claimants, meeple.PlayerID)
+			}
+		}
+	}
+	return claimants
+}
+
+// isFeatureClaimed checks if any meeple sits on the connected feature
+func (b *Board) isFeatureClaimed(pos Position, featureID int) bool {
+	return len(b.featureClaimants(b.GetConnectedFeature(pos, featureID))) > 0
+}
+
+// scoredWhenPlaced checks if a feature of the tile at pos is one of the
+// features held by someone that the tile completed this turn
+func (b *Board) scoredWhenPlaced(pos Position, featureID int) bool {
+	if b.LastPlacedTile == nil || b.LastPlacedTile.Position != pos {
+		return false
+	}
+	for _, id := range b.scoredFeatures {
+		if id == featureID {
+			return true
+		}
+	}
+	return false
+}
+
+// countTiles returns the number of distinct tiles a connected feature spans
+func countTiles(feature []FeatureRef) int {
+	positions := make(map[Position]bool)
+	for _, ref := range feature {
+		positions[ref.Pos] = true
+	}
+	return len(positions)
+}
+
+// hasBuilder checks if the player's builder sits on the connected feature
+// outside of the given position
+func (b *Board) hasBuilder(feature []FeatureRef, playerID string, exclude Position) bool {
+	for _, ref := range feature {
+		if ref.Pos == exclude {
+			continue
+		}
+		for _, meeple := range b.Tiles[ref.Pos].Meeples {
+			if meeple.FeatureID == ref.FeatureID && meeple.Type == BuilderMeeple && meeple.PlayerID == playerID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetClaimableFeatures returns the IDs of the features of the tile at pos
+// that a meeple could go on, i.e. that no meeple sits on anywhere along
+// their connected extent and that weren't scored for their holders this
+// turn. It returns nil if no tile is placed at pos.
+func (b *Board) GetClaimableFeatures(pos Position) []int {
+	placedTile, exists := b.Tiles[pos]
+	if !exists {
+		return nil
+	}
+	
+	claimable := make([]int, 0, len(placedTile.Tile.Features))
+	for i := range placedTile.Tile.Features {
+		if !b.isFeatureClaimed(pos, i) && !b.scoredWhenPlaced(pos, i) {
+			claimable = append(claimable, i)
+		}
+	}
+	return claimable
+}
+
+// MeepleOption describes a feature of the last placed tile as a meeple target
+type MeepleOption struct {
+	FeatureID        int         `json:"featureId"`
+	Type             FeatureType `json:"type"`
+	Claimable        bool        `json:"claimable"`
+	NetworkTileCount int         `json:"networkTileCount"`
+	CurrentClaimants []string    `json:"currentClaimants"`
+}
+
+// GetMeepleOptions describes every feature of the tile placed this turn
+func (b *Board) GetMeepleOptions() ([]MeepleOption, error) {
+	if b.LastPlacedTile == nil {
+		return nil, ErrNoTilePlaced
+	}
+
+	pos := b.LastPlacedTile.Position
+	options := make([]MeepleOption, 0, len(b.LastPlacedTile.Tile.Features))
+	for i, feature := range b.LastPlacedTile.Tile.Features {
+		connected := b.GetConnectedFeature(pos, i)
+		claimants := b.featureClaimants(connected)
+		options = append(options, MeepleOption{
+			FeatureID:        i,
+			Type:             feature.Type,
+			Claimable:        len(claimants) == 0,
+			NetworkTileCount: countTiles(connected),
+			CurrentClaimants: claimants,
+		})
+	}
+
+	return options, nil
+}
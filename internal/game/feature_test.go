@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetMeepleOptions(t *testing.T) {
+	b := newStartedBoard(t)
+	if _, err := b.GetMeepleOptions(); !errors.Is(err, ErrNoTilePlaced) {
+		t.Fatalf("GetMeepleOptions before placing = %v, want ErrNoTilePlaced", err)
+	}
+
+	// "a" claims the road leaving the junction south, "b" continues it
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 1)
+	b.NextTurn()
+	play(t, b, kindRoadCurve, Position{1, 1}, 90)
+
+	options, err := b.GetMeepleOptions()
+	if err != nil {
+		t.Fatalf("GetMeepleOptions: %v", err)
+	}
+	if len(options) != len(b.LastPlacedTile.Tile.Features) {
+		t.Fatalf("got %d options for %d features", len(options), len(b.LastPlacedTile.Tile.Features))
+	}
+	for i, option := range options {
+		if option.FeatureID != i || option.Type != b.LastPlacedTile.Tile.Features[i].Type {
+			t.Fatalf("option %d = %+v, does not describe feature %d", i, option, i)
+		}
+		if option.Type != RoadFeature {
+			if !option.Claimable || len(option.CurrentClaimants) > 0 {
+				t.Fatalf("field option %+v, want claimable", option)
+			}
+			continue
+		}
+		if option.Claimable || !reflect.DeepEqual(option.CurrentClaimants, []string{"a"}) || option.NetworkTileCount != 2 {
+			t.Fatalf("road option %+v, want claimed by a over 2 tiles", option)
+		}
+	}
+}
+
+func TestPlaceMeepleOnClaimedFeature(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// "a" claims the starting tile's road from a junction east of it
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 2)
+	b.NextTurn()
+
+	// The road continues west, two tiles away from a's meeple
+	play(t, b, kindStraightRoad, Position{-1, 0}, 90)
+	if err := b.PlaceMeeple("b", 0); !errors.Is(err, ErrFeatureClaimed) {
+		t.Fatalf("PlaceMeeple on the claimed road = %v, want ErrFeatureClaimed", err)
+	}
+	if b.GetPlayer("b").Meeples != DefaultMeeplesPerPlayer || len(b.LastPlacedTile.Meeples) != 0 {
+		t.Fatalf("the refused meeple was taken from the supply or placed")
+	}
+	if err := b.PlaceMeeple("b", 1); err != nil {
+		t.Fatalf("PlaceMeeple on the free field: %v", err)
+	}
+}
+
+func TestPlaceMeepleOnFeatureScoredThisTurn(t *testing.T) {
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 2)
+	b.NextTurn()
+
+	// b's junction closes a's road, which scores before b's meeple phase
+	play(t, b, kindRoadJunction, Position{-1, 0}, 0)
+	if score := b.GetPlayer("a").Score; score != 3 {
+		t.Fatalf("a scored %d for the road, want 3", score)
+	}
+	for _, featureID := range b.GetClaimableFeatures(Position{-1, 0}) {
+		if featureID == 0 {
+			t.Fatalf("the road scored this turn is claimable")
+		}
+	}
+	if err := b.PlaceMeeple("b", 0); !errors.Is(err, ErrFeatureClaimed) {
+		t.Fatalf("PlaceMeeple on the road scored this turn = %v, want ErrFeatureClaimed", err)
+	}
+	if player := b.GetPlayer("b"); player.Score != 0 || player.Meeples != DefaultMeeplesPerPlayer {
+		t.Fatalf("b has %d points and %d meeples, want the road not scored again", player.Score, player.Meeples)
+	}
+
+	// The junction's other roads are still free
+	if err := b.PlaceMeeple("b", 1); err != nil {
+		t.Fatalf("PlaceMeeple on the new road: %v", err)
+	}
+}
+
+func TestPlaceMeepleInvalidFeature(t *testing.T) {
+	b := newStartedBoard(t)
+	play(t, b, kindRoadJunction, Position{1, 0}, 0)
+	b.NextTurn()
+
+	// The monastery has 2 features, the junction placed before it 6
+	play(t, b, kindMonastery, Position{0, 1}, 0)
+	for _, featureID := range []int{-1, 2, 5} {
+		if err := b.PlaceMeeple("b", featureID); !errors.Is(err, ErrInvalidFeature) {
+			t.Fatalf("PlaceMeeple(%d) = %v, want ErrInvalidFeature", featureID, err)
+		}
+	}
+	if b.GetPlayer("b").Meeples != DefaultMeeplesPerPlayer || len(b.Tiles[Position{1, 0}].Meeples) != 0 {
+		t.Fatalf("a refused meeple was taken from the supply or placed")
+	}
+
+	if err := b.PlaceMeeple("b", 0); err != nil {
+		t.Fatalf("PlaceMeeple on the monastery: %v", err)
+	}
+	if len(b.Tiles[Position{0, 1}].Meeples) != 1 {
+		t.Fatalf("the meeple did not go on the tile placed this turn")
+	}
+}
@@ -0,0 +1,64 @@
+package game
+
+// GridCell represents a placed tile in a dense board grid
+type GridCell struct {
+	TileID   int            `json:"tileId"`
+	Rotation int            `json:"rotation"`
+	Meeples  []PlacedMeeple `json:"meeples"`
+}
+
+// BoardGrid is a dense representation of the board. Cells are indexed as
+// Cells[row][column], where row = Y + Offset.Y and column = X + Offset.X,
+// so the top-left tile of the board is at [0][0]. Empty cells are nil.
+type BoardGrid struct {
+	Offset Position      `json:"offset"`
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Cells  [][]*GridCell `json:"cells"`
+}
+
+// GetBoardGrid returns the board as a dense grid normalized to start at [0][0]
+func (b *Board) GetBoardGrid() BoardGrid {
+	if len(b.Tiles) == 0 {
+		return BoardGrid{Cells: [][]*GridCell{}}
+	}
+
+	first := true
+	var minX, minY, maxX, maxY int
+	for pos := range b.Tiles {
+		if first || pos.X < minX {
+			minX = pos.X
+		}
+		if first || pos.Y < minY {
+			minY = pos.Y
+		}
+		if first || pos.X > maxX {
+			maxX = pos.X
+		}
+		if first || pos.Y > maxY {
+			maxY = pos.Y
+		}
+		first = false
+	}
+
+	grid := BoardGrid{
+		Offset: Position{X: -minX, Y: -minY},
+		Width:  maxX - minX + 1,
+		Height: maxY - minY + 1,
+	}
+
+	grid.Cells = make([][]*GridCell, grid.Height)
+	for row := range grid.Cells {
+		grid.Cells[row] = make([]*GridCell, grid.Width)
+	}
+
+	for pos, placedTile := range b.Tiles {
+		grid.Cells[pos.Y+grid.Offset.Y][pos.X+grid.Offset.X] = &GridCell{
+			TileID:   placedTile.Tile.ID,
+			Rotation: placedTile.Rotation,
+			Meeples:  append([]PlacedMeeple{}, placedTile.Meeples...),
+		}
+	}
+
+	return grid
+}
@@ -0,0 +1,51 @@
+package game
+
+import "testing"
+
+func TestGetBoardGrid(t *testing.T) {
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindStraightRoad, Position{-1, 0}, 90, 0)
+	b.NextTurn()
+	play(t, b, kindMonastery, Position{0, 1}, 0)
+	b.NextTurn()
+
+	grid := b.GetBoardGrid()
+	if grid.Offset != (Position{1, 0}) || grid.Width != 2 || grid.Height != 2 {
+		t.Fatalf("grid offset %v size %dx%d, want offset (1, 0) size 2x2", grid.Offset, grid.Width, grid.Height)
+	}
+	if len(grid.Cells) != grid.Height {
+		t.Fatalf("grid has %d rows, want %d", len(grid.Cells), grid.Height)
+	}
+
+	for row, cells := range grid.Cells {
+		if len(cells) != grid.Width {
+			t.Fatalf("row %d has %d cells, want %d", row, len(cells), grid.Width)
+		}
+		for column, cell := range cells {
+			pos := Position{column - grid.Offset.X, row - grid.Offset.Y}
+			placedTile, exists := b.Tiles[pos]
+			if !exists {
+				if cell != nil {
+					t.Fatalf("cell [%d][%d] = %+v, want empty", row, column, cell)
+				}
+				continue
+			}
+			if cell == nil || cell.TileID != placedTile.Tile.ID || cell.Rotation != placedTile.Rotation || len(cell.Meeples) != len(placedTile.Meeples) {
+				t.Fatalf("cell [%d][%d] = %+v, want the tile at %v", row, column, cell, pos)
+			}
+		}
+	}
+
+	// The grid holds copies of the meeples
+	grid.Cells[0][0].Meeples[0].PlayerID = "changed"
+	if b.Tiles[Position{-1, 0}].Meeples[0].PlayerID != "a" {
+		t.Fatalf("changing the grid changed the board")
+	}
+}
+
+func TestGetBoardGridEmpty(t *testing.T) {
+	b := &Board{}
+	if grid := b.GetBoardGrid(); grid.Width != 0 || grid.Height != 0 || grid.Cells == nil {
+		t.Fatalf("grid of an empty board = %+v, want no cells", grid)
+	}
+}
@@ -0,0 +1,87 @@
+package game
+
+import "testing"
+
+// Kinds of the standard set used by tests, as indexes into baseGameTileKinds
+const (
+	kindMonasteryRoad = 0
+	kindMonastery     = 1
+	kindFullCity      = 2
+	kindCityCap       = 3
+	kindCityCorner    = 12
+	kindStraightRoad  = 19
+	kindRoadCurve     = 20
+	kindRoadJunction  = 21
+)
+
+// newTestBoard returns a seeded board with players "a" and "b" that has not
+// started yet
+func newTestBoard(t *testing.T) *Board {
+	t.Helper()
+
+	b := NewBoardWithSeed(1)
+	for _, id := range []string{"a", "b"} {
+		if err := b.AddPlayer(&Player{ID: id, Name: id}); err != nil {
+			t.Fatalf("AddPlayer(%q): %v", id, err)
+		}
+	}
+	return b
+}
+
+// newStartedBoard returns a seeded two player game that has started
+func newStartedBoard(t *testing.T) *Board {
+	t.Helper()
+
+	b := newTestBoard(t)
+	if err := b.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	return b
+}
+
+// drawKind makes a tile of the given kind the current tile. The tile comes
+// out of the deck and the previous current tile goes back in its place, so
+// no tile is lost or duplicated.
+func drawKind(t *testing.T, b *Board, kind int) {
+	t.Helper()
+
+	first := startingTileKind.count
+	for _, k := range baseGameTileKinds[:kind] {
+		first += k.count
+	}
+
+	for i, tile := range b.TileDeck {
+		if tile.KindID() != first {
+			continue
+		}
+		if b.CurrentTile != nil {
+			b.TileDeck[i] = b.CurrentTile
+		} else {
+			b.TileDeck = append(b.TileDeck[:i], b.TileDeck[i+1:]...)
+		}
+		b.CurrentTile = tile
+		return
+	}
+	t.Fatalf("no tile of kind %d left in the deck", kind)
+}
+
+// play places a tile of the given kind for the current player
+func play(t *testing.T, b *Board, kind int, pos Position, rotation int) {
+	t.Helper()
+
+	drawKind(t, b, kind)
+	if err := b.PlaceTile(pos, rotation); err != nil {
+		t.Fatalf("PlaceTile(%v, %d): %v", pos, rotation, err)
+	}
+}
+
+// playMeeple places a tile of the given kind and a meeple of the current
+// player on its feature
+func playMeeple(t *testing.T, b *Board, kind int, pos Position, rotation, featureID int) {
+	t.Helper()
+
+	play(t, b, kind, pos, rotation)
+	if err := b.PlaceMeeple(b.GetCurrentPlayer().ID, featureID); err != nil {
+		t.Fatalf("PlaceMeeple(%d) at %v: %v", featureID, pos, err)
+	}
+}
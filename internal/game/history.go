@@ -0,0 +1,110 @@
+package game
+
+// MoveRecord is one turn's tile placement along with the figure placed on it
+type MoveRecord struct {
+	Turn        int            `json:"turn"` // 1 for the first tile placed
+	PlayerID    string         `json:"playerId"`
+	TileID      int            `json:"tileId"`
+	Position    Position       `json:"position"`
+	Rotation    int            `json:"rotation"`
+	FeatureID   *int           `json:"featureId,omitempty"`   // feature the figure went on, nil without one
+	MeepleType  *MeepleType    `json:"meepleType,omitempty"`  // figure placed, nil without one
+	ScoreChange map[string]int `json:"scoreChange,omitempty"` // points each player scored from the move
+}
+
+// recordTile appends a record for the tile just placed. scoresBefore are
+// the scores before the placement was scored.
+func (b *Board) recordTile(placedTile *PlacedTile, scoresBefore map[string]int) {
+	b.MoveHistory = append(b.MoveHistory, MoveRecord{
+		Turn:        len(b.MoveHistory) + 1,
+		PlayerID:    placedTile.PlacedBy,
+		TileID:      placedTile.Tile.ID,
+		Position:    placedTile.Position,
+		Rotation:    placedTile.Rotation,
+		ScoreChange: b.scoreChange(scoresBefore),
+	})
+}
+
+// recordFigure adds the figure just placed on the last placed tile to its
+// record, along with anything it scored
+func (b *Board) recordFigure(featureID int, meepleType MeepleType, scoresBefore map[string]int) {
+	if len(b.MoveHistory) == 0 {
+		return
+	}
+	
+	record := &b.MoveHistory[len(b.MoveHistory)-1]
+	record.FeatureID = &featureID
+	record.MeepleType = &meepleType
+	for playerID, points := range b.scoreChange(scoresBefore) {
+		if record.ScoreChange == nil {
+			record.ScoreChange = make(map[string]int)
+		}
+		record.ScoreChange[playerID] += points
+	}
+}
+
+// unrecordFigure removes the figure from the last record after it was taken
+// back. Only figures that didn't score can be taken back.
+func (b *Board) unrecordFigure() {
+	if len(b.MoveHistory) == 0 {
+		return
+	}
+	
+	record := &b.MoveHistory[len(b.MoveHistory)-1]
+	record.FeatureID = nil
+	record.MeepleType = nil
+}
+
+// copyScores returns a copy of the current scores
+func (b *Board) copyScores() map[string]int {
+	scores := make(map[string]int, len(b.Scores))
+	for playerID, score := range b.Scores {
+		scores[playerID] = score
+	}
+	return scores
+}
+
+// scoreChange returns the points each player gained since scoresBefore,
+// or nil if nobody scored
+func (b *Board) scoreChange(scoresBefore map[string]int) map[string]int {
+	var change map[string]int
+	for playerID, score := range b.Scores {
+		if score == scoresBefore[playerID] {
+			continue
+		}
+		if change == nil {
+			change = make(map[string]int)
+		}
+		change[playerID] = score - scoresBefore[playerID]
+	}
+	return change
+}
+
+// GetMoveHistory returns a copy of the moves played so far, oldest first
+func (b *Board) GetMoveHistory() []MoveRecord {
+	history := make([]MoveRecord, len(b.MoveHistory))
+	for i, record := range b.MoveHistory {
+		history[i] = record.clone()
+	}
+	return history
+}
+
+// clone returns a copy of the record that shares nothing with it
+func (r MoveRecord) clone() MoveRecord {
+	if r.FeatureID != nil {
+		featureID := *r.FeatureID
+		r.FeatureID = &featureID
+	}
+	if r.MeepleType != nil {
+		meepleType := *r.MeepleType
+		r.MeepleType = &meepleType
+	}
+	if r.ScoreChange != nil {
+		change := make(map[string]int, len(r.ScoreChange))
+		for playerID, points := range r.ScoreChange {
+			change[playerID] = points
+		}
+		r.ScoreChange = change
+	}
+	return r
+}
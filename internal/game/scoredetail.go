@@ -0,0 +1,70 @@
+package game
+
+// PlayerScoreDetail breaks a player's score down by the features it came
+// from, along with where the player's meeples are
+type PlayerScoreDetail struct {
+	Roads            int `json:"roads"`
+	Cities           int `json:"cities"`
+	Monasteries      int `json:"monasteries"`
+	Fields           int `json:"fields"` // only scored at game end
+	Total            int `json:"total"`
+	MeeplesDeployed  int `json:"meeplesDeployed"`  // meeples on the board, not counting the builder
+	MeeplesAvailable int `json:"meeplesAvailable"` // meeples in supply
+}
+
+// recordPoints adds points scored on a feature of the given type to the
+// player's breakdown
+func (b *Board) recordPoints(playerID string, featureType FeatureType, points int) {
+	if b.scoreDetails == nil {
+		b.scoreDetails = make(map[string]PlayerScoreDetail)
+	}
+	
+	detail := b.scoreDetails[playerID]
+	switch featureType {
+	case RoadFeature:
+		detail.Roads += points
+	case CityFeature:
+		detail.Cities += points
+	case MonasteryFeature:
+		detail.Monasteries += points
+	case FieldFeature:
+		detail.Fields += points
+	}
+	b.scoreDetails[playerID] = detail
+}
+
+// GetScoreDetails returns the score breakdown of every player, keyed by
+// player ID. Total always matches the player's score.
+func (b *Board) GetScoreDetails() map[string]PlayerScoreDetail {
+	deployed := make(map[string]int)
+	for _, tile := range b.Tiles {
+		for _, meeple := range tile.Meeples {
+			if meeple.Type != BuilderMeeple {
+				deployed[meeple.PlayerID]++
+			}
+		}
+	}
+	
+	details := make(map[string]PlayerScoreDetail, len(b.Players))
+	for _, player := range b.Players {
+		detail := b.scoreDetails[player.ID]
+		detail.Total = player.Score
+		detail.MeeplesDeployed = deployed[player.ID]
+		detail.MeeplesAvailable = player.Meeples
+		details[player.ID] = detail
+	}
+	return details
+}
+
+// copyScoreDetails returns a copy of the recorded score breakdowns
+func (b *Board) copyScoreDetails() map[string]PlayerScoreDetail {
+	if b.scoreDetails == nil {
+		return nil
+	}
+	
+	details := make(map[string]PlayerScoreDetail, len(b.scoreDetails))
+	for playerID, detail := range b.scoreDetails {
+		details[playerID] = detail
+	}
+	return details
+}
@@ -0,0 +1,404 @@
+package game
+
+// isFeatureComplete checks if every open edge of a connected road or city
+// continues into a neighboring tile. A city segment without edges is enclosed
+// by its own tile and complete on its own.
+func (b *Board) isFeatureComplete(feature []FeatureRef) bool {
+	if len(feature) == 0 {
+		return false
+	}
+
+	tile := b.Tiles[feature[0].Pos]
+	featureType := tile.Tile.Features[feature[0].FeatureID].Type
+
+	for _, ref := range feature {
+		for _, dir := range b.Tiles[ref.Pos].FeatureEdges(ref.FeatureID) {
+			neighbor, exists := b.Tiles[ref.Pos.neighbor(dir)]
+			if !exists || neighbor.featureAtEdge(dir.opposite(), featureType) < 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// majorityPlayers returns the players with the most meeples on a feature
+func majorityPlayers(claimants []string) []string {
+	counts := make(map[string]int)
+	best := 0
+	for _, playerID := range claimants {
+		counts[playerID]++
+		if counts[playerID] > best {
+			best = counts[playerID]
+		}
+	}
+
+	winners := make([]string, 0)
+	for _, playerID := range claimants {
+		if counts[playerID] == best {
+			winners = append(winners, playerID)
+			counts[playerID] = 0 // only list each player once
+		}
+	}
+	return winners
+}
+
+// addScore awards points scored on a feature of the given type to a player
+func (b *Board) addScore(playerID string, featureType FeatureType, points int) {
+	player := b.GetPlayer(playerID)
+	if player == nil {
+		return
+	}
+	player.Score += points
+	b.Scores[playerID] = player.Score
+	b.recordPoints(playerID, featureType, points)
+}
+
+// returnMeeples removes every figure from a connected feature and gives it
+// back to its owner. It returns the figures removed.
+func (b *Board) returnMeeples(feature []FeatureRef) []ReturnedMeeple {
+	returned := make([]ReturnedMeeple, 0)
+	for _, ref := range feature {
+		tile := b.Tiles[ref.Pos]
+		remaining := tile.Meeples[:0]
+		for _, meeple := range tile.Meeples {
+			if meeple.FeatureID != ref.FeatureID {
+				remaining = append(remaining, meeple)
+				continue
+			}
+			returned = append(returned, ReturnedMeeple{
+				Position:  ref.Pos,
+				PlayerID:  meeple.PlayerID,
+				FeatureID: meeple.FeatureID,
+				Type:      meeple.Type,
+			})
+
+			if player := b.GetPlayer(meeple.PlayerID); player != nil {
+				if meeple.Type == BuilderMeeple {
+					player.HasBuilder = true
+				} else {
+					player.Meeples++
+				}
+			}
+		}
+		if len(remaining) != len(tile.Meeples) {
+			b.markTileChanged(ref.Pos)
+		}
+		tile.Meeples = remaining
+	}
+	return returned
+}
+
+// featureType returns the type of a connected feature
+func (b *Board) featureType(feature []FeatureRef) FeatureType {
+	return b.Tiles[feature[0].Pos].Tile.Features[feature[0].FeatureID].Type
+}
+
+// featureValue returns the points a completed feature is worth
+func (b *Board) featureValue(feature []FeatureRef) int {
+	switch b.featureType(feature) {
+	case RoadFeature:
+		return countTiles(feature)
+	case CityFeature:
+		return 2*countTiles(feature) + 2*b.countShields(feature)
+	default:
+		return 0
+	}
+}
+
+// countShields returns the number of tiles of a connected city that carry a
+// shield, either on the city segment itself or on the tile
+func (b *Board) countShields(feature []FeatureRef) int {
+	shields := make(map[Position]bool)
+	for _, ref := range feature {
+		tile := b.Tiles[ref.Pos]
+		if tile.Tile.HasShield || tile.Tile.Features[ref.FeatureID].HasShield {
+			shields[ref.Pos] = true
+		}
+	}
+	return len(shields)
+}
+
+// featureAwards returns the points each player wins on a feature worth the
+// given points: the players with the most meeples on it each get the full
+// value. It doesn't change the board.
+func (b *Board) featureAwards(feature []FeatureRef, points int) map[string]int {
+	awards := make(map[string]int)
+	for _, playerID := range majorityPlayers(b.featureClaimants(feature)) {
+		awards[playerID] = points
+	}
+	return awards
+}
+
+// scoreFeature awards a completed feature to the players with the most
+// meeples on it and returns all figures to their owners. Tied players each
+// get the full value.
+func (b *Board) scoreFeature(feature []FeatureRef) {
+	points := b.featureValue(feature)
+	players := make([]string, 0)
+	for playerID, awarded := range b.featureAwards(feature, points) {
+		b.addScore(playerID, b.featureType(feature), awarded)
+		players = append(players, playerID)
+	}
+	
+	event := b.newScoringEvent(feature, players, points)
+	event.ReturnedMeeples = b.returnMeeples(feature)
+	b.recordScoringEvent(event)
+}
+
+// scoreIfComplete scores the connected feature if it is complete
+func (b *Board) scoreIfComplete(pos Position, featureID int) {
+	tile := b.Tiles[pos]
+	if tile == nil || featureID < 0 || featureID >= len(tile.Tile.Features) {
+		return
+	}
+
+	featureType := tile.Tile.Features[featureID].Type
+	if featureType == MonasteryFeature {
+		b.scoreMonasteryIfComplete(pos, featureID)
+		return
+	}
+	if featureType != RoadFeature && featureType != CityFeature {
+		return
+	}
+
+	feature := b.GetConnectedFeature(pos, featureID)
+	if b.isFeatureComplete(feature) {
+		b.scoreFeature(feature)
+	}
+}
+
+// ScoreCompletedFeatures scores every feature completed by the tile at pos.
+// It runs as soon as the tile is placed, so meeples returned by a completed
+// feature are back in supply before the player places a meeple this turn.
+// Each feature scored is added to ScoringEvents.
+func (b *Board) ScoreCompletedFeatures(pos Position) {
+	tile := b.Tiles[pos]
+	if tile == nil {
+		return
+	}
+
+	for i := range tile.Tile.Features {
+		b.scoreIfComplete(pos, i)
+	}
+	
+	// The tile may also be the last neighbor of a monastery around it
+	for _, neighbor := range surroundingPositions(pos) {
+		if neighborTile, exists := b.Tiles[neighbor]; exists {
+			for i, feature := range neighborTile.Tile.Features {
+				if feature.Type == MonasteryFeature {
+					b.scoreMonasteryIfComplete(neighbor, i)
+				}
+			}
+		}
+	}
+}
+
+// claimedCompletedFeatures returns the roads and cities of a tile just placed
+// that it completes while someone holds them. They are scored right away and
+// must not be claimed and scored a second time in the same turn.
+func (b *Board) claimedCompletedFeatures(placedTile *PlacedTile) []int {
+	scored := make([]int, 0)
+	for i, feature := range placedTile.Tile.Features {
+		if feature.Type != RoadFeature && feature.Type != CityFeature {
+			continue
+		}
+		connected := b.GetConnectedFeature(placedTile.Position, i)
+		if b.isFeatureComplete(connected) && len(b.featureClaimants(connected)) > 0 {
+			scored = append(scored, i)
+		}
+	}
+	return scored
+}
+
+// surroundingPositions returns the 8 positions around pos
+func surroundingPositions(pos Position) []Position {
+	positions := make([]Position, 0, 8)
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx != 0 || dy != 0 {
+				positions = append(positions, Position{pos.X + dx, pos.Y + dy})
+			}
+		}
+	}
+	return positions
+}
+
+// countMonasteryNeighbors returns how many of the 8 positions around a
+// monastery hold a tile
+func (b *Board) countMonasteryNeighbors(pos Position) int {
+	count := 0
+	for _, neighbor := range surroundingPositions(pos) {
+		if _, exists := b.Tiles[neighbor]; exists {
+			count++
+		}
+	}
+	return count
+}
+
+// scoreMonasteryIfComplete awards 9 points to the owner of a claimed
+// monastery once all 8 surrounding positions are filled
+func (b *Board) scoreMonasteryIfComplete(pos Position, featureID int) {
+	if b.countMonasteryNeighbors(pos) < 8 {
+		return
+	}
+	
+	feature := []FeatureRef{{Pos: pos, FeatureID: featureID}}
+	claimants := b.featureClaimants(feature)
+	if len(claimants) == 0 {
+		return
+	}
+	
+	b.addScore(claimants[0], MonasteryFeature, 9)
+	
+	event := b.newScoringEvent(feature, []string{claimants[0]}, 9)
+	event.ReturnedMeeples = b.returnMeeples(feature)
+	b.recordScoringEvent(event)
+}
+
+// featureKey identifies a connected feature by its first segment in board
+// order, so the same feature reached from different segments is counted once
+func featureKey(feature []FeatureRef) FeatureRef {
+	key := feature[0]
+	for _, ref := range feature[1:] {
+		if ref.Pos.Y < key.Pos.Y || (ref.Pos.Y == key.Pos.Y && (ref.Pos.X < key.Pos.X ||
+			(ref.Pos.X == key.Pos.X && ref.FeatureID < key.FeatureID))) {
+			key = ref
+		}
+	}
+	return key
+}
+
+// borderedCities returns the number of completed and incomplete cities a
+// connected field borders
+func (b *Board) borderedCities(field []FeatureRef) (completed, incomplete int) {
+	cities := make(map[FeatureRef]bool)
+	for _, ref := range field {
+		for _, cityID := range b.Tiles[ref.Pos].Tile.Features[ref.FeatureID].Cities {
+			city := b.GetConnectedFeature(ref.Pos, cityID)
+			if len(city) == 0 {
+				continue
+			}
+			
+			key := featureKey(city)
+			if _, counted := cities[key]; !counted {
+				cities[key] = b.isFeatureComplete(city)
+			}
+		}
+	}
+	
+	for _, complete := range cities {
+		if complete {
+			completed++
+		} else {
+			incomplete++
+		}
+	}
+	return completed, incomplete
+}
+
+// claimedFields returns every connected field holding at least one farmer
+func (b *Board) claimedFields() [][]FeatureRef {
+	fields := make([][]FeatureRef, 0)
+	seen := make(map[FeatureRef]bool)
+	for pos, tile := range b.Tiles {
+		for i, feature := range tile.Tile.Features {
+			if feature.Type != FieldFeature || seen[FeatureRef{Pos: pos, FeatureID: i}] {
+				continue
+			}
+			
+			field := b.GetConnectedFeature(pos, i)
+			for _, ref := range field {
+				seen[ref] = true
+			}
+			
+			if len(b.featureClaimants(field)) > 0 {
+				fields = append(fields, field)
+			}
+		}
+	}
+	return fields
+}
+
+// fieldAwards returns the points each player wins on a field: 3 per
+// completed city it borders, for the players with the most farmers on it
+func (b *Board) fieldAwards(field []FeatureRef) map[string]int {
+	completed, _ := b.borderedCities(field)
+	return b.featureAwards(field, 3*completed)
+}
+
+// FieldScores returns the points each player's farmers would score if the
+// game ended now, without changing the board
+func (b *Board) FieldScores() map[string]int {
+	scores := make(map[string]int)
+	for _, field := range b.claimedFields() {
+		for playerID, points := range b.fieldAwards(field) {
+			scores[playerID] += points
+		}
+	}
+	return scores
+}
+
+// scoreFields awards each claimed field 3 points per completed city it
+// borders, to the players with the most farmers on it. Used at game end.
+func (b *Board) scoreFields() {
+	for _, field := range b.claimedFields() {
+		for playerID, points := range b.fieldAwards(field) {
+			b.addScore(playerID, FieldFeature, points)
+		}
+		b.returnMeeples(field)
+	}
+}
+
+// scoreIncompleteFeatures awards each claimed road or city left incomplete
+// 1 point per tile, plus 1 per shield for cities, to the players with the
+// most meeples on it. Used at game end.
+func (b *Board) scoreIncompleteFeatures() {
+	seen := make(map[FeatureRef]bool)
+	for pos, tile := range b.Tiles {
+		for i, feature := range tile.Tile.Features {
+			if feature.Type != RoadFeature && feature.Type != CityFeature || seen[FeatureRef{Pos: pos, FeatureID: i}] {
+				continue
+			}
+			
+			connected := b.GetConnectedFeature(pos, i)
+			for _, ref := range connected {
+				seen[ref] = true
+			}
+			if len(b.featureClaimants(connected)) == 0 {
+				continue
+			}
+			
+			points := countTiles(connected)
+			if feature.Type == CityFeature {
+				points += b.countShields(connected)
+			}
+			for playerID, awarded := range b.featureAwards(connected, points) {
+				b.addScore(playerID, feature.Type, awarded)
+			}
+			b.returnMeeples(connected)
+		}
+	}
+}
+
+// scoreIncompleteMonasteries awards each claimed monastery 1 point for the
+// monastery tile plus 1 per surrounding tile. Used at game end.
+func (b *Board) scoreIncompleteMonasteries() {
+	for pos, tile := range b.Tiles {
+		for i, feature := range tile.Tile.Features {
+			if feature.Type != MonasteryFeature {
+				continue
+			}
+			
+			ref := []FeatureRef{{Pos: pos, FeatureID: i}}
+			claimants := b.featureClaimants(ref)
+			if len(claimants) == 0 {
+				continue
+			}
+			
+			b.addScore(claimants[0], MonasteryFeature, 1+b.countMonasteryNeighbors(pos))
+			b.returnMeeples(ref)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package game
+
+import "testing"
+
+func TestScoreCompletedRoadBeforeMeeplePhase(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// "a" claims the starting tile's road, ended by a junction on the east
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 2)
+	b.NextTurn()
+	play(t, b, kindMonastery, Position{0, 1}, 0)
+	b.NextTurn()
+
+	// A second junction ends the road on the west
+	play(t, b, kindRoadJunction, Position{-1, 0}, 0)
+	a := b.GetPlayer("a")
+	if a.Score != 3 || a.Meeples != DefaultMeeplesPerPlayer {
+		t.Fatalf("after completing the road a has %d points and %d meeples, want 3 and all meeples back", a.Score, a.Meeples)
+	}
+	if len(b.ScoringEvents) != 1 {
+		t.Fatalf("scoring events = %+v, want the road", b.ScoringEvents)
+	}
+	event := b.ScoringEvents[0]
+	if event.FeatureType != RoadFeature || len(event.Tiles) != 3 || event.Points != 3 || len(event.ReturnedMeeples) != 1 {
+		t.Fatalf("scoring event = %+v, want a road of 3 tiles worth 3", event)
+	}
+
+	// The meeple back in supply can be placed right away
+	if err := b.PlaceMeeple("a", 1); err != nil {
+		t.Fatalf("PlaceMeeple after scoring: %v", err)
+	}
+}
+
+func TestScoreCityCompletedWithMeeple(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// A city cap above the starting tile closes its city
+	playMeeple(t, b, kindCityCap, Position{0, -1}, 180, 0)
+	a := b.GetPlayer("a")
+	if a.Score != 4 || a.Meeples != DefaultMeeplesPerPlayer {
+		t.Fatalf("a has %d points and %d meeples, want 4 for a city of 2 tiles and the meeple back", a.Score, a.Meeples)
+	}
+	if len(b.Tiles[Position{0, -1}].Meeples) != 0 {
+		t.Fatalf("the scored meeple is still on the board")
+	}
+}
+
+// tilePlay is a tile placed in a scripted game
+type tilePlay struct {
+	kind     int
+	pos      Position
+	rotation int
+}
+
+// playTurns places the tiles in turn, without meeples
+func playTurns(t *testing.T, b *Board, plays ...tilePlay) {
+	t.Helper()
+
+	for _, p := range plays {
+		play(t, b, p.kind, p.pos, p.rotation)
+		b.NextTurn()
+	}
+}
+
+func TestScoreCompletedMonastery(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// "a" claims a monastery below the starting tile
+	playMeeple(t, b, kindMonastery, Position{0, 1}, 0, 0)
+	b.NextTurn()
+
+	// Surrounding it with tiles, the last one completes it
+	playTurns(t, b,
+		tilePlay{kindStraightRoad, Position{1, 0}, 90},
+		tilePlay{kindStraightRoad, Position{-1, 0}, 90},
+		tilePlay{kindMonastery, Position{1, 1}, 0},
+		tilePlay{kindMonastery, Position{-1, 1}, 0},
+		tilePlay{kindMonastery, Position{0, 2}, 0},
+		tilePlay{kindCityCap, Position{1, 2}, 180},
+	)
+	if score := b.GetPlayer("a").Score; score != 0 {
+		t.Fatalf("a scored %d for a monastery with 7 neighbors", score)
+	}
+	play(t, b, kindCityCap, Position{-1, 2}, 180)
+
+	a := b.GetPlayer("a")
+	if a.Score != 9 || a.Meeples != DefaultMeeplesPerPlayer {
+		t.Fatalf("a has %d points and %d meeples, want 9 and the meeple back", a.Score, a.Meeples)
+	}
+}
+
+func TestScoreIncompleteMonasteryAtGameEnd(t *testing.T) {
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindMonastery, Position{0, 1}, 0, 0)
+	b.NextTurn()
+	playTurns(t, b, tilePlay{kindStraightRoad, Position{1, 0}, 90})
+
+	// The monastery itself, the starting tile and the road next to it
+	b.EndGame()
+	if score := b.GetPlayer("a").Score; score != 3 {
+		t.Fatalf("a scored %d for a monastery with 2 neighbors at game end, want 3", score)
+	}
+}
+
+func TestScoreFarmers(t *testing.T) {
+	t.Run("field bordering a completed city", func(t *testing.T) {
+		b := newStartedBoard(t)
+
+		// The cap closes the starting tile's city, "a" farms the field above
+		playMeeple(t, b, kindCityCap, Position{0, -1}, 180, 1)
+		if scores := b.FieldScores(); scores["a"] != 3 {
+			t.Fatalf("FieldScores() = %v, want 3 for a", scores)
+		}
+		if score := b.GetPlayer("a").Score; score != 0 {
+			t.Fatalf("a scored %d for a farmer before the game ended", score)
+		}
+
+		b.EndGame()
+		a := b.GetPlayer("a")
+		if a.Score != 3 || a.Meeples != DefaultMeeplesPerPlayer {
+			t.Fatalf("a has %d points and %d meeples at game end, want 3 and the farmer back", a.Score, a.Meeples)
+		}
+	})
+
+	t.Run("field bordering an incomplete city", func(t *testing.T) {
+		b := newStartedBoard(t)
+		playTurns(t, b, tilePlay{kindStraightRoad, Position{1, 0}, 90})
+		playMeeple(t, b, kindCityCap, Position{1, 1}, 180, 1)
+
+		b.EndGame()
+		if score := b.GetPlayer("b").Score; score != 0 {
+			t.Fatalf("b scored %d for a field bordering no completed city", score)
+		}
+	})
+
+	t.Run("tied farmers", func(t *testing.T) {
+		b := newStartedBoard(t)
+
+		// Separate fields: "a" above the cap, "b" along the road east
+		playMeeple(t, b, kindCityCap, Position{0, -1}, 180, 1)
+		b.NextTurn()
+		playMeeple(t, b, kindStraightRoad, Position{1, 0}, 90, 2)
+		b.NextTurn()
+
+		// The monastery joins both fields
+		play(t, b, kindMonastery, Position{1, -1}, 0)
+		b.EndGame()
+		for _, id := range []string{"a", "b"} {
+			if score := b.GetPlayer(id).Score; score != 3 {
+				t.Fatalf("%s scored %d for a tied field bordering one completed city, want 3", id, score)
+			}
+		}
+	})
+}
+
+func TestScoreIncompleteRoadsAndCitiesAtGameEnd(t *testing.T) {
+	b := newStartedBoard(t)
+
+	// "a" claims the road through the starting tile, "b" a city cap open
+	// to the south
+	playMeeple(t, b, kindStraightRoad, Position{1, 0}, 90, 0)
+	b.NextTurn()
+	playMeeple(t, b, kindCityCap, Position{1, 1}, 180, 0)
+
+	b.EndGame()
+	for id, want := range map[string]int{"a": 2, "b": 1} {
+		p := b.GetPlayer(id)
+		if p.Score != want || p.Meeples != DefaultMeeplesPerPlayer {
+			t.Fatalf("%s has %d points and %d meeples at game end, want %d and the meeple back", id, p.Score, p.Meeples, want)
+		}
+	}
+}
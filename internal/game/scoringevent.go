@@ -0,0 +1,52 @@
+package game
+
+import "sort"
+
+// ScoringEvent describes a feature scored during the game, e.g. to animate
+// the points and the figures going back to their owners
+type ScoringEvent struct {
+	FeatureType     FeatureType      `json:"featureType"`
+	Tiles           []Position       `json:"tiles"`           // tiles the feature covers, in board order
+	Players         []string         `json:"players"`         // players who scored, empty if nobody had a meeple on it
+	Points          int              `json:"points"`          // awarded to each scoring player
+	ReturnedMeeples []ReturnedMeeple `json:"returnedMeeples"` // figures taken off the feature
+}
+
+// ReturnedMeeple is a figure taken off the board and given back to its owner
+type ReturnedMeeple struct {
+	Position  Position   `json:"position"`
+	PlayerID  string     `json:"playerId"`
+	FeatureID int        `json:"featureId"`
+	Type      MeepleType `json:"type"`
+}
+
+// newScoringEvent creates the event for a scored feature, awarding points to
+// each of players. Nothing is recorded yet.
+func (b *Board) newScoringEvent(feature []FeatureRef, players []string, points int) ScoringEvent {
+	seen := make(map[Position]bool)
+	tiles := make([]Position, 0, len(feature))
+	for _, ref := range feature {
+		if !seen[ref.Pos] {
+			seen[ref.Pos] = true
+			tiles = append(tiles, ref.Pos)
+		}
+	}
+	sortPositions(tiles)
+	
+	sort.Strings(players)
+	return ScoringEvent{
+		FeatureType: b.featureType(feature),
+		Tiles:       tiles,
+		Players:     players,
+		Points:      points,
+	}
+}
+
+// recordScoringEvent adds the event to ScoringEvents, unless the feature
+// gave nobody points nor figures back
+func (b *Board) recordScoringEvent(event ScoringEvent) {
+	if len(event.Players) == 0 && len(event.ReturnedMeeples) == 0 {
+		return
+	}
+	b.ScoringEvents = append(b.ScoringEvents, event)
+}
@@ -0,0 +1,160 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TileMap holds the placed tiles by position. It marshals to a JSON object
+// keyed by "x,y", since JSON can't use Position as a key directly.
+type TileMap map[Position]*PlacedTile
+
+// MarshalJSON encodes the tiles as an object keyed by "x,y"
+func (m TileMap) MarshalJSON() ([]byte, error) {
+	tiles := make(map[string]*PlacedTile, len(m))
+	for pos, tile := range m {
+		tiles[fmt.Sprintf("%d,%d", pos.X, pos.Y)] = tile
+	}
+	return json.Marshal(tiles)
+}
+
+// UnmarshalJSON decodes tiles from an object keyed by "x,y"
+func (m *TileMap) UnmarshalJSON(data []byte) error {
+	var tiles map[string]*PlacedTile
+	if err := json.Unmarshal(data, &tiles); err != nil {
+		return err
+	}
+	
+	*m = make(TileMap, len(tiles))
+	for key, tile := range tiles {
+		var pos Position
+		if _, err := fmt.Sscanf(key, "%d,%d", &pos.X, &pos.Y); err != nil {
+			return fmt.Errorf("invalid tile position %q: %w", key, err)
+		}
+		(*m)[pos] = tile
+	}
+	return nil
+}
+
+// boardSnapshot is the serialized form of a Board, including the turn state
+// that isn't exported
+type boardSnapshot struct {
+	Tiles            TileMap        `json:"tiles"`
+	TileDeck         []*Tile        `json:"tileDeck"`
+	CurrentTile      *Tile          `json:"currentTile"`
+	TotalTiles       int            `json:"totalTiles"`
+	Seed             int64          `json:"seed"`
+	Players          []*Player      `json:"players"`
+	CurrentPlayer    int            `json:"currentPlayer"`
+	TurnOrder        []string       `json:"turnOrder,omitempty"`
+	RandomizeSeating bool           `json:"randomizeSeating,omitempty"`
+	GameStarted      bool           `json:"gameStarted"`
+	GameEnded        bool           `json:"gameEnded"`
+	Scores           map[string]int `json:"scores"`
+	ScoreDetails     map[string]PlayerScoreDetail `json:"scoreDetails,omitempty"`
+	LastPlacedTile   *Position      `json:"lastPlacedTile,omitempty"`
+	MeeplePlaced     bool           `json:"meeplePlaced"`
+	ScoredFeatures   []int          `json:"scoredFeatures,omitempty"`
+	Builders         bool           `json:"builders"`
+	BuilderTriggered bool           `json:"builderTriggered"`
+	BonusTurn        bool           `json:"bonusTurn"`
+	StrictPlacement  bool           `json:"strictPlacement"`
+	MoveHistory      []MoveRecord   `json:"moveHistory,omitempty"`
+	MeeplesPerPlayer int            `json:"meeplesPerPlayer"`
+	Version          int            `json:"version"`
+}
+
+// Snapshot serializes the full board state, so a game can be restored with
+// LoadBoard
+func (b *Board) Snapshot() ([]byte, error) {
+	snapshot := boardSnapshot{
+		Tiles:            b.Tiles,
+		TileDeck:         b.TileDeck,
+		CurrentTile:      b.CurrentTile,
+		TotalTiles:       b.TotalTiles,
+		Seed:             b.Seed,
+		Players:          b.Players,
+		CurrentPlayer:    b.CurrentPlayer,
+		TurnOrder:        b.TurnOrder,
+		RandomizeSeating: b.RandomizeSeating,
+		GameStarted:      b.GameStarted,
+		GameEnded:        b.GameEnded,
+		Scores:           b.Scores,
+		ScoreDetails:     b.scoreDetails,
+		MeeplePlaced:     b.meeplePlaced,
+		ScoredFeatures:   b.scoredFeatures,
+		Builders:         b.Builders,
+		BuilderTriggered: b.builderTriggered,
+		BonusTurn:        b.bonusTurn,
+		StrictPlacement:  b.StrictPlacement,
+		MoveHistory:      b.MoveHistory,
+		MeeplesPerPlayer: b.MeeplesPerPlayer,
+		Version:          b.Version,
+	}
+	if b.LastPlacedTile != nil {
+		pos := b.LastPlacedTile.Position
+		snapshot.LastPlacedTile = &pos
+	}
+	
+	return json.Marshal(snapshot)
+}
+
+// LoadBoard restores a board from a Snapshot
+func LoadBoard(data []byte) (*Board, error) {
+	var snapshot boardSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	
+	board := &Board{
+		Tiles:            snapshot.Tiles,
+		TileDeck:         snapshot.TileDeck,
+		CurrentTile:      snapshot.CurrentTile,
+		TotalTiles:       snapshot.TotalTiles,
+		Seed:             snapshot.Seed,
+		Players:          snapshot.Players,
+		CurrentPlayer:    snapshot.CurrentPlayer,
+		TurnOrder:        snapshot.TurnOrder,
+		RandomizeSeating: snapshot.RandomizeSeating,
+		GameStarted:      snapshot.GameStarted,
+		GameEnded:        snapshot.GameEnded,
+		Scores:           snapshot.Scores,
+		scoreDetails:     snapshot.ScoreDetails,
+		meeplePlaced:     snapshot.MeeplePlaced,
+		scoredFeatures:   snapshot.ScoredFeatures,
+		Builders:         snapshot.Builders,
+		builderTriggered: snapshot.BuilderTriggered,
+		bonusTurn:        snapshot.BonusTurn,
+		StrictPlacement:  snapshot.StrictPlacement,
+		MoveHistory:      snapshot.MoveHistory,
+		MeeplesPerPlayer: snapshot.MeeplesPerPlayer,
+		Version:          snapshot.Version,
+	}
+	if board.Tiles == nil {
+		board.Tiles = make(TileMap)
+	}
+	if board.Players == nil {
+		board.Players = make([]*Player, 0)
+	}
+	if board.Scores == nil {
+		board.Scores = make(map[string]int)
+	}
+	if board.MeeplesPerPlayer == 0 {
+		board.MeeplesPerPlayer = DefaultMeeplesPerPlayer
+	}
+	// Games saved before turn orders were kept played in seating order
+	if board.GameStarted && board.TurnOrder == nil {
+		board.TurnOrder = board.seatOrder()
+	}
+	
+	if snapshot.LastPlacedTile != nil {
+		tile, exists := board.Tiles[*snapshot.LastPlacedTile]
+		if !exists {
+			return nil, fmt.Errorf("last placed tile %v is not on the board", *snapshot.LastPlacedTile)
+		}
+		board.LastPlacedTile = tile
+	}
+	board.featureGraph()
+	
+	return board, nil
+}
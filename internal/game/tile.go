@@ -1,6 +1,9 @@
 package game
 
-import "fmt"
+import (
+	"fmt"
+	"hash/fnv"
+)
 
 // TileEdge represents the type of edge on a tile
 type TileEdge int
@@ -11,6 +14,20 @@ const (
 	Field
 )
 
+// String returns the name of the edge type
+func (e TileEdge) String() string {
+	switch e {
+	case Road:
+		return "Road"
+	case City:
+		return "City"
+	case Field:
+		return "Field"
+	default:
+		return fmt.Sprintf("TileEdge(%d)", int(e))
+	}
+}
+
 // Tile represents a Carcassonne tile with its edges and features
 type Tile struct {
 	ID       int
@@ -29,6 +46,8 @@ type Feature struct {
 	Edges    []Direction // Which edges this feature touches
 	ID       int         // Unique identifier for scoring
 	HasShield bool       // For cities
+	HalfEdges []HalfEdge // For fields, the halves of road sides it touches
+	Cities   []int       // For fields, the IDs of the city features it borders
 }
 
 type FeatureType int
@@ -49,17 +68,81 @@ const (
 	West
 )
 
+// String returns the lowercase name of the direction
+func (d Direction) String() string {
+	switch d {
+	case North:
+		return "north"
+	case East:
+		return "east"
+	case South:
+		return "south"
+	case West:
+		return "west"
+	default:
+		return fmt.Sprintf("Direction(%d)", int(d))
+	}
+}
+
+// HalfEdge is one half of a tile side, numbered clockwise from the west half
+// of the north side. Fields meet across half sides, so the fields on both
+// sides of a road continue separately into the neighboring tile.
+type HalfEdge int
+
+const (
+	NorthWestHalf HalfEdge = iota
+	NorthEastHalf
+	EastNorthHalf
+	EastSouthHalf
+	SouthEastHalf
+	SouthWestHalf
+	WestSouthHalf
+	WestNorthHalf
+)
+
+// direction returns the side the half edge belongs to
+func (h HalfEdge) direction() Direction {
+	return Direction(int(h) / 2)
+}
+
+// rotate returns the half edge after rotating its tile clockwise
+func (h HalfEdge) rotate(rotation int) HalfEdge {
+	return HalfEdge((int(h) + rotation/90*2) % 8)
+}
+
+// opposite returns the half edge of the neighboring tile that touches h.
+// Numbering runs clockwise on both tiles, so the halves swap.
+func (h HalfEdge) opposite() HalfEdge {
+	return HalfEdge(int(h.direction().opposite())*2 + 1 - int(h)%2)
+}
+
+// halvesOf returns both halves of a tile side
+func halvesOf(dir Direction) []HalfEdge {
+	return []HalfEdge{HalfEdge(int(dir) * 2), HalfEdge(int(dir)*2 + 1)}
+}
+
 // Position represents a position on the board
 type Position struct {
 	X, Y int
 }
 
+// normalizeRotation brings a rotation in degrees into 0, 90, 180 or 270,
+// e.g. 450 becomes 90 and -90 becomes 270. Rotations that aren't a multiple
+// of 90 are invalid.
+func normalizeRotation(rotation int) (int, bool) {
+	if rotation%90 != 0 {
+		return 0, false
+	}
+	return (rotation%360 + 360) % 360, true
+}
+
 // PlacedTile represents a tile that has been placed on the board
 type PlacedTile struct {
 	Tile     *Tile
 	Position Position
 	Rotation int // 0, 90, 180, 270 degrees
 	Meeples  []PlacedMeeple
+	PlacedBy string // Player ID, empty for the starting tile
 }
 
 // PlacedMeeple represents a meeple placed on a tile
@@ -67,11 +150,39 @@ type PlacedMeeple struct {
 	PlayerID   string
 	FeatureID  int
 	Color      string
+	Type       MeepleType
 }
 
-// Rotate rotates the tile by 90 degrees clockwise
-func (t *Tile) Rotate() {
-	t.North, t.East, t.South, t.West = t.West, t.North, t.East, t.South
+// MeepleType represents the kind of figure placed on a tile
+type MeepleType int
+
+const (
+	NormalMeeple MeepleType = iota
+	BuilderMeeple // Traders & Builders: grants an extra turn when its feature is extended
+)
+
+// Rotate returns a copy of the tile rotated by 90 degrees clockwise. The
+// receiver is left untouched since deck tiles are shared; placed tiles
+// express rotation through PlacedTile.Rotation instead.
+func (t *Tile) Rotate() *Tile {
+	rotated := *t
+	rotated.North, rotated.East, rotated.South, rotated.West = t.West, t.North, t.East, t.South
+	
+	rotated.Features = make([]Feature, len(t.Features))
+	for i, feature := range t.Features {
+		feature.Edges = make([]Direction, 0, len(t.Features[i].Edges))
+		for _, dir := range t.Features[i].Edges {
+			feature.Edges = append(feature.Edges, rotateDirection(dir, 90))
+		}
+		feature.HalfEdges = make([]HalfEdge, 0, len(t.Features[i].HalfEdges))
+		for _, half := range t.Features[i].HalfEdges {
+			feature.HalfEdges = append(feature.HalfEdges, half.rotate(90))
+		}
+		feature.Cities = append([]int{}, t.Features[i].Cities...)
+		rotated.Features[i] = feature
+	}
+	
+	return &rotated
 }
 
 // GetEdge returns the edge in the specified direction after rotation
@@ -91,6 +202,41 @@ func (pt *PlacedTile) GetEdge(dir Direction) TileEdge {
 	}
 }
 
+// TileOrientation describes the edges of a tile after rotation
+type TileOrientation struct {
+	Rotation int      `json:"rotation"`
+	North    TileEdge `json:"north"`
+	East     TileEdge `json:"east"`
+	South    TileEdge `json:"south"`
+	West     TileEdge `json:"west"`
+}
+
+// Orientation returns the edges of the tile rotated clockwise by the given
+// number of degrees, without modifying the tile
+func (t *Tile) Orientation(rotation int) TileOrientation {
+	pt := &PlacedTile{Tile: t, Rotation: rotation}
+	return TileOrientation{
+		Rotation: rotation,
+		North:    pt.GetEdge(North),
+		East:     pt.GetEdge(East),
+		South:    pt.GetEdge(South),
+		West:     pt.GetEdge(West),
+	}
+}
+
+// neighbors lists the offset of each adjacent position, the side of a tile
+// facing it and the side of the neighbor facing back
+var neighbors = []struct {
+	dx, dy   int
+	dir      Direction
+	opposite Direction
+}{
+	{0, -1, North, South},
+	{1, 0, East, West},
+	{0, 1, South, North},
+	{-1, 0, West, East},
+}
+
 // CanPlaceAt checks if a tile can be placed at the given position
 func (pt *PlacedTile) CanPlaceAt(board map[Position]*PlacedTile, pos Position) bool {
 	// Check if position is already occupied
@@ -100,24 +246,11 @@ func (pt *PlacedTile) CanPlaceAt(board map[Position]*PlacedTile, pos Position) b
 
 	// Check if there's at least one adjacent tile
 	hasAdjacent := false
-	adjacentPositions := []struct {
-		pos Position
-		dir Direction
-		opposite Direction
-	}{
-		{Position{pos.X, pos.Y - 1}, North, South},
-		{Position{pos.X + 1, pos.Y}, East, West},
-		{Position{pos.X, pos.Y + 1}, South, North},
-		{Position{pos.X - 1, pos.Y}, West, East},
-	}
-
-	for _, adj := range adjacentPositions {
-		if adjacentTile, exists := board[adj.pos]; exists {
+	for _, adj := range neighbors {
+		if adjacentTile, exists := board[Position{pos.X + adj.dx, pos.Y + adj.dy}]; exists {
 			hasAdjacent = true
 			// Check if edges match
-			myEdge := pt.GetEdge(adj.dir)
-			theirEdge := adjacentTile.GetEdge(adj.opposite)
-			if myEdge != theirEdge {
+			if pt.GetEdge(adj.dir) != adjacentTile.GetEdge(adj.opposite) {
 				return false
 			}
 		}
@@ -126,62 +259,243 @@ func (pt *PlacedTile) CanPlaceAt(board map[Position]*PlacedTile, pos Position) b
 	return hasAdjacent
 }
 
-// CreateStandardTileSet creates the standard 72-tile Carcassonne set
+// CanPlaceAtWithReason checks a placement like CanPlaceAt and returns why it
+// isn't allowed, wrapping ErrInvalidPlacement, or nil if it is
+func (pt *PlacedTile) CanPlaceAtWithReason(board map[Position]*PlacedTile, pos Position) error {
+	if _, exists := board[pos]; exists {
+		return fmt.Errorf("%w: position (%d, %d) is already occupied", ErrInvalidPlacement, pos.X, pos.Y)
+	}
+	
+	hasAdjacent := false
+	for _, adj := range neighbors {
+		adjacentTile, exists := board[Position{pos.X + adj.dx, pos.Y + adj.dy}]
+		if !exists {
+			continue
+		}
+		hasAdjacent = true
+		
+		myEdge := pt.GetEdge(adj.dir)
+		theirEdge := adjacentTile.GetEdge(adj.opposite)
+		if myEdge != theirEdge {
+			return fmt.Errorf("%w: %s edge %s does not match neighbor's %s edge %s",
+				ErrInvalidPlacement, adj.dir, myEdge, adj.opposite, theirEdge)
+		}
+	}
+	
+	if !hasAdjacent {
+		return fmt.Errorf("%w: no tile next to position (%d, %d)", ErrInvalidPlacement, pos.X, pos.Y)
+	}
+	return nil
+}
+
+// IsOpenAdjacentAt checks if the position is free and touches at least one
+// placed tile, ignoring edge matching. Used by relaxed placement mode.
+func (pt *PlacedTile) IsOpenAdjacentAt(board map[Position]*PlacedTile, pos Position) bool {
+	if _, exists := board[pos]; exists {
+		return false
+	}
+	
+	adjacent := []Position{
+		{pos.X, pos.Y - 1},
+		{pos.X + 1, pos.Y},
+		{pos.X, pos.Y + 1},
+		{pos.X - 1, pos.Y},
+	}
+	
+	for _, adjPos := range adjacent {
+		if _, exists := board[adjPos]; exists {
+			return true
+		}
+	}
+	
+	return false
+}
+
+// tileKind describes one kind of base game tile and how many copies of it
+// the set has. Fields list the sides they cover entirely as edges, the
+// halves of road sides they touch as half edges, and the cities they border.
+type tileKind struct {
+	count     int
+	north     TileEdge
+	east      TileEdge
+	south     TileEdge
+	west      TileEdge
+	shield    bool
+	monastery bool
+	features  []Feature
+}
+
+func city(edges ...Direction) Feature  { return Feature{Type: CityFeature, Edges: edges} }
+func road(edges ...Direction) Feature  { return Feature{Type: RoadFeature, Edges: edges} }
+func field(edges ...Direction) Feature { return Feature{Type: FieldFeature, Edges: edges} }
+func monastery() Feature               { return Feature{Type: MonasteryFeature, Edges: []Direction{}} }
+
+// touching adds the halves of road sides a field touches
+func (f Feature) touching(halves ...HalfEdge) Feature {
+	f.HalfEdges = halves
+	return f
+}
+
+// bordering adds the IDs of the cities on the same tile a field borders
+func (f Feature) bordering(cities ...int) Feature {
+	f.Cities = cities
+	return f
+}
+
+// startingTileKind is the base game starting tile: a city on top of a
+// straight road. The set holds it plus 3 more copies in the deck.
+var startingTileKind = tileKind{
+	count: 4, north: City, east: Road, south: Field, west: Road,
+	features: []Feature{city(North), road(East, West),
+		field().touching(EastNorthHalf, WestNorthHalf).bordering(0),
+		field(South).touching(EastSouthHalf, WestSouthHalf)},
+}
+
+// baseGameTileKinds lists the remaining tiles of the base game, matching the
+// published distribution
+var baseGameTileKinds = []tileKind{
+	// Monastery with road
+	{count: 2, north: Field, east: Field, south: Road, west: Field, monastery: true,
+		features: []Feature{monastery(), road(South), field(North, East, West).touching(SouthEastHalf, SouthWestHalf)}},
+	// Monastery
+	{count: 4, north: Field, east: Field, south: Field, west: Field, monastery: true,
+		features: []Feature{monastery(), field(North, East, South, West)}},
+	// Full city with shield
+	{count: 1, north: City, east: City, south: City, west: City, shield: true,
+		features: []Feature{city(North, East, South, West)}},
+	// City cap
+	{count: 5, north: City, east: Field, south: Field, west: Field,
+		features: []Feature{city(North), field(East, South, West).bordering(0)}},
+	// City through the middle with shield
+	{count: 2, north: Field, east: City, south: Field, west: City, shield: true,
+		features: []Feature{city(East, West), field(North).bordering(0), field(South).bordering(0)}},
+	// City through the middle
+	{count: 1, north: Field, east: City, south: Field, west: City,
+		features: []Feature{city(East, West), field(North).bordering(0), field(South).bordering(0)}},
+	// Two opposite city caps
+	{count: 3, north: Field, east: City, south: Field, west: City,
+		features: []Feature{city(East), city(West), field(North, South).bordering(0, 1)}},
+	// Two adjacent city caps
+	{count: 2, north: City, east: City, south: Field, west: Field,
+		features: []Feature{city(North), city(East), field(South, West).bordering(0, 1)}},
+	// City cap with road curving right
+	{count: 3, north: City, east: Road, south: Road, west: Field,
+		features: []Feature{city(North), road(East, South), field().touching(EastSouthHalf, SouthEastHalf),
+			field(West).touching(EastNorthHalf, SouthWestHalf).bordering(0)}},
+	// City cap with road curving left
+	{count: 3, north: City, east: Field, south: Road, west: Road,
+		features: []Feature{city(North), road(South, West), field().touching(SouthWestHalf, WestSouthHalf),
+			field(East).touching(SouthEastHalf, WestNorthHalf).bordering(0)}},
+	// City cap with road junction
+	{count: 3, north: City, east: Road, south: Road, west: Road,
+		features: []Feature{city(North), road(East), road(South), road(West),
+			field().touching(WestNorthHalf, EastNorthHalf).bordering(0),
+			field().touching(EastSouthHalf, SouthEastHalf),
+			field().touching(SouthWestHalf, WestSouthHalf)}},
+	// City corner with shield
+	{count: 2, north: City, east: Field, south: Field, west: City, shield: true,
+		features: []Feature{city(North, West), field(East, South).bordering(0)}},
+	// City corner
+	{count: 3, north: City, east: Field, south: Field, west: City,
+		features: []Feature{city(North, West), field(East, South).bordering(0)}},
+	// City corner with road curve and shield
+	{count: 2, north: City, east: Road, south: Road, west: City, shield: true,
+		features: []Feature{city(North, West), road(East, South), field().touching(EastSouthHalf, SouthEastHalf),
+			field().touching(EastNorthHalf, SouthWestHalf).bordering(0)}},
+	// City corner with road curve
+	{count: 3, north: City, east: Road, south: Road, west: City,
+		features: []Feature{city(North, West), road(East, South), field().touching(EastSouthHalf, SouthEastHalf),
+			field().touching(EastNorthHalf, SouthWestHalf).bordering(0)}},
+	// Three sided city with shield
+	{count: 1, north: City, east: City, south: Field, west: City, shield: true,
+		features: []Feature{city(North, East, West), field(South).bordering(0)}},
+	// Three sided city
+	{count: 3, north: City, east: City, south: Field, west: City,
+		features: []Feature{city(North, East, West), field(South).bordering(0)}},
+	// Three sided city with road and shield
+	{count: 2, north: City, east: City, south: Road, west: City, shield: true,
+		features: []Feature{city(North, East, West), road(South),
+			field().touching(SouthEastHalf).bordering(0), field().touching(SouthWestHalf).bordering(0)}},
+	// Three sided city with road
+	{count: 1, north: City, east: City, south: Road, west: City,
+		features: []Feature{city(North, East, West), road(South),
+			field().touching(SouthEastHalf).bordering(0), field().touching(SouthWestHalf).bordering(0)}},
+	// Straight road
+	{count: 8, north: Road, east: Field, south: Road, west: Field,
+		features: []Feature{road(North, South), field(East).touching(NorthEastHalf, SouthEastHalf),
+			field(West).touching(SouthWestHalf, NorthWestHalf)}},
+	// Road curve
+	{count: 9, north: Field, east: Field, south: Road, west: Road,
+		features: []Feature{road(South, West), field(North, East).touching(SouthEastHalf, WestNorthHalf),
+			field().touching(SouthWestHalf, WestSouthHalf)}},
+	// Road junction
+	{count: 4, north: Field, east: Road, south: Road, west: Road,
+		features: []Feature{road(East), road(South), road(West), field(North).touching(WestNorthHalf, EastNorthHalf),
+			field().touching(EastSouthHalf, SouthEastHalf), field().touching(SouthWestHalf, WestSouthHalf)}},
+	// Crossroads
+	{count: 1, north: Road, east: Road, south: Road, west: Road,
+		features: []Feature{road(North), road(East), road(South), road(West),
+			field().touching(NorthEastHalf, EastNorthHalf), field().touching(EastSouthHalf, SouthEastHalf),
+			field().touching(SouthWestHalf, WestSouthHalf), field().touching(WestNorthHalf, NorthWestHalf)}},
+}
+
+// newTile creates a tile of the given kind
+func (k tileKind) newTile(id int) *Tile {
+	features := make([]Feature, len(k.features))
+	for i, feature := range k.features {
+		feature.ID = i
+		feature.Edges = append([]Direction{}, feature.Edges...)
+		feature.HalfEdges = append([]HalfEdge{}, feature.HalfEdges...)
+		feature.Cities = append([]int{}, feature.Cities...)
+		feature.HasShield = k.shield && feature.Type == CityFeature
+		features[i] = feature
+	}
+	
+	return &Tile{
+		ID:           id,
+		North:        k.north,
+		East:         k.east,
+		South:        k.south,
+		West:         k.west,
+		Features:     features,
+		HasShield:    k.shield,
+		HasMonastery: k.monastery,
+	}
+}
+
+// CreateStandardTileSet creates the standard 72-tile Carcassonne set. The
+// starting tile always comes first.
 func CreateStandardTileSet() []*Tile {
 	tiles := make([]*Tile, 0, 72)
 	
-	// Starting tile (monastery with road)
-	tiles = append(tiles, &Tile{
-		ID: 0,
-		North: Field, East: Road, South: Road, West: Field,
-		HasMonastery: true,
-		Features: []Feature{
-			{Type: MonasteryFeature, Edges: []Direction{}, ID: 0},
-			{Type: RoadFeature, Edges: []Direction{East, South}, ID: 1},
-		},
-	})
-
-	// Add more tiles - this is a simplified set for demonstration
-	// In a full implementation, you'd add all 72 unique tiles
+	for _, kind := range append([]tileKind{startingTileKind}, baseGameTileKinds...) {
+		for i := 0; i < kind.count; i++ {
+			tiles = append(tiles, kind.newTile(len(tiles)))
+		}
+	}
 	
-	// Road tiles
-	for i := 1; i <= 8; i++ {
-		tiles = append(tiles, &Tile{
-			ID: i,
-			North: Field, East: Road, South: Field, West: Road,
-			Features: []Feature{
-				{Type: RoadFeature, Edges: []Direction{East, West}, ID: 0},
-				{Type: FieldFeature, Edges: []Direction{North, South}, ID: 1},
-			},
-		})
-	}
-
-	// City tiles
-	for i := 9; i <= 16; i++ {
-		tiles = append(tiles, &Tile{
-			ID: i,
-			North: City, East: Field, South: Field, West: Field,
-			Features: []Feature{
-				{Type: CityFeature, Edges: []Direction{North}, ID: 0},
-				{Type: FieldFeature, Edges: []Direction{East, South, West}, ID: 1},
-			},
-		})
-	}
-
-	// Monastery tiles
-	for i := 17; i <= 20; i++ {
-		tiles = append(tiles, &Tile{
-			ID: i,
-			North: Field, East: Field, South: Field, West: Field,
-			HasMonastery: true,
-			Features: []Feature{
-				{Type: MonasteryFeature, Edges: []Direction{}, ID: 0},
-				{Type: FieldFeature, Edges: []Direction{North, East, South, West}, ID: 1},
-			},
-		})
+	return tiles
+}
+
+// KindID returns the ID of the first tile of the same kind in the standard
+// set. Copies of a kind get consecutive IDs, so all of them share it.
+func (t *Tile) KindID() int {
+	first := 0
+	for _, kind := range append([]tileKind{startingTileKind}, baseGameTileKinds...) {
+		if t.ID < first+kind.count {
+			return first
+		}
+		first += kind.count
 	}
+	return t.ID
+}
 
-	return tiles
+// SeedFromString derives a deck seed from a string, so seeds can be shared
+// as words or dates
+func SeedFromString(s string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return int64(h.Sum64())
 }
 
 func (t *Tile) String() string {
@@ -0,0 +1,107 @@
+package game
+
+import "testing"
+
+func TestOrientation(t *testing.T) {
+	tile := baseGameTileKinds[kindCityCap].newTile(0)
+
+	// The city turns clockwise with the tile
+	cityEdge := map[int]func(o TileOrientation) TileEdge{
+		0:   func(o TileOrientation) TileEdge { return o.North },
+		90:  func(o TileOrientation) TileEdge { return o.East },
+		180: func(o TileOrientation) TileEdge { return o.South },
+		270: func(o TileOrientation) TileEdge { return o.West },
+	}
+	for rotation, edge := range cityEdge {
+		o := tile.Orientation(rotation)
+		if o.Rotation != rotation || edge(o) != City {
+			t.Fatalf("Orientation(%d) = %+v, want the city turned by %d degrees", rotation, o, rotation)
+		}
+		cities := 0
+		for _, e := range []TileEdge{o.North, o.East, o.South, o.West} {
+			if e == City {
+				cities++
+			}
+		}
+		if cities != 1 {
+			t.Fatalf("Orientation(%d) = %+v, want one city edge", rotation, o)
+		}
+	}
+
+	if tile.North != City || tile.East != Field {
+		t.Fatalf("Orientation modified the tile")
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tile := baseGameTileKinds[kindRoadCurve].newTile(0)
+	rotated := tile.Rotate()
+
+	if tile.South != Road || tile.West != Road || tile.Features[0].Edges[0] != South {
+		t.Fatalf("Rotate modified the tile: %+v", tile)
+	}
+	if rotated == tile {
+		t.Fatalf("Rotate returned the tile itself")
+	}
+
+	// The rotated tile has the edges a placed tile rotated by 90 degrees has
+	if o := tile.Orientation(90); rotated.North != o.North || rotated.East != o.East || rotated.South != o.South || rotated.West != o.West {
+		t.Fatalf("rotated edges %v %v %v %v, want %+v", rotated.North, rotated.East, rotated.South, rotated.West, o)
+	}
+	road := rotated.Features[0]
+	if len(road.Edges) != 2 || road.Edges[0] != West || road.Edges[1] != North {
+		t.Fatalf("rotated road edges = %v, want west and north", road.Edges)
+	}
+
+	// Changing the copy's features leaves the tile's alone
+	road.Edges[0] = East
+	if tile.Features[0].Edges[0] != South {
+		t.Fatalf("the rotated tile shares feature edges with the tile")
+	}
+}
+
+func TestStandardTileSet(t *testing.T) {
+	tiles := CreateStandardTileSet()
+	if len(tiles) != 72 {
+		t.Fatalf("set has %d tiles, want 72", len(tiles))
+	}
+	if len(baseGameTileKinds)+1 != 24 {
+		t.Fatalf("set has %d kinds of tiles, want 24", len(baseGameTileKinds)+1)
+	}
+
+	monasteries, shields, kinds := 0, 0, make(map[int]bool)
+	for i, tile := range tiles {
+		if tile.ID != i {
+			t.Fatalf("tile %d has ID %d", i, tile.ID)
+		}
+		if tile.HasMonastery {
+			monasteries++
+		}
+		if tile.HasShield {
+			shields++
+		}
+		kinds[tile.KindID()] = true
+
+		// Every edge belongs to exactly one feature
+		edges := make(map[Direction]int)
+		for _, feature := range tile.Features {
+			for _, dir := range feature.Edges {
+				edges[dir]++
+			}
+		}
+		for _, dir := range []Direction{North, East, South, West} {
+			edge := (&PlacedTile{Tile: tile}).GetEdge(dir)
+			if edge != Field && edges[dir] != 1 {
+				t.Fatalf("%v edge of tile %d is in %d features", dir, i, edges[dir])
+			}
+		}
+	}
+	if monasteries != 6 || shields != 10 || len(kinds) != 24 {
+		t.Fatalf("set has %d monasteries, %d shields and %d kinds, want 6, 10 and 24", monasteries, shields, len(kinds))
+	}
+
+	start := tiles[0]
+	if start.North != City || start.East != Road || start.South != Field || start.West != Road {
+		t.Fatalf("first tile is not the starting tile: %+v", start)
+	}
+}
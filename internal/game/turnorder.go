@@ -0,0 +1,80 @@
+package game
+
+import "math/rand"
+
+// playerIndex returns the index of the player in Players, or -1
+func (b *Board) playerIndex(playerID string) int {
+	for i, player := range b.Players {
+		if player.ID == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// seatOrder returns the IDs of the players in the order they joined
+func (b *Board) seatOrder() []string {
+	order := make([]string, len(b.Players))
+	for i, player := range b.Players {
+		order[i] = player.ID
+	}
+	return order
+}
+
+// nextInTurnOrder returns the player taking their turn after the given one,
+// or "" if nobody else is left. Before the game started, players take turns
+// in the order they joined.
+func (b *Board) nextInTurnOrder(playerID string) string {
+	order := b.TurnOrder
+	if order == nil {
+		order = b.seatOrder()
+	}
+	
+	start := -1
+	for i, id := range order {
+		if id == playerID {
+			start = i
+			break
+		}
+	}
+	
+	for step := 1; step <= len(order); step++ {
+		next := order[(start+step+len(order))%len(order)]
+		if next != playerID && b.playerIndex(next) >= 0 {
+			return next
+		}
+	}
+	return ""
+}
+
+// shuffleTurnOrder shuffles TurnOrder with an RNG seeded from the board's
+// seed, so a seeded game reproduces its seating along with its deck
+func (b *Board) shuffleTurnOrder() {
+	rng := rand.New(rand.NewSource(b.Seed))
+	rng.Shuffle(len(b.TurnOrder), func(i, j int) {
+		b.TurnOrder[i], b.TurnOrder[j] = b.TurnOrder[j], b.TurnOrder[i]
+	})
+}
+
+// copyTurnOrder returns a copy of TurnOrder
+func (b *Board) copyTurnOrder() []string {
+	if b.TurnOrder == nil {
+		return nil
+	}
+	return append([]string{}, b.TurnOrder...)
+}
+
+// removeFromTurnOrder drops a player leaving the game from TurnOrder
+func (b *Board) removeFromTurnOrder(playerID string) {
+	if b.TurnOrder == nil {
+		return
+	}
+	
+	order := make([]string, 0, len(b.TurnOrder))
+	for _, id := range b.TurnOrder {
+		if id != playerID {
+			order = append(order, id)
+		}
+	}
+	b.TurnOrder = order
+}
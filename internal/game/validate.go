@@ -0,0 +1,146 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Validate checks the board for state corruption and returns every broken
+// invariant. It is meant as a cheap assertion after each move:
+//
+//   - every meeple on the board belongs to a player in the game
+//   - a feature segment on a tile holds at most one meeple
+//   - no meeple went on a connected feature that was already claimed when
+//     it was placed. Meeples on the same connected feature are legal only if
+//     their separate claims merged later. Telling when each tile was placed
+//     needs MoveHistory; meeples on tiles it doesn't record aren't checked.
+//   - no player has a negative score or a negative meeple supply
+//   - the current player index points into the player list
+//   - once the game started, the turn order lists every player exactly once
+//   - no tile is lost or duplicated: placed tiles, including the starting
+//     tile, plus the deck plus the current tile add up to the tile set size
+func (b *Board) Validate() []error {
+	errs := make([]error, 0)
+
+	for pos, tile := range b.Tiles {
+		claimed := make(map[int]bool)
+		for _, meeple := range tile.Meeples {
+			if b.GetPlayer(meeple.PlayerID) == nil {
+				errs = append(errs, fmt.Errorf("meeple at %v belongs to unknown player %q", pos, meeple.PlayerID))
+			}
+			if meeple.Type != NormalMeeple {
+				continue
+			}
+			if claimed[meeple.FeatureID] {
+				errs = append(errs, fmt.Errorf("feature %d at %v holds more than one meeple", meeple.FeatureID, pos))
+			}
+			claimed[meeple.FeatureID] = true
+		}
+	}
+
+	errs = append(errs, b.validateClaims()...)
+
+	for _, player := range b.Players {
+		if player.Score < 0 {
+			errs = append(errs, fmt.Errorf("player %q has negative score %d", player.ID, player.Score))
+		}
+		if player.Meeples < 0 {
+			errs = append(errs, fmt.Errorf("player %q has negative meeple supply %d", player.ID, player.Meeples))
+		}
+	}
+	for playerID, score := range b.Scores {
+		if score < 0 {
+			errs = append(errs, fmt.Errorf("score of %q is negative: %d", playerID, score))
+		}
+	}
+
+	if len(b.Players) > 0 && (b.CurrentPlayer < 0 || b.CurrentPlayer >= len(b.Players)) {
+		errs = append(errs, fmt.Errorf("current player index %d out of range [0, %d)", b.CurrentPlayer, len(b.Players)))
+	}
+
+	if b.GameStarted {
+		listed := make(map[string]bool, len(b.TurnOrder))
+		for _, playerID := range b.TurnOrder {
+			if listed[playerID] || b.GetPlayer(playerID) == nil {
+				errs = append(errs, fmt.Errorf("turn order lists %q more than once or for a player not in the game", playerID))
+			}
+			listed[playerID] = true
+		}
+		if len(listed) != len(b.Players) {
+			errs = append(errs, fmt.Errorf("turn order lists %d players, the game has %d", len(listed), len(b.Players)))
+		}
+	}
+
+	tileCount := len(b.Tiles) + len(b.TileDeck)
+	if b.CurrentTile != nil {
+		tileCount++
+	}
+	if tileCount != b.TotalTiles {
+		errs = append(errs, fmt.Errorf("tile count %d does not match tile set size %d", tileCount, b.TotalTiles))
+	}
+
+	return errs
+}
+
+// validateClaims checks every meeple against the board as it was when the
+// meeple was placed: only the tiles placed up to its turn, and only the
+// meeples placed before it. A meeple still on the board has been there since
+// it was placed, so finding an older one on the same connected feature means
+// it went on a claimed feature. The tiles are added to one feature graph in
+// the order they were placed, each turn's meeples are checked once the graph
+// holds the board of that turn.
+func (b *Board) validateClaims() []error {
+	errs := make([]error, 0)
+
+	// placedOn is the turn each tile was placed on; tiles missing from the
+	// history, like the starting tile, count as placed before the first turn
+	placedOn := make(map[Position]int, len(b.MoveHistory))
+	for _, record := range b.MoveHistory {
+		placedOn[record.Position] = record.Turn
+	}
+
+	positions := make([]Position, 0, len(b.Tiles))
+	for pos := range b.Tiles {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return placedOn[positions[i]] < placedOn[positions[j]]
+	})
+
+	placed := make(TileMap, len(b.Tiles))
+	graph := NewFeatureGraph()
+	for start := 0; start < len(positions); {
+		turn := placedOn[positions[start]]
+		end := start
+		for ; end < len(positions) && placedOn[positions[end]] == turn; end++ {
+			pos := positions[end]
+			placed[pos] = b.Tiles[pos]
+			graph.AddTile(placed, b.Tiles[pos])
+		}
+
+		for _, pos := range positions[start:end] {
+			if _, recorded := placedOn[pos]; !recorded {
+				continue
+			}
+			for _, meeple := range b.Tiles[pos].Meeples {
+				if meeple.Type != NormalMeeple {
+					continue
+				}
+
+				for _, ref := range graph.Connected(FeatureRef{Pos: pos, FeatureID: meeple.FeatureID}) {
+					if placedOn[ref.Pos] >= turn {
+						continue
+					}
+					for _, other := range b.Tiles[ref.Pos].Meeples {
+						if other.Type == NormalMeeple && other.FeatureID == ref.FeatureID {
+							errs = append(errs, fmt.Errorf("meeple of %q at %v went on feature %d already claimed by %q at %v", meeple.PlayerID, pos, meeple.FeatureID, other.PlayerID, ref.Pos))
+						}
+					}
+				}
+			}
+		}
+		start = end
+	}
+
+	return errs
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// mergedRoadsBoard plays three turns: "a" claims the road leaving a junction
+// south, "b" claims a separate road below the starting tile, and "a" then
+// joins both roads into one. Both claims are legal.
+func mergedRoadsBoard(t *testing.T) *Board {
+	t.Helper()
+
+	b := newStartedBoard(t)
+	playMeeple(t, b, kindRoadJunction, Position{1, 0}, 0, 1)
+	b.NextTurn()
+	playMeeple(t, b, kindRoadCurve, Position{0, 1}, 270, 0)
+	b.NextTurn()
+	play(t, b, kindRoadCurve, Position{1, 1}, 90)
+	b.NextTurn()
+
+	if claimants := b.featureClaimants(b.GetConnectedFeature(Position{1, 1}, 0)); len(claimants) != 2 {
+		t.Fatalf("merged road claimants = %v, want both players", claimants)
+	}
+	return b
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, b *Board)
+		want    string // part of an expected error, "" for a valid board
+	}{
+		{
+			name:    "valid game with merged claims",
+			corrupt: func(t *testing.T, b *Board) {},
+		},
+		{
+			name: "meeple of unknown player",
+			corrupt: func(t *testing.T, b *Board) {
+				b.Tiles[Position{1, 0}].Meeples[0].PlayerID = "ghost"
+			},
+			want: `belongs to unknown player "ghost"`,
+		},
+		{
+			name: "two meeples on one segment",
+			corrupt: func(t *testing.T, b *Board) {
+				tile := b.Tiles[Position{1, 0}]
+				tile.Meeples = append(tile.Meeples, PlacedMeeple{PlayerID: "b", FeatureID: 1})
+			},
+			want: "holds more than one meeple",
+		},
+		{
+			name: "meeple placed on a claimed feature",
+			corrupt: func(t *testing.T, b *Board) {
+				play(t, b, kindStraightRoad, Position{0, 2}, 0)
+				b.LastPlacedTile.Meeples = append(b.LastPlacedTile.Meeples, PlacedMeeple{PlayerID: "b", FeatureID: 0})
+			},
+			want: "already claimed",
+		},
+		{
+			name: "negative score",
+			corrupt: func(t *testing.T, b *Board) {
+				b.Players[0].Score = -1
+			},
+			want: "negative score",
+		},
+		{
+			name: "negative meeple supply",
+			corrupt: func(t *testing.T, b *Board) {
+				b.Players[1].Meeples = -1
+			},
+			want: "negative meeple supply",
+		},
+		{
+			name: "negative score entry",
+			corrupt: func(t *testing.T, b *Board) {
+				b.Scores["a"] = -3
+			},
+			want: `score of "a" is negative`,
+		},
+		{
+			name: "current player out of range",
+			corrupt: func(t *testing.T, b *Board) {
+				b.CurrentPlayer = len(b.Players)
+			},
+			want: "out of range",
+		},
+		{
+			name: "turn order repeats a player",
+			corrupt: func(t *testing.T, b *Board) {
+				b.TurnOrder = []string{"a", "a"}
+			},
+			want: "more than once",
+		},
+		{
+			name: "turn order misses a player",
+			corrupt: func(t *testing.T, b *Board) {
+				b.TurnOrder = b.TurnOrder[:1]
+			},
+			want: "turn order lists 1 players",
+		},
+		{
+			name: "lost tile",
+			corrupt: func(t *testing.T, b *Board) {
+				b.TileDeck = b.TileDeck[1:]
+			},
+			want: "tile count",
+		},
+		{
+			name: "duplicated tile",
+			corrupt: func(t *testing.T, b *Board) {
+				b.TileDeck = append(b.TileDeck, b.TileDeck[0])
+			},
+			want: "tile count",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := mergedRoadsBoard(t)
+			tt.corrupt(t, b)
+
+			errs := b.Validate()
+			if tt.want == "" {
+				if len(errs) > 0 {
+					t.Fatalf("Validate() = %v, want no errors", errs)
+				}
+				return
+			}
+
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.want) {
+					return
+				}
+			}
+			t.Fatalf("Validate() = %v, want an error containing %q", errs, tt.want)
+		})
+	}
+}
@@ -1,15 +1,22 @@
 package player
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"math/rand"
 	"time"
 	"carcassonne-ws/internal/game"
 )
 
+// ErrNoLegalMove is returned when the current tile fits nowhere on the board
+var ErrNoLegalMove = errors.New("no legal move")
+
 // Bot represents an AI player
 type Bot struct {
 	Player *game.Player
-	Difficulty string // "easy", "medium", "hard"
+	Difficulty string // "easy", "novice", "medium", "hard"
+	rng    *rand.Rand // Source of the bot's random choices
 }
 
 // NewBot creates a new bot player
@@ -19,26 +26,30 @@ func NewBot(id, name, color string) *Bot {
 			ID:      id,
 			Name:    name,
 			Color:   color,
-			Meeples: 7,
+			Meeples: game.DefaultMeeplesPerPlayer,
 			IsBot:   true,
 			Score:   0,
 		},
 		Difficulty: "easy",
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+// SetRand replaces the source of the bot's random choices, e.g. with a
+// seeded one to make its moves reproducible
+func (b *Bot) SetRand(rng *rand.Rand) {
+	b.rng = rng
+}
+
 // MakeMove makes a move for the bot
 func (b *Bot) MakeMove(board *game.Board) (BotMove, error) {
-	rand.Seed(time.Now().UnixNano())
-	
 	// Get valid placements
 	validPlacements := board.GetValidPlacements()
 	if len(validPlacements) == 0 {
-		return BotMove{}, nil // No valid moves
+		return BotMove{}, ErrNoLegalMove
 	}
 	
-	// Choose a random valid placement
-	placement := validPlacements[rand.Intn(len(validPlacements))]
+	placement := b.ChooseBestPlacement(validPlacements, board)
 	
 	move := BotMove{
 		TilePlacement: TilePlacement{
@@ -47,14 +58,9 @@ func (b *Bot) MakeMove(board *game.Board) (BotMove, error) {
 		},
 	}
 	
-	// Decide whether to place a meeple (50% chance for easy bots)
-	if b.Player.Meeples > 0 && rand.Float32() < 0.5 {
-		// Choose a random feature to place meeple on
-		if board.CurrentTile != nil && len(board.CurrentTile.Features) > 0 {
-			featureID := rand.Intn(len(board.CurrentTile.Features))
-			move.MeeplePlacement = &MeeplePlacement{
-				FeatureID: featureID,
-			}
+	if place, featureID := b.ShouldPlaceMeeple(board, placement); place {
+		move.MeeplePlacement = &MeeplePlacement{
+			FeatureID: featureID,
 		}
 	}
 	
@@ -87,11 +93,11 @@ func (b *Bot) ExecuteMove(board *game.Board, move BotMove) error {
 	}
 	
 	// Place meeple if specified
+	// MakeMove only picks claimable features, so this should not fail
 	if move.MeeplePlacement != nil {
 		err = board.PlaceMeeple(b.Player.ID, move.MeeplePlacement.FeatureID)
 		if err != nil {
-			// Meeple placement failed, but tile placement succeeded
-			// This is okay, just continue without the meeple
+			return fmt.Errorf("placing meeple: %w", err)
 		}
 	}
 	
@@ -103,57 +109,114 @@ func (b *Bot) GetStrategy() string {
 	switch b.Difficulty {
 	case "easy":
 		return "Random valid moves"
+	case "novice":
+		return "Random moves that don't strand its own features"
 	case "medium":
 		return "Prioritize completing features"
 	case "hard":
-		return "Advanced scoring optimization"
+		return "Maximize score gain, block opponents and control fields"
 	default:
 		return "Random valid moves"
 	}
 }
 
+// ValidDifficulties returns the difficulty levels a bot can play at, from
+// weakest to strongest
+func ValidDifficulties() []string {
+	return []string{"easy", "novice", "medium", "hard"}
+}
+
+// IsValidDifficulty checks if the difficulty is one of ValidDifficulties
+func IsValidDifficulty(difficulty string) bool {
+	for _, valid := range ValidDifficulties() {
+		if difficulty == valid {
+			return true
+		}
+	}
+	return false
+}
+
 // SetDifficulty sets the bot's difficulty level
 func (b *Bot) SetDifficulty(difficulty string) {
 	b.Difficulty = difficulty
 }
 
-// ShouldPlaceMeeple determines if the bot should place a meeple
-func (b *Bot) ShouldPlaceMeeple(board *game.Board, tile *game.Tile) (bool, int) {
-	if b.Player.Meeples <= 0 {
+// ShouldPlaceMeeple determines if the bot should place a meeple on the
+// current tile once placed as given, and on which feature. Only features a
+// meeple can legally go on are considered.
+func (b *Bot) ShouldPlaceMeeple(board *game.Board, placement game.PlacementOption) (bool, int) {
+	if b.Player.Meeples <= 0 || board.CurrentTile == nil {
+		return false, -1
+	}
+	
+	claimable := make([]int, 0)
+	for i := range board.CurrentTile.Features {
+		if board.IsFeatureClaimableAt(placement, i) {
+			claimable = append(claimable, i)
+		}
+	}
+	if len(claimable) == 0 {
 		return false, -1
 	}
 	
 	switch b.Difficulty {
-	case "easy":
+	case "easy", "novice":
 		// 50% chance to place meeple on random feature
-		if rand.Float32() < 0.5 && len(tile.Features) > 0 {
-			return true, rand.Intn(len(tile.Features))
+		if b.rng.Float32() < 0.5 {
+			return true, claimable[b.rng.Intn(len(claimable))]
+		}
+		return false, -1
+		
+	case "hard":
+		if featureID, value := b.bestMeeple(board, placement); value > 0 {
+			return true, featureID
 		}
 		return false, -1
 		
 	case "medium":
-		// Prefer monasteries and short roads/cities
-		for i, feature := range tile.Features {
-			if feature.Type == game.MonasteryFeature {
-				return true, i
+		// Pick the feature closest to completion, so the meeple comes back
+		// soon. The fewer meeples left, the closer it has to be.
+		best, bestTurns := -1, 0
+		for _, featureID := range claimable {
+			turns := estimateCompletionTurns(board, placement, featureID)
+			if turns < 0 {
+				continue
+			}
+			if best < 0 || turns < bestTurns {
+				best, bestTurns = featureID, turns
 			}
 		}
-		// Fallback to random
-		if rand.Float32() < 0.3 && len(tile.Features) > 0 {
-			return true, rand.Intn(len(tile.Features))
+		if best < 0 || bestTurns > maxCompletionTurns(b.Player.Meeples) {
+			return false, -1
 		}
-		return false, -1
-		
-	case "hard":
-		// More sophisticated strategy would go here
-		// For now, same as medium
-		return b.ShouldPlaceMeeple(board, tile)
+		return true, best
 		
 	default:
 		return false, -1
 	}
 }
 
+// estimateCompletionTurns estimates how many more tiles a feature of the
+// current tile needs to complete once placed as given: one per open end of
+// a road or city, one per empty position around a monastery. Fields never
+// complete and return -1.
+func estimateCompletionTurns(board *game.Board, placement game.PlacementOption, featureID int) int {
+	return board.OpenEdgesAt(placement, featureID)
+}
+
+// maxCompletionTurns returns how far from completion a feature may be for
+// the bot to still commit one of its remaining meeples to it
+func maxCompletionTurns(meeples int) int {
+	switch {
+	case meeples < 2:
+		return 1
+	case meeples < 4:
+		return 3
+	default:
+		return 8
+	}
+}
+
 // ChooseBestPlacement chooses the best tile placement based on difficulty
 func (b *Bot) ChooseBestPlacement(validPlacements []game.PlacementOption, board *game.Board) game.PlacementOption {
 	if len(validPlacements) == 0 {
@@ -163,19 +226,178 @@ func (b *Bot) ChooseBestPlacement(validPlacements []game.PlacementOption, board
 	switch b.Difficulty {
 	case "easy":
 		// Random placement
-		return validPlacements[rand.Intn(len(validPlacements))]
+		return validPlacements[b.rng.Intn(len(validPlacements))]
+		
+	case "novice":
+		// Random placement that keeps its own features completable
+		return b.chooseSafePlacement(validPlacements, board)
 		
 	case "medium":
 		// Prefer placements that complete features or extend existing ones
-		// For now, just random (would implement scoring logic here)
-		return validPlacements[rand.Intn(len(validPlacements))]
+		return b.chooseCompletingPlacement(validPlacements, board)
 		
 	case "hard":
-		// Advanced placement strategy
-		// For now, just random (would implement advanced AI here)
-		return validPlacements[rand.Intn(len(validPlacements))]
+		// Weigh every placement together with the best meeple it allows
+		return b.chooseHardPlacement(validPlacements, board)
+		
+	default:
+		return validPlacements[b.rng.Intn(len(validPlacements))]
+	}
+}
+
+// chooseSafePlacement picks a random placement among those that leave every
+// road and city the bot claims completable, or among all of them if none do
+func (b *Bot) chooseSafePlacement(validPlacements []game.PlacementOption, board *game.Board) game.PlacementOption {
+	safe := make([]game.PlacementOption, 0, len(validPlacements))
+	for _, placement := range validPlacements {
+		if board.StrandedFeaturesAt(placement, b.Player.ID) == 0 {
+			safe = append(safe, placement)
+		}
+	}
+	if len(safe) == 0 {
+		safe = validPlacements
+	}
+	
+	return safe[b.rng.Intn(len(safe))]
+}
+
+// chooseCompletingPlacement picks the placement that does the most for the
+// bot's own roads and cities: completing them first, then extending them.
+// Ties are broken randomly.
+func (b *Bot) chooseCompletingPlacement(validPlacements []game.PlacementOption, board *game.Board) game.PlacementOption {
+	best := make([]game.PlacementOption, 0)
+	bestScore := -1
+	
+	for _, placement := range validPlacements {
+		effect := board.EvaluatePlacement(placement, b.Player.ID)
+		score := 10*effect.CompletedOwn + effect.Points + 3*effect.ExtendedOwn
+		
+		switch {
+		case score > bestScore:
+			best = []game.PlacementOption{placement}
+			bestScore = score
+		case score == bestScore:
+			best = append(best, placement)
+		}
+	}
+	
+	return best[b.rng.Intn(len(best))]
+}
+
+// Weights of the hard bot's placement heuristic, in points
+const (
+	freedMeepleWeight = 2.0 // each own meeple back in supply
+	blockedWeight     = 2.0 // each opponent feature hemmed in
+	fieldWeight       = 0.5 // each end game field point gained or lost
+)
+
+// chooseHardPlacement rates every placement by the points it gains and the
+// meeples it frees, the opponent features it blocks and the field control it
+// wins, plus the best meeple it allows, and returns the highest rated one.
+// Ties are broken randomly.
+func (b *Bot) chooseHardPlacement(validPlacements []game.PlacementOption, board *game.Board) game.PlacementOption {
+	fieldsBefore := board.FieldScores()
+	best := make([]game.PlacementOption, 0)
+	bestScore := math.Inf(-1)
+	
+	for _, placement := range validPlacements {
+		score := b.ratePlacement(board, placement, fieldsBefore)
+		if _, value := b.bestMeeple(board, placement); value > 0 {
+			score += value
+		}
+		
+		switch {
+		case score > bestScore:
+			best = []game.PlacementOption{placement}
+			bestScore = score
+		case score == bestScore:
+			best = append(best, placement)
+		}
+	}
+	
+	return best[b.rng.Intn(len(best))]
+}
+
+// ratePlacement rates a placement for the hard bot. Points scored by
+// opponents and field points they gain count against it.
+func (b *Bot) ratePlacement(board *game.Board, placement game.PlacementOption, fieldsBefore map[string]int) float64 {
+	sim := board.SimulateScore(placement)
+	
+	score := freedMeepleWeight * float64(sim.FreedMeeples[b.Player.ID])
+	score += blockedWeight * float64(board.BlockedFeaturesAt(placement, b.Player.ID))
+	for _, player := range board.Players {
+		gain := float64(sim.Points[player.ID]) + fieldWeight*float64(sim.FieldPoints[player.ID]-fieldsBefore[player.ID])
+		if player.ID == b.Player.ID {
+			score += gain
+		} else {
+			score -= gain
+		}
+	}
+	
+	return score
+}
+
+// bestMeeple picks the claimable feature of a placement where a meeple is
+// expected to earn the most beyond what it costs to tie it up, and returns
+// it with that margin. It returns -1 when the bot has no meeple left.
+func (b *Bot) bestMeeple(board *game.Board, placement game.PlacementOption) (int, float64) {
+	if b.Player.Meeples <= 0 || board.CurrentTile == nil {
+		return -1, 0
+	}
+	
+	best, bestValue := -1, math.Inf(-1)
+	for i, feature := range board.CurrentTile.Features {
+		if !board.IsFeatureClaimableAt(placement, i) {
+			continue
+		}
+		
+		value := expectedMeepleValue(board, placement, i) - meepleCost(b.Player.Meeples)
+		if feature.Type == game.FieldFeature && board.TotalTiles > 0 {
+			// A farmer never comes back, so it costs more the earlier it goes
+			value -= 6 * float64(len(board.TileDeck)) / float64(board.TotalTiles)
+		}
+		if value > bestValue {
+			best, bestValue = i, value
+		}
+	}
+	
+	return best, bestValue
+}
+
+// expectedMeepleValue estimates what a meeple on a feature of the current
+// tile would score once placed as given. Roads and cities only score when
+// completed, so each open end makes them more valuable but less likely to
+// pay out.
+func expectedMeepleValue(board *game.Board, placement game.PlacementOption, featureID int) float64 {
+	switch board.CurrentTile.Features[featureID].Type {
+	case game.RoadFeature, game.CityFeature:
+		open := board.OpenEdgesAt(placement, featureID)
+		if open == 0 {
+			// Already scored when the tile is placed
+			return 0
+		}
+		growth := 1
+		if board.CurrentTile.Features[featureID].Type == game.CityFeature {
+			growth = 2
+		}
+		value := float64(board.FeatureValueAt(placement, featureID) + growth*open)
+		return value * 2 / float64(2+open)
+		
+	case game.MonasteryFeature:
+		empty := board.OpenEdgesAt(placement, featureID)
+		return float64(9-empty) + 0.6*float64(empty)
+		
+	case game.FieldFeature:
+		completed, incomplete := board.FieldCitiesAt(placement, featureID)
+		return 3*float64(completed) + 1.5*float64(incomplete)
 		
 	default:
-		return validPlacements[rand.Intn(len(validPlacements))]
+		return 0
 	}
 }
+
+// meepleCost is what tying up one of the bot's remaining meeples is worth,
+// growing as the supply runs low
+func meepleCost(meeples int) float64 {
+	return 1 + 4/float64(meeples)
+}
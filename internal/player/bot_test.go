@@ -0,0 +1,154 @@
+package player
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"carcassonne-ws/internal/game"
+)
+
+// drawTile makes the first deck tile with the given edges the current tile,
+// putting the current tile back in its place
+func drawTile(t *testing.T, board *game.Board, north, east, south, west game.TileEdge) {
+	t.Helper()
+
+	for i, tile := range board.TileDeck {
+		if tile.North == north && tile.East == east && tile.South == south && tile.West == west {
+			board.TileDeck[i], board.CurrentTile = board.CurrentTile, tile
+			return
+		}
+	}
+	t.Fatalf("no tile with edges %v %v %v %v in the deck", north, east, south, west)
+}
+
+// newBotGame starts a seeded game of the bot against player "b"
+func newBotGame(t *testing.T, bot *Bot) *game.Board {
+	t.Helper()
+
+	board := game.NewBoardWithSeed(1)
+	for _, p := range []*game.Player{bot.Player, {ID: "b", Name: "b"}} {
+		if err := board.AddPlayer(p); err != nil {
+			t.Fatalf("AddPlayer: %v", err)
+		}
+	}
+	if err := board.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	return board
+}
+
+func TestMediumBotCompletesOwnRoad(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		bot := NewBot("bot", "Bot", "red")
+		bot.SetDifficulty("medium")
+		bot.SetRand(rand.New(rand.NewSource(seed)))
+		board := newBotGame(t, bot)
+
+		// The bot claims the starting tile's road, ended by a junction east
+		drawTile(t, board, game.Field, game.Road, game.Road, game.Road)
+		if err := board.PlaceTile(game.Position{X: 1, Y: 0}, 0); err != nil {
+			t.Fatalf("PlaceTile: %v", err)
+		}
+		if err := board.PlaceMeeple("bot", 2); err != nil {
+			t.Fatalf("PlaceMeeple: %v", err)
+		}
+		board.NextTurn()
+		drawTile(t, board, game.Field, game.Field, game.Field, game.Field)
+		if err := board.PlaceTile(game.Position{X: 0, Y: 1}, 0); err != nil {
+			t.Fatalf("PlaceTile: %v", err)
+		}
+		board.NextTurn()
+
+		// Another junction west of the starting tile completes the road
+		drawTile(t, board, game.Field, game.Road, game.Road, game.Road)
+		placement := bot.ChooseBestPlacement(board.GetValidPlacements(), board)
+		if effect := board.EvaluatePlacement(placement, "bot"); effect.CompletedOwn != 1 || effect.Points != 3 {
+			t.Fatalf("seed %d: bot chose %+v doing %+v, want the placement completing its road", seed, placement, effect)
+		}
+	}
+}
+
+// playBotGame plays a seeded game between the bots to its end and returns
+// the board with the moves made
+func playBotGame(t *testing.T, seed int64, bots ...*Bot) (*game.Board, []BotMove) {
+	t.Helper()
+
+	board := game.NewBoardWithSeed(seed)
+	for _, bot := range bots {
+		if err := board.AddPlayer(bot.Player); err != nil {
+			t.Fatalf("AddPlayer: %v", err)
+		}
+	}
+	if err := board.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	var moves []BotMove
+	for !board.GameEnded {
+		var bot *Bot
+		for _, b := range bots {
+			if b.Player.ID == board.GetCurrentPlayer().ID {
+				bot = b
+			}
+		}
+		move, err := bot.MakeMove(board)
+		if err == ErrNoLegalMove {
+			board.DiscardCurrentTile()
+			board.NextTurn()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("MakeMove: %v", err)
+		}
+		if err := bot.ExecuteMove(board, move); err != nil {
+			t.Fatalf("ExecuteMove: %v", err)
+		}
+		moves = append(moves, move)
+		board.NextTurn()
+	}
+	return board, moves
+}
+
+// newSeededBot returns a bot of the given difficulty with a seeded random
+// source
+func newSeededBot(id, difficulty string, seed int64) *Bot {
+	bot := NewBot(id, id, "red")
+	bot.SetDifficulty(difficulty)
+	bot.SetRand(rand.New(rand.NewSource(seed)))
+	return bot
+}
+
+func TestSeededBotsReplay(t *testing.T) {
+	play := func(difficulty string) []BotMove {
+		_, moves := playBotGame(t, 3, newSeededBot("bot1", difficulty, 1), newSeededBot("bot2", difficulty, 2))
+		return moves
+	}
+
+	for _, difficulty := range ValidDifficulties() {
+		first, again := play(difficulty), play(difficulty)
+		if len(first) == 0 || !reflect.DeepEqual(first, again) {
+			t.Fatalf("seeded %s bots played %d and %d different moves", difficulty, len(first), len(again))
+		}
+	}
+}
+
+func TestHardBotBeatsEasyBot(t *testing.T) {
+	const games = 10
+
+	wins := 0
+	for seed := int64(0); seed < games; seed++ {
+		hard, easy := newSeededBot("hard", "hard", seed), newSeededBot("easy", "easy", seed)
+		bots := []*Bot{hard, easy}
+		if seed%2 == 1 {
+			bots = []*Bot{easy, hard}
+		}
+
+		playBotGame(t, seed, bots...)
+		if hard.Player.Score > easy.Player.Score {
+			wins++
+		}
+	}
+	if wins < games*3/4 {
+		t.Fatalf("hard bot won %d of %d games against the easy bot", wins, games)
+	}
+}
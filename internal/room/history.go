@@ -0,0 +1,121 @@
+package room
+
+import (
+	"sync"
+	"time"
+)
+
+// maxGamesPerPlayer bounds how many finished games are kept per player,
+// the oldest ones are dropped first
+const maxGamesPerPlayer = 100
+
+// Game outcomes from a player's point of view
+const (
+	OutcomeWin  = "win"
+	OutcomeDraw = "draw"
+	OutcomeLoss = "loss"
+)
+
+// FinalScore represents a player's score at the end of a game
+type FinalScore struct {
+	PlayerID string `json:"playerId"`
+	Name     string `json:"name"`
+	Score    int    `json:"score"`
+	IsBot    bool   `json:"isBot"`
+}
+
+// GameSummary represents a finished game as seen by one of its players
+type GameSummary struct {
+	RoomID     string       `json:"roomId"`
+	FinishedAt time.Time    `json:"finishedAt"`
+	Score      int          `json:"score"`
+	Outcome    string       `json:"outcome"`
+	Players    []FinalScore `json:"players"`
+}
+
+// GameHistory keeps the finished games of each player, newest first
+type GameHistory struct {
+	games map[string][]GameSummary
+	mutex sync.RWMutex
+}
+
+// NewGameHistory creates an empty game history
+func NewGameHistory() *GameHistory {
+	return &GameHistory{
+		games: make(map[string][]GameSummary),
+	}
+}
+
+// Record adds a finished game to the history of every human player in it
+func (h *GameHistory) Record(result GameResult) {
+	scores := make([]FinalScore, 0, len(result.Players))
+	best, bestCount := 0, 0
+	for _, p := range result.Players {
+		scores = append(scores, FinalScore{
+			PlayerID: p.ID,
+			Name:     p.Name,
+			Score:    p.Score,
+			IsBot:    p.IsBot,
+		})
+	
+		switch {
+		case bestCount == 0 || p.Score > best:
+			best, bestCount = p.Score, 1
+		case p.Score == best:
+			bestCount++
+		}
+	}
+	
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	
+	for _, p := range result.Players {
+		if p.IsBot {
+			continue
+		}
+	
+		outcome := OutcomeLoss
+		if p.Score == best {
+			outcome = OutcomeWin
+			if bestCount > 1 {
+				outcome = OutcomeDraw
+			}
+		}
+	
+		summary := GameSummary{
+			RoomID:     result.RoomID,
+			FinishedAt: result.FinishedAt,
+			Score:      p.Score,
+			Outcome:    outcome,
+			Players:    scores,
+		}
+	
+		games := append([]GameSummary{summary}, h.games[p.ID]...)
+		if len(games) > maxGamesPerPlayer {
+			games = games[:maxGamesPerPlayer]
+		}
+		h.games[p.ID] = games
+	}
+}
+
+// Get returns a page of a player's finished games, newest first, along with
+// the total number of games kept for that player
+func (h *GameHistory) Get(playerID string, offset, limit int) ([]GameSummary, int) {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	
+	games := h.games[playerID]
+	total := len(games)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	
+	return append([]GameSummary{}, games[offset:end]...), total
+}
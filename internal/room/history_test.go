@@ -0,0 +1,80 @@
+package room
+
+import (
+	"fmt"
+	"testing"
+	"time"
+	"carcassonne-ws/internal/game"
+)
+
+// gameResult returns the result of a game of human players with the given
+// scores, and of bots scoring 50 points
+func gameResult(roomID string, scores map[string]int, bots ...string) GameResult {
+	result := GameResult{RoomID: roomID, FinishedAt: time.Now()}
+	for id, score := range scores {
+		result.Players = append(result.Players, game.Player{ID: id, Name: id, Score: score})
+	}
+	for _, id := range bots {
+		result.Players = append(result.Players, game.Player{ID: id, Name: id, Score: 50, IsBot: true})
+	}
+	return result
+}
+
+func TestGameHistoryOutcomes(t *testing.T) {
+	h := NewGameHistory()
+	h.Record(gameResult("won", map[string]int{"alice": 30, "bob": 20}))
+	h.Record(gameResult("drawn", map[string]int{"alice": 25, "bob": 25}))
+	h.Record(gameResult("beaten by a bot", map[string]int{"alice": 40}, "Bot 1"))
+
+	games, total := h.Get("alice", 0, 10)
+	if total != 3 || len(games) != 3 {
+		t.Fatalf("alice has %d of %d games, want 3", len(games), total)
+	}
+	want := []struct{ roomID, outcome string }{
+		{"beaten by a bot", OutcomeLoss},
+		{"drawn", OutcomeDraw},
+		{"won", OutcomeWin},
+	}
+	for i, w := range want {
+		if games[i].RoomID != w.roomID || games[i].Outcome != w.outcome {
+			t.Fatalf("game %d = %s %s, want %s %s", i, games[i].RoomID, games[i].Outcome, w.roomID, w.outcome)
+		}
+	}
+	if outcome := h.games["bob"][1].Outcome; outcome != OutcomeLoss {
+		t.Fatalf("bob's first game is a %s, want a loss", outcome)
+	}
+	if _, total := h.Get("Bot 1", 0, 10); total != 0 {
+		t.Fatalf("bot has %d games in the history, want none", total)
+	}
+}
+
+func TestGameHistoryPages(t *testing.T) {
+	h := NewGameHistory()
+	for i := 0; i < maxGamesPerPlayer+5; i++ {
+		h.Record(gameResult(fmt.Sprintf("room %d", i), map[string]int{"alice": i}))
+	}
+
+	games, total := h.Get("alice", 0, 0)
+	if total != maxGamesPerPlayer || len(games) != maxGamesPerPlayer {
+		t.Fatalf("kept %d of %d games, want the last %d", len(games), total, maxGamesPerPlayer)
+	}
+	if last := fmt.Sprintf("room %d", maxGamesPerPlayer+4); games[0].RoomID != last {
+		t.Fatalf("newest game is %q, want %q", games[0].RoomID, last)
+	}
+
+	tests := []struct {
+		offset, limit, want int
+	}{
+		{offset: 0, limit: 10, want: 10},
+		{offset: 95, limit: 10, want: 5},
+		{offset: 100, limit: 10, want: 0},
+		{offset: 500, limit: 10, want: 0},
+		{offset: -3, limit: 2, want: 2},
+	}
+	for _, tt := range tests {
+		page, total := h.Get("alice", tt.offset, tt.limit)
+		if len(page) != tt.want || total != maxGamesPerPlayer {
+			t.Fatalf("Get(%d, %d) = %d games of %d, want %d", tt.offset, tt.limit, len(page), total, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package room
+
+import (
+	"sort"
+	"sync"
+	"time"
+	"carcassonne-ws/internal/game"
+)
+
+// DailyChallengeDate returns the tag of the daily challenge for a given time
+func DailyChallengeDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// DailyChallengeSeed returns the deck seed shared by every daily challenge
+// game of the given date
+func DailyChallengeSeed(date string) int64 {
+	return game.SeedFromString("daily-" + date)
+}
+
+// LeaderboardEntry represents a player's final score in a daily challenge
+type LeaderboardEntry struct {
+	PlayerID   string    `json:"playerId"`
+	Name       string    `json:"name"`
+	Score      int       `json:"score"`
+	RoomID     string    `json:"roomId"`
+	FinishedAt time.Time `json:"finishedAt"`
+}
+
+// Leaderboard keeps daily challenge results grouped by date
+type Leaderboard struct {
+	entries map[string][]LeaderboardEntry
+	mutex   sync.RWMutex
+}
+
+// NewLeaderboard creates an empty leaderboard
+func NewLeaderboard() *Leaderboard {
+	return &Leaderboard{
+		entries: make(map[string][]LeaderboardEntry),
+	}
+}
+
+// Record adds results to the leaderboard of the given date
+func (l *Leaderboard) Record(date string, entries ...LeaderboardEntry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	
+	l.entries[date] = append(l.entries[date], entries...)
+}
+
+// Get returns the results of the given date, best score first
+func (l *Leaderboard) Get(date string) []LeaderboardEntry {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	
+	entries := append([]LeaderboardEntry{}, l.entries[date]...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Score > entries[j].Score
+	})
+	
+	return entries
+}
@@ -0,0 +1,81 @@
+package room
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// deckOrder returns the IDs of a room's undrawn tiles, in order
+func deckOrder(r *Room) []int {
+	ids := make([]int, len(r.Board.TileDeck))
+	for i, tile := range r.Board.TileDeck {
+		ids[i] = tile.ID
+	}
+	return ids
+}
+
+func TestDailyChallengeDeck(t *testing.T) {
+	m := NewManager()
+	morning := time.Date(2024, 5, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	evening := time.Date(2024, 5, 1, 23, 0, 0, 0, time.UTC)
+	if DailyChallengeDate(morning) != "2024-04-30" || DailyChallengeDate(evening) != "2024-05-01" {
+		t.Fatalf("dates = %q, %q, want the UTC days", DailyChallengeDate(morning), DailyChallengeDate(evening))
+	}
+
+	first, err := m.CreateDailyChallenge("first", "alice", 2, evening)
+	if err != nil {
+		t.Fatalf("CreateDailyChallenge: %v", err)
+	}
+	second, err := m.CreateDailyChallenge("second", "bob", 2, evening.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("CreateDailyChallenge: %v", err)
+	}
+	otherDay, err := m.CreateDailyChallenge("other day", "carol", 2, morning)
+	if err != nil {
+		t.Fatalf("CreateDailyChallenge: %v", err)
+	}
+
+	if !reflect.DeepEqual(deckOrder(first), deckOrder(second)) {
+		t.Fatalf("daily challenges of the same day have different decks")
+	}
+	if reflect.DeepEqual(deckOrder(first), deckOrder(otherDay)) {
+		t.Fatalf("daily challenges of different days have the same deck")
+	}
+}
+
+func TestDailyChallengeLeaderboard(t *testing.T) {
+	m := NewManager()
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	date := DailyChallengeDate(day)
+
+	r, err := m.CreateDailyChallenge("daily", "alice", 4, day)
+	if err != nil {
+		t.Fatalf("CreateDailyChallenge: %v", err)
+	}
+	seatPlayers(t, r, "alice", "bob")
+	if err := r.AddBot("Bot", "easy", "alice"); err != nil {
+		t.Fatalf("AddBot: %v", err)
+	}
+	startRoom(t, r)
+	for i, p := range r.Board.Players {
+		p.Score = 10 * i
+	}
+	endGame(t, r)
+
+	entries := m.GetLeaderboard(date)
+	if len(entries) != 2 {
+		t.Fatalf("leaderboard = %+v, want the two humans", entries)
+	}
+	for i, entry := range entries {
+		if entry.RoomID != r.ID || entry.PlayerID == "" {
+			t.Fatalf("entry %d = %+v, want a player of room %q", i, entry, r.ID)
+		}
+		if i > 0 && entry.Score > entries[i-1].Score {
+			t.Fatalf("leaderboard = %+v, want the best score first", entries)
+		}
+	}
+	if other := m.GetLeaderboard("2024-05-02"); len(other) != 0 {
+		t.Fatalf("leaderboard of another day = %+v, want none", other)
+	}
+}
@@ -2,34 +2,259 @@ package room
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"sync"
+	"time"
 	"carcassonne-ws/internal/game"
 )
 
 // Manager manages all game rooms
 type Manager struct {
-	rooms map[string]*Room
-	mutex sync.RWMutex
+	rooms       map[string]*Room
+	leaderboard *Leaderboard
+	history     *GameHistory
+	mutex       sync.RWMutex
+	
+	// Games started and ended since the server started
+	gamesStarted int
+	gamesEnded   int
+	statsMutex   sync.Mutex
+	
+	// now is the clock rooms are created and cleaned up by
+	now func() time.Time
+	
+	// Room creation limits: rooms in total, and rooms each player may
+	// create per minute along with when they created their recent ones
+	maxRooms       int
+	roomsPerMinute int
+	createdAt      map[string][]time.Time
 }
 
+// Defaults of the idle room sweeper, see RunSweeper
+const (
+	DefaultSweepInterval = time.Minute
+	DefaultIdleRoomTTL   = 30 * time.Minute
+)
+
+// Default room creation limits, see SetRoomLimits
+const (
+	DefaultMaxRooms       = 1000
+	DefaultRoomsPerMinute = 5
+)
+
 // NewManager creates a new room manager
 func NewManager() *Manager {
 	return &Manager{
-		rooms: make(map[string]*Room),
+		rooms:       make(map[string]*Room),
+		leaderboard: NewLeaderboard(),
+		history:     NewGameHistory(),
+		now:         time.Now,
+		
+		maxRooms:       DefaultMaxRooms,
+		roomsPerMinute: DefaultRoomsPerMinute,
+		createdAt:      make(map[string][]time.Time),
 	}
 }
 
+// SetRoomLimits sets how many rooms may exist at once and how many rooms a
+// player may create per minute
+func (m *Manager) SetRoomLimits(maxRooms, roomsPerMinute int) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	m.maxRooms = maxRooms
+	m.roomsPerMinute = roomsPerMinute
+}
+
+// SetClock replaces the clock used to date and clean up rooms, e.g. with a
+// fake one in tests
+func (m *Manager) SetClock(now func() time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	
+	m.now = now
+}
+
 // CreateRoom creates a new room
-func (m *Manager) CreateRoom(name, createdBy string, maxPlayers int) (*Room, error) {
+func (m *Manager) CreateRoom(name, createdBy string, maxPlayers int, options Options) (*Room, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
-	room := NewRoom(name, createdBy, maxPlayers)
+	now := m.now()
+	if len(m.rooms) >= m.maxRooms || m.recentRooms(createdBy, now) >= m.roomsPerMinute {
+		return nil, ErrRoomLimit
+	}
+	m.createdAt[createdBy] = append(m.createdAt[createdBy], now)
+	
+	room := NewRoom(name, createdBy, maxPlayers, options)
+	room.CreatedAt = now
+	room.onGameEnd = m.handleGameEnd
 	m.rooms[room.ID] = room
 	
 	return room, nil
 }
 
+// snapshotPrefix and snapshotExt name the files SaveAll writes, one per room
+const (
+	snapshotPrefix = "room-"
+	snapshotExt    = ".json"
+)
+
+// SaveAll writes a snapshot of every unfinished room to dir, replacing the
+// snapshots of a previous save
+func (m *Manager) SaveAll(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	
+	m.mutex.RLock()
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		rooms = append(rooms, room)
+	}
+	m.mutex.RUnlock()
+	
+	saved := make(map[string]bool)
+	for _, room := range rooms {
+		if room.HasEnded() {
+			continue
+		}
+		
+		data, err := room.Snapshot()
+		if err != nil {
+			return fmt.Errorf("saving room %s: %w", room.ID, err)
+		}
+		
+		// Write to a temporary file first so a crash never leaves half a snapshot
+		name := snapshotPrefix + room.ID + snapshotExt
+		tmp := filepath.Join(dir, name+".tmp")
+		if err := os.WriteFile(tmp, data, 0o644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+			return err
+		}
+		saved[name] = true
+	}
+	
+	// Drop snapshots of rooms that are gone since the last save
+	files, err := filepath.Glob(filepath.Join(dir, snapshotPrefix+"*"+snapshotExt))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if !saved[filepath.Base(file)] {
+			os.Remove(file)
+		}
+	}
+	
+	return nil
+}
+
+// LoadAll restores the rooms saved to dir and returns them. A missing
+// directory holds no rooms.
+func (m *Manager) LoadAll(dir string) ([]*Room, error) {
+	files, err := filepath.Glob(filepath.Join(dir, snapshotPrefix+"*"+snapshotExt))
+	if err != nil {
+		return nil, err
+	}
+	
+	loaded := make([]*Room, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return loaded, err
+		}
+		
+		room, err := LoadRoom(data)
+		if err != nil {
+			return loaded, fmt.Errorf("loading %s: %w", filepath.Base(file), err)
+		}
+		
+		m.mutex.Lock()
+		room.onGameEnd = m.handleGameEnd
+		m.rooms[room.ID] = room
+		m.mutex.Unlock()
+		
+		loaded = append(loaded, room)
+	}
+	
+	return loaded, nil
+}
+
+// recentRooms returns how many rooms the player created in the minute before
+// now, forgetting older ones.
+// Must be called with the manager lock held.
+func (m *Manager) recentRooms(playerID string, now time.Time) int {
+	recent := m.createdAt[playerID][:0]
+	for _, created := range m.createdAt[playerID] {
+		if now.Sub(created) < time.Minute {
+			recent = append(recent, created)
+		}
+	}
+	
+	if len(recent) == 0 {
+		delete(m.createdAt, playerID)
+		return 0
+	}
+	m.createdAt[playerID] = recent
+	return len(recent)
+}
+
+// CreateDailyChallenge creates a room whose deck is shared by every daily
+// challenge game of the given day
+func (m *Manager) CreateDailyChallenge(name, createdBy string, maxPlayers int, day time.Time) (*Room, error) {
+	date := DailyChallengeDate(day)
+	
+	options := DefaultOptions()
+	options.DailyChallenge = date
+	options.Seed = DailyChallengeSeed(date)
+	
+	return m.CreateRoom(name, createdBy, maxPlayers, options)
+}
+
+// handleGameEnd records the result of a finished game
+func (m *Manager) handleGameEnd(result GameResult) {
+	m.statsMutex.Lock()
+	m.gamesEnded++
+	m.statsMutex.Unlock()
+	
+	m.history.Record(result)
+	
+	if result.Options.DailyChallenge == "" {
+		return
+	}
+	
+	entries := make([]LeaderboardEntry, 0, len(result.Players))
+	for _, p := range result.Players {
+		if p.IsBot {
+			continue
+		}
+		entries = append(entries, LeaderboardEntry{
+			PlayerID:   p.ID,
+			Name:       p.Name,
+			Score:      p.Score,
+			RoomID:     result.RoomID,
+			FinishedAt: result.FinishedAt,
+		})
+	}
+	
+	m.leaderboard.Record(result.Options.DailyChallenge, entries...)
+}
+
+// GetLeaderboard returns the daily challenge results of the given date
+func (m *Manager) GetLeaderboard(date string) []LeaderboardEntry {
+	return m.leaderboard.Get(date)
+}
+
+// GetPlayerGames returns a page of a player's finished games, newest first,
+// and the total number of games kept for that player
+func (m *Manager) GetPlayerGames(playerID string, offset, limit int) ([]GameSummary, int) {
+	return m.history.Get(playerID, offset, limit)
+}
+
 // GetRoom returns a room by ID
 func (m *Manager) GetRoom(roomID string) (*Room, error) {
 	m.mutex.RLock()
@@ -37,22 +262,40 @@ func (m *Manager) GetRoom(roomID string) (*Room, error) {
 	
 	room, exists := m.rooms[roomID]
 	if !exists {
-		return nil, fmt.Errorf("room not found")
+		return nil, ErrRoomNotFound
 	}
 	
 	return room, nil
 }
 
-// JoinRoom adds a player to a room
-func (m *Manager) JoinRoom(roomID string, player *game.Player) error {
+// JoinRoom adds a player to a room. Private rooms need their password.
+func (m *Manager) JoinRoom(roomID string, player *game.Player, password string) error {
 	room, err := m.GetRoom(roomID)
 	if err != nil {
 		return err
 	}
 	
+	if err := room.CheckPassword(password); err != nil {
+		return err
+	}
+	
 	return room.AddPlayer(player)
 }
 
+// FindActiveGame returns the running game a player is seated in, along with
+// their seat, or nil if there is none
+func (m *Manager) FindActiveGame(playerID string) (*Room, *game.Player) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	for _, room := range m.rooms {
+		if seat := room.GetSeatedPlayer(playerID); seat != nil {
+			return room, seat
+		}
+	}
+	return nil, nil
+}
+
 // LeaveRoom removes a player from a room
 func (m *Manager) LeaveRoom(roomID, playerID string) error {
 	room, err := m.GetRoom(roomID)
@@ -65,8 +308,9 @@ func (m *Manager) LeaveRoom(roomID, playerID string) error {
 		return err
 	}
 	
-	// Clean up empty rooms
-	if room.GetPlayerCount() == 0 && !room.GameStarted {
+	// Clean up empty rooms. A started game is kept while somebody watches
+	// it, the sweeper removes it later.
+	if room.GetPlayerCount() == 0 && (!room.HasStarted() || room.GetSpectatorCount() == 0) {
 		m.mutex.Lock()
 		delete(m.rooms, roomID)
 		m.mutex.Unlock()
@@ -75,6 +319,47 @@ func (m *Manager) LeaveRoom(roomID, playerID string) error {
 	return nil
 }
 
+// ForfeitRoom takes a player out of the running game of a room, see
+// Room.Forfeit
+func (m *Manager) ForfeitRoom(roomID, playerID string) (bool, error) {
+	room, err := m.GetRoom(roomID)
+	if err != nil {
+		return false, err
+	}
+	
+	return room.Forfeit(playerID)
+}
+
+// SetReady marks a player of a room as ready to start, or not
+func (m *Manager) SetReady(roomID, playerID string, ready bool) error {
+	room, err := m.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	
+	return room.SetReady(playerID, ready)
+}
+
+// SetPlayerName renames a player in a room before its game starts
+func (m *Manager) SetPlayerName(roomID, playerID, name string) (string, error) {
+	room, err := m.GetRoom(roomID)
+	if err != nil {
+		return "", err
+	}
+	
+	return room.SetPlayerName(playerID, name)
+}
+
+// BanPlayer bans a player from a room
+func (m *Manager) BanPlayer(roomID, playerID, creatorID string) error {
+	room, err := m.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	
+	return room.BanPlayer(playerID, creatorID)
+}
+
 // ListRooms returns all available rooms
 func (m *Manager) ListRooms() []RoomInfo {
 	m.mutex.RLock()
@@ -88,7 +373,7 @@ func (m *Manager) ListRooms() []RoomInfo {
 	return rooms
 }
 
-// GetActiveRooms returns only rooms that are not full and not started
+// GetActiveRooms returns only public rooms that are not full and not started
 func (m *Manager) GetActiveRooms() []RoomInfo {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
@@ -96,7 +381,7 @@ func (m *Manager) GetActiveRooms() []RoomInfo {
 	rooms := make([]RoomInfo, 0)
 	for _, room := range m.rooms {
 		info := room.GetRoomInfo()
-		if !info.GameStarted && info.PlayerCount < info.MaxPlayers {
+		if !info.GameStarted && info.PlayerCount < info.MaxPlayers && !info.HasPassword {
 			rooms = append(rooms, info)
 		}
 	}
@@ -104,13 +389,34 @@ func (m *Manager) GetActiveRooms() []RoomInfo {
 	return rooms
 }
 
+// GetLiveRooms returns every room whose game hasn't ended, newest first,
+// including running games that can only be watched
+func (m *Manager) GetLiveRooms() []RoomInfo {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	rooms := make([]RoomInfo, 0, len(m.rooms))
+	for _, room := range m.rooms {
+		if room.HasEnded() {
+			continue
+		}
+		rooms = append(rooms, room.GetRoomInfo())
+	}
+	
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].CreatedAt.After(rooms[j].CreatedAt)
+	})
+	
+	return rooms
+}
+
 // FindPlayerRoom finds the room a player is currently in
 func (m *Manager) FindPlayerRoom(playerID string) (*Room, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 	
 	for _, room := range m.rooms {
-		if _, exists := room.Players[playerID]; exists {
+		if room.GetPlayer(playerID) != nil {
 			return room, nil
 		}
 	}
@@ -118,14 +424,44 @@ func (m *Manager) FindPlayerRoom(playerID string) (*Room, error) {
 	return nil, fmt.Errorf("player not in any room")
 }
 
-// CleanupEmptyRooms removes empty rooms that are not in progress
-func (m *Manager) CleanupEmptyRooms() {
+// CleanupIdleRooms removes the rooms that were idle for at least ttl and
+// returns how many it removed. Rooms whose game never started are idle
+// without a human player, counted from their creation so their creator has
+// time to sit down. Started rooms are idle once their game ended or no human
+// player or spectator is left, counted from the first cleanup that finds
+// them idle, so players get to look at the final scores.
+func (m *Manager) CleanupIdleRooms(ttl time.Duration) int {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	
+	now := m.now()
+	removed := 0
 	for roomID, room := range m.rooms {
-		if room.GetPlayerCount() == 0 && !room.GameStarted {
+		if room.isIdle(now, ttl) {
 			delete(m.rooms, roomID)
+			removed++
+		}
+	}
+	
+	// Forget the room creations that no longer count towards any limit
+	for playerID := range m.createdAt {
+		m.recentRooms(playerID, now)
+	}
+	
+	return removed
+}
+
+// RunSweeper calls CleanupIdleRooms every interval until done is closed
+func (m *Manager) RunSweeper(interval, ttl time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			m.CleanupIdleRooms(ttl)
 		}
 	}
 }
@@ -159,14 +495,42 @@ func (m *Manager) StartGame(roomID, playerID string) error {
 	}
 	
 	if !room.IsCreator(playerID) {
-		return fmt.Errorf("only room creator can start the game")
+		return ErrNotRoomCreator
 	}
 	
-	if !room.CanStart() {
-		return fmt.Errorf("cannot start game: need at least 2 players")
+	if err := room.StartGame(); err != nil {
+		return err
+	}
+	
+	m.statsMutex.Lock()
+	m.gamesStarted++
+	m.statsMutex.Unlock()
+	
+	return nil
+}
+
+// GetGameCounts returns how many games were started and ended since the
+// server started
+func (m *Manager) GetGameCounts() (started, ended int) {
+	m.statsMutex.Lock()
+	defer m.statsMutex.Unlock()
+	
+	return m.gamesStarted, m.gamesEnded
+}
+
+// GetGamesInProgress returns the number of rooms with a running game
+func (m *Manager) GetGamesInProgress() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	
+	count := 0
+	for _, room := range m.rooms {
+		if room.IsInProgress() {
+			count++
+		}
 	}
 	
-	return room.StartGame()
+	return count
 }
 
 // AddBot adds a bot to the specified room
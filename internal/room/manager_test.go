@@ -0,0 +1,204 @@
+package room
+
+import (
+	"testing"
+	"time"
+	"carcassonne-ws/internal/game"
+)
+
+// fakeClock is a clock tests move forward by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// testOptions are room options without turn timer, so no timer goroutine
+// plays turns behind the test's back
+func testOptions() Options {
+	options := DefaultOptions()
+	options.TurnTimeout = 0
+	return options
+}
+
+// seatPlayers adds ready human players to a room
+func seatPlayers(t *testing.T, r *Room, playerIDs ...string) {
+	t.Helper()
+
+	for _, id := range playerIDs {
+		if err := r.AddPlayer(&game.Player{ID: id, Name: id}); err != nil {
+			t.Fatalf("AddPlayer(%q): %v", id, err)
+		}
+		if err := r.SetReady(id, true); err != nil {
+			t.Fatalf("SetReady(%q): %v", id, err)
+		}
+	}
+}
+
+// startRoom starts the room's game
+func startRoom(t *testing.T, r *Room) {
+	t.Helper()
+
+	if err := r.StartGame(); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+}
+
+// passTurn ends the current turn as is, whatever was played of it
+func passTurn(r *Room) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.nextTurn()
+}
+
+// endGame ends the room's running game by emptying the deck before the
+// next turn is drawn
+func endGame(t *testing.T, r *Room) {
+	t.Helper()
+
+	r.mutex.Lock()
+	r.Board.TileDeck = nil
+	r.mutex.Unlock()
+	passTurn(r)
+	if !r.HasEnded() {
+		t.Fatalf("game did not end")
+	}
+}
+
+func TestCleanupIdleRooms(t *testing.T) {
+	const ttl = 10 * time.Minute
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewManager()
+	m.SetClock(clock.Now)
+	m.SetRoomLimits(DefaultMaxRooms, 10)
+
+	create := func(name string, options Options) *Room {
+		r, err := m.CreateRoom(name, "creator", 4, options)
+		if err != nil {
+			t.Fatalf("CreateRoom(%q): %v", name, err)
+		}
+		return r
+	}
+
+	emptyLobby := create("empty lobby", testOptions())
+
+	seatedLobby := create("seated lobby", testOptions())
+	seatPlayers(t, seatedLobby, "alice")
+
+	running := create("running", testOptions())
+	seatPlayers(t, running, "alice", "bob")
+	startRoom(t, running)
+
+	ended := create("ended", testOptions())
+	seatPlayers(t, ended, "alice", "bob")
+	startRoom(t, ended)
+	endGame(t, ended)
+
+	botOptions := testOptions()
+	botOptions.AllowAllBots = true
+	botGame := create("bot game", botOptions)
+	watchedBotGame := create("watched bot game", botOptions)
+	for _, r := range []*Room{botGame, watchedBotGame} {
+		for _, name := range []string{"Bot 1", "Bot 2"} {
+			if err := r.AddBot(name, "easy", "creator"); err != nil {
+				t.Fatalf("AddBot: %v", err)
+			}
+		}
+		startRoom(t, r)
+	}
+	watchedBotGame.AddSpectator("carol")
+
+	steps := []struct {
+		advance time.Duration
+		removed []*Room
+	}{
+		// Nothing is idle long enough yet; the ended game and the
+		// unwatched bot game start counting as idle
+		{advance: ttl - time.Minute},
+		// The empty lobby reached the TTL since its creation
+		{advance: time.Minute, removed: []*Room{emptyLobby}},
+		{advance: ttl - 2*time.Minute},
+		// The ended and the unwatched bot game were idle for the TTL
+		{advance: time.Minute, removed: []*Room{ended, botGame}},
+		{advance: 24 * time.Hour},
+	}
+
+	gone := make(map[*Room]bool)
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		if n := m.CleanupIdleRooms(ttl); n != len(step.removed) {
+			t.Fatalf("step %d: CleanupIdleRooms removed %d rooms, want %d", i, n, len(step.removed))
+		}
+		for _, r := range step.removed {
+			gone[r] = true
+		}
+
+		for _, r := range []*Room{emptyLobby, seatedLobby, running, ended, botGame, watchedBotGame} {
+			_, err := m.GetRoom(r.ID)
+			if exists := err == nil; exists == gone[r] {
+				t.Fatalf("step %d: room %q exists = %v, want %v", i, r.Name, exists, !gone[r])
+			}
+		}
+	}
+}
+
+func TestLeaveRoomRemovesEmptyEndedGame(t *testing.T) {
+	m := NewManager()
+	r, err := m.CreateRoom("ended", "alice", 4, testOptions())
+	if err != nil {
+		t.Fatalf("CreateRoom: %v", err)
+	}
+	seatPlayers(t, r, "alice", "bob")
+	startRoom(t, r)
+	endGame(t, r)
+
+	for _, id := range []string{"alice", "bob"} {
+		if err := m.LeaveRoom(r.ID, id); err != nil {
+			t.Fatalf("LeaveRoom(%q): %v", id, err)
+		}
+	}
+
+	if _, err := m.GetRoom(r.ID); err != ErrRoomNotFound {
+		t.Fatalf("GetRoom after everybody left = %v, want ErrRoomNotFound", err)
+	}
+}
+
+func TestRoomLimits(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewManager()
+	m.SetClock(clock.Now)
+	m.SetRoomLimits(3, 2)
+
+	steps := []struct {
+		advance time.Duration
+		creator string
+		want    error
+	}{
+		{creator: "alice"},
+		{advance: 30 * time.Second, creator: "alice"},
+		// Two rooms within the last minute
+		{creator: "alice", want: ErrRoomLimit},
+		{creator: "bob"},
+		// The first room is a minute old, but the server is full
+		{advance: 30 * time.Second, creator: "alice", want: ErrRoomLimit},
+	}
+
+	for i, step := range steps {
+		clock.now = clock.now.Add(step.advance)
+		if _, err := m.CreateRoom("room", step.creator, 4, testOptions()); err != step.want {
+			t.Fatalf("step %d: CreateRoom by %s = %v, want %v", i, step.creator, err, step.want)
+		}
+	}
+
+	// Removing the empty rooms makes space again
+	clock.now = clock.now.Add(time.Hour)
+	if n := m.CleanupIdleRooms(time.Minute); n != 3 {
+		t.Fatalf("CleanupIdleRooms removed %d rooms, want 3", n)
+	}
+	if _, err := m.CreateRoom("room", "alice", 4, testOptions()); err != nil {
+		t.Fatalf("CreateRoom after a room was removed: %v", err)
+	}
+}
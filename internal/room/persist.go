@@ -0,0 +1,111 @@
+package room
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"carcassonne-ws/internal/game"
+	"carcassonne-ws/internal/player"
+)
+
+// roomSnapshot is the serialized form of a Room. Players and bots are stored
+// on the board; the room only keeps which of them are bots.
+type roomSnapshot struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	MaxPlayers  int               `json:"maxPlayers"`
+	CreatedBy   string            `json:"createdBy"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Options     Options           `json:"options"`
+	GameStarted bool              `json:"gameStarted"`
+	GameEnded   bool              `json:"gameEnded"`
+	Bots        map[string]string `json:"bots"` // bot ID to difficulty
+	Banned      []string          `json:"banned"`
+	TurnNumber  int               `json:"turnNumber"`
+	Board       json.RawMessage   `json:"board"`
+}
+
+// Snapshot serializes the room and its game, so it can be restored with
+// LoadRoom
+func (r *Room) Snapshot() ([]byte, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	board, err := r.Board.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	
+	snapshot := roomSnapshot{
+		ID:          r.ID,
+		Name:        r.Name,
+		MaxPlayers:  r.MaxPlayers,
+		CreatedBy:   r.CreatedBy,
+		CreatedAt:   r.CreatedAt,
+		Options:     r.Options,
+		GameStarted: r.GameStarted,
+		GameEnded:   r.GameEnded,
+		Bots:        make(map[string]string, len(r.Bots)),
+		Banned:      make([]string, 0, len(r.banned)),
+		TurnNumber:  r.turnNumber,
+		Board:       board,
+	}
+	for botID, bot := range r.Bots {
+		snapshot.Bots[botID] = bot.Difficulty
+	}
+	for playerID := range r.banned {
+		snapshot.Banned = append(snapshot.Banned, playerID)
+	}
+	
+	return json.Marshal(snapshot)
+}
+
+// LoadRoom restores a room from a Snapshot. Human players of a running game
+// count as disconnected until they reconnect, and the turn clocks restart.
+func LoadRoom(data []byte) (*Room, error) {
+	var snapshot roomSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, err
+	}
+	
+	board, err := game.LoadBoard(snapshot.Board)
+	if err != nil {
+		return nil, fmt.Errorf("loading board of room %s: %w", snapshot.ID, err)
+	}
+	
+	r := NewRoom(snapshot.Name, snapshot.CreatedBy, snapshot.MaxPlayers, snapshot.Options)
+	r.ID = snapshot.ID
+	r.CreatedAt = snapshot.CreatedAt
+	r.GameStarted = snapshot.GameStarted
+	r.GameEnded = snapshot.GameEnded
+	r.turnNumber = snapshot.TurnNumber
+	r.Board = board
+	
+	for _, playerID := range snapshot.Banned {
+		r.banned[playerID] = true
+	}
+	
+	for _, p := range board.Players {
+		difficulty, isBot := snapshot.Bots[p.ID]
+		if !isBot {
+			r.Players[p.ID] = p
+			if r.GameStarted && !r.GameEnded {
+				r.disconnectedAt[p.ID] = time.Now()
+			}
+			continue
+		}
+		
+		bot := player.NewBot(p.ID, p.Name, p.Color)
+		bot.Player = p
+		bot.SetDifficulty(difficulty)
+		r.Bots[p.ID] = bot
+	}
+	
+	if r.GameStarted && !r.GameEnded {
+		r.beginTurn()
+		r.startTurnClock()
+		r.startTurnTimer()
+	}
+	
+	return r, nil
+}
@@ -0,0 +1,78 @@
+package room
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"carcassonne-ws/internal/game"
+)
+
+// playBotTurns plays the given number of bot turns of a bots-only room
+func playBotTurns(t *testing.T, r *Room, turns int) {
+	t.Helper()
+
+	for i := 0; i < turns && !r.HasEnded(); i++ {
+		if _, err := r.ProcessBotTurn(); err != nil {
+			t.Fatalf("ProcessBotTurn: %v", err)
+		}
+	}
+}
+
+func TestSeededBotRoomsReplay(t *testing.T) {
+	options := testOptions()
+	options.AllowAllBots = true
+	options.Seed = 42
+
+	// Bot IDs are random, the moves themselves must repeat
+	histories := make([][]game.MoveRecord, 2)
+	for i := range histories {
+		r := newBotRoom(t, options)
+		startRoom(t, r)
+		playBotTurns(t, r, 20)
+		for _, move := range r.GetMoveHistory() {
+			move.PlayerID, move.ScoreChange = "", nil
+			histories[i] = append(histories[i], move)
+		}
+	}
+	if len(histories[0]) == 0 || !reflect.DeepEqual(histories[0], histories[1]) {
+		t.Fatalf("bots of rooms with the same seed played different games")
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	options := testOptions()
+	options.AllowAllBots = true
+	options.Seed = 42
+	r := newBotRoom(t, options)
+	startRoom(t, r)
+	playBotTurns(t, r, 10)
+
+	data, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	loaded, err := LoadRoom(data)
+	if err != nil {
+		t.Fatalf("LoadRoom: %v", err)
+	}
+
+	if loaded.ID != r.ID || loaded.Name != r.Name || !reflect.DeepEqual(loaded.Options, r.Options) {
+		t.Fatalf("loaded room %q %q, want %q %q with the same options", loaded.ID, loaded.Name, r.ID, r.Name)
+	}
+	want, _ := json.Marshal(r.GetGameState())
+	got, _ := json.Marshal(loaded.GetGameState())
+	if string(got) != string(want) {
+		t.Fatalf("loaded game state differs:\n got %s\nwant %s", got, want)
+	}
+	for id, bot := range r.Bots {
+		if restored := loaded.Bots[id]; restored == nil || restored.Difficulty != bot.Difficulty {
+			t.Fatalf("bot %q was not restored as %s", id, bot.Difficulty)
+		}
+	}
+	if errs := loaded.Board.Validate(); len(errs) > 0 {
+		t.Fatalf("loaded board is invalid: %v", errs)
+	}
+
+	// The restored bots carry on playing
+	playBotTurns(t, loaded, 5)
+}
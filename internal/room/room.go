@@ -1,9 +1,14 @@
 package room
 
 import (
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 	"carcassonne-ws/internal/game"
 	"carcassonne-ws/internal/player"
 	"github.com/google/uuid"
@@ -18,16 +23,247 @@ type Room struct {
 	CreatedAt   time.Time
 	Players     map[string]*game.Player
 	Bots        map[string]*player.Bot
+	Spectators  map[string]bool // IDs of players watching without a seat
 	Board       *game.Board
 	GameStarted bool
 	GameEnded   bool
+	Options     Options
+	banned      map[string]bool
 	mutex       sync.RWMutex
+	
+	// ready holds the human players who are ready for the game to start
+	ready map[string]bool
+	
+	// disconnectedAt holds when each player of a running game lost their
+	// connection, until they reconnect
+	disconnectedAt map[string]time.Time
+	
+	// rng picks the tiles placed for players who ran out of time, seeded
+	// from Options.Seed so seeded rooms replay
+	rng *rand.Rand
+	
+	// Game clock state
+	turnNumber    int
+	turnStartedAt time.Time
+	clockTimer    *time.Timer
+	onFlag        func(playerID string)
+	turnTimer     *time.Timer
+	onTurnTimeout func(playerID string)
+	
+	// phase is the input the room waits for from the current player
+	phase TurnPhase
+	
+	// Turn scoring: the current player's score when their turn began and
+	// the summary of the last finished turn
+	turnStartScore int
+	lastTurn       TurnSummary
+	
+	// endAnnounced is set once the end of the game was reported to clients
+	endAnnounced bool
+	
+	// idleSince is when the sweeper first found the started room idle, see
+	// isIdle. Zero while the room is in use.
+	idleSince time.Time
+	
+	// deltaVersion is the board version the last game state delta was
+	// taken at, the next delta holds the changes since
+	deltaVersion int
+	
+	// Bot failure tracking, bots stop playing once botsErrored is set
+	botFailures int
+	botsErrored bool
+	
+	// onGameEnd is called once, with the room lock held, when the game ends
+	onGameEnd func(result GameResult)
+}
+
+// TurnSummary describes a finished turn
+type TurnSummary struct {
+	PlayerID    string
+	ScoreChange int
+	NextPlayer  string
+}
+
+// TurnPhase is the step of a turn the current player is in. A turn moves
+// from tile placement to meeple placement once the tile is placed, and on
+// to complete once a figure is placed; undoing the figure goes back to
+// meeple placement. Ending the turn starts the next one in tile placement.
+type TurnPhase string
+
+const (
+	PhaseTilePlacement   TurnPhase = "TILE_PLACEMENT"   // waiting for the tile
+	PhaseMeeplePlacement TurnPhase = "MEEPLE_PLACEMENT" // waiting for a figure or the end of the turn
+	PhaseComplete        TurnPhase = "COMPLETE"         // figure placed, waiting for the end of the turn
+)
+
+// GameResult summarizes a finished game
+type GameResult struct {
+	RoomID     string
+	Options    Options
+	Players    []game.Player
+	FinishedAt time.Time
+}
+
+// Options holds the configurable rules of a room
+type Options struct {
+	// StrictPlacement enforces edge matching when placing tiles
+	StrictPlacement bool
+	
+	// PauseWhenEmpty stops bot turns while no client is connected to the
+	// room. When false, the game keeps advancing without broadcasting.
+	PauseWhenEmpty bool
+	
+	// CommandMaxAge rejects game commands whose client timestamp is older
+	// than this window. Zero disables the check. Timestamps ahead of the
+	// server clock are accepted, so the window only needs to cover lag.
+	CommandMaxAge time.Duration
+	
+	// TimeBank is each player's total thinking time for the whole game,
+	// chess-clock style. A player whose bank runs out has their turns
+	// played automatically. Zero disables the clock.
+	TimeBank time.Duration
+	
+	// TurnTimeout is how long a player may take for a single turn before a
+	// random valid tile is placed for them. Zero disables the timer.
+	TurnTimeout time.Duration
+	
+	// AllowAllBots lets a game start without any human player, e.g. for demos
+	AllowAllBots bool
+	
+	// Builders enables the Traders & Builders builder figure, which grants
+	// an extra turn when its owner extends the road or city it stands on
+	Builders bool
+	
+	// RandomizeSeating shuffles the turn order when the game starts, so the
+	// creator doesn't always play first. When false, players take turns in
+	// the order they joined.
+	RandomizeSeating bool
+	
+	// HidePlacements sends the valid placements of the current tile only to
+	// the current player instead of the whole room
+	HidePlacements bool
+	
+	// MeeplesPerPlayer is the number of meeples each player starts with
+	MeeplesPerPlayer int
+	
+	// Seed fixes the deck order. Zero shuffles randomly.
+	Seed int64
+	
+	// DailyChallenge is the date tag of a daily challenge room, whose final
+	// scores are recorded to that day's leaderboard
+	DailyChallenge string
+	
+	// Password makes the room private: it is left out of the room list and
+	// joining or watching it requires the password. Never sent to clients.
+	Password string
 }
 
+// DefaultOptions returns the standard room options
+func DefaultOptions() Options {
+	return Options{
+		StrictPlacement: true,
+		TurnTimeout:     DefaultTurnTimeout,
+		MeeplesPerPlayer: game.DefaultMeeplesPerPlayer,
+	}
+}
+
+// DefaultTurnTimeout is the per-turn time limit of new rooms
+const DefaultTurnTimeout = 90 * time.Second
+
+// ReconnectGracePeriod is how long a player dropped from a running game
+// keeps their seat before their turns are played for them
+const ReconnectGracePeriod = 60 * time.Second
+
+// maxBotFailures is the number of consecutive failed bot turns after which a
+// room's bot processing is paused
+const maxBotFailures = 5
+
+var (
+	// ErrBotsErrored is returned once a room's bots were paused after repeated failures
+	ErrBotsErrored = errors.New("bot processing paused after repeated failures")
+	
+	// ErrPlayerBanned is returned when a banned player tries to join a room
+	ErrPlayerBanned = errors.New("player is banned from this room")
+	
+	// ErrNoHumanPlayers is returned when starting a bots-only game that is not allowed
+	ErrNoHumanPlayers = errors.New("need at least one human player to start")
+	
+	// ErrGameStarted is returned when joining or changing a room whose game already started
+	ErrGameStarted = errors.New("game already started")
+	
+	// ErrRoomFull is returned when the room has no free seat
+	ErrRoomFull = errors.New("room is full")
+	
+	// ErrAlreadyInRoom is returned when the player already has a seat in the room
+	ErrAlreadyInRoom = errors.New("player already in room")
+	
+	// ErrRoomLimit is returned when creating a room while the server holds
+	// as many rooms as it allows, or the player created too many recently
+	ErrRoomLimit = errors.New("room limit reached")
+	
+	// ErrRoomNotFound is returned for unknown room IDs
+	ErrRoomNotFound = errors.New("room not found")
+	
+	// ErrNotRoomCreator is returned when another player than the creator starts the game
+	ErrNotRoomCreator = errors.New("only room creator can start the game")
+	
+	// ErrNotEnoughPlayers is returned when starting a game with fewer than 2 players
+	ErrNotEnoughPlayers = errors.New("need at least 2 players to start")
+	
+	// ErrPlayersNotReady is returned when starting a game before every human player is ready
+	ErrPlayersNotReady = errors.New("not all players are ready")
+	
+	// ErrNoFreeColor is returned when every player color is taken
+	ErrNoFreeColor = errors.New("no available colors")
+	
+	// ErrInvalidDifficulty is returned when adding a bot with an unknown
+	// difficulty, see player.ValidDifficulties
+	ErrInvalidDifficulty = errors.New("invalid difficulty")
+	
+	// ErrIncorrectPassword is returned when joining or watching a private
+	// room without its password
+	ErrIncorrectPassword = errors.New("incorrect password")
+	
+	// ErrTilePlaced is returned for a second tile placement in the same turn
+	ErrTilePlaced = errors.New("tile already placed this turn")
+	
+	// ErrInvalidName is returned for an empty name or one longer than
+	// MaxNameLength
+	ErrInvalidName = errors.New("invalid name")
+	
+	// ErrNameTaken is returned when renaming to a name another player or bot
+	// of the room goes by
+	ErrNameTaken = errors.New("name already taken")
+)
+
+// MaxNameLength is the most characters a player may rename themselves to
+const MaxNameLength = 32
+
+// playerColors are the colors handed out to players who don't pick a free one
+var playerColors = []string{"red", "blue", "green", "yellow", "black", "pink"}
+
 // NewRoom creates a new game room
-func NewRoom(name, createdBy string, maxPlayers int) *Room {
-	if maxPlayers < 2 || maxPlayers > 5 {
-		maxPlayers = 5
+func NewRoom(name, createdBy string, maxPlayers int, options Options) *Room {
+	if maxPlayers < 2 || maxPlayers > game.MaxPlayers {
+		maxPlayers = game.MaxPlayers
+	}
+	
+	if options.MeeplesPerPlayer <= 0 {
+		options.MeeplesPerPlayer = game.DefaultMeeplesPerPlayer
+	}
+	
+	board := game.NewBoard()
+	if options.Seed != 0 {
+		board = game.NewBoardWithSeed(options.Seed)
+	}
+	board.StrictPlacement = options.StrictPlacement
+	board.Builders = options.Builders
+	board.RandomizeSeating = options.RandomizeSeating
+	board.MeeplesPerPlayer = options.MeeplesPerPlayer
+	
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	if options.Seed != 0 {
+		rng = rand.New(rand.NewSource(options.Seed))
 	}
 	
 	return &Room{
@@ -38,7 +274,13 @@ func NewRoom(name, createdBy string, maxPlayers int) *Room {
 		CreatedAt:  time.Now(),
 		Players:    make(map[string]*game.Player),
 		Bots:       make(map[string]*player.Bot),
-		Board:      game.NewBoard(),
+		Spectators: make(map[string]bool),
+		Board:      board,
+		Options:    options,
+		banned:     make(map[string]bool),
+		disconnectedAt: make(map[string]time.Time),
+		ready:      make(map[string]bool),
+		rng:        rng,
 	}
 }
 
@@ -47,17 +289,28 @@ func (r *Room) AddPlayer(player *game.Player) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
+	if r.banned[player.ID] {
+		return ErrPlayerBanned
+	}
+	
 	if r.GameStarted {
-		return fmt.Errorf("game already started")
+		return ErrGameStarted
 	}
 	
 	if len(r.Players)+len(r.Bots) >= r.MaxPlayers {
-		return fmt.Errorf("room is full")
+		return ErrRoomFull
 	}
 	
 	if _, exists := r.Players[player.ID]; exists {
-		return fmt.Errorf("player already in room")
+		return ErrAlreadyInRoom
+	}
+	
+	color, err := r.assignColor(player.Color)
+	if err != nil {
+		return err
 	}
+	player.Color = color
+	player.Name = r.uniqueName(player.Name)
 	
 	r.Players[player.ID] = player
 	r.Board.AddPlayer(player)
@@ -65,250 +318,1209 @@ func (r *Room) AddPlayer(player *game.Player) error {
 	return nil
 }
 
-// RemovePlayer removes a player from the room
-func (r *Room) RemovePlayer(playerID string) error {
+// CheckPassword returns ErrIncorrectPassword unless the room is public or
+// the password matches
+func (r *Room) CheckPassword(password string) error {
+	if r.Options.Password == "" {
+		return nil
+	}
+	if subtle.ConstantTimeCompare([]byte(password), []byte(r.Options.Password)) != 1 {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+// AssignColor returns the color a player joining the room gets: the
+// requested one if nobody in the room has it, else the first free color
+func (r *Room) AssignColor(requested string) (string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.assignColor(requested)
+}
+
+// assignColor picks a color no player or bot in the room has.
+// Must be called with the room lock held.
+func (r *Room) assignColor(requested string) (string, error) {
+	usedColors := make(map[string]bool)
+	for _, p := range r.Players {
+		usedColors[p.Color] = true
+	}
+	for _, b := range r.Bots {
+		usedColors[b.Player.Color] = true
+	}
+	
+	if requested != "" && !usedColors[requested] {
+		return requested, nil
+	}
+	
+	for _, color := range playerColors {
+		if !usedColors[color] {
+			return color, nil
+		}
+	}
+	
+	return "", ErrNoFreeColor
+}
+
+// uniqueName returns the name, with a " (2)", " (3)"... suffix if a player
+// or bot of the room already goes by it. Names only need to be unique
+// within a room.
+// Must be called with the room lock held.
+func (r *Room) uniqueName(name string) string {
+	if name == "" {
+		return name
+	}
+	
+	taken := make(map[string]bool)
+	for _, p := range r.Players {
+		taken[p.Name] = true
+	}
+	for _, b := range r.Bots {
+		taken[b.Player.Name] = true
+	}
+	
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s (%d)", name, n)
+	}
+	return unique
+}
+
+// SetPlayerName renames a player of the room before the game starts. The
+// name is trimmed and must not be used by anybody else in the room.
+func (r *Room) SetPlayerName(playerID, name string) (string, error) {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
 	if r.GameStarted {
-		return fmt.Errorf("cannot leave during game")
+		return "", ErrGameStarted
 	}
 	
-	if _, exists := r.Players[playerID]; !exists {
-		return fmt.Errorf("player not in room")
+	player, exists := r.Players[playerID]
+	if !exists {
+		return "", fmt.Errorf("player not in room")
 	}
 	
-	delete(r.Players, playerID)
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > MaxNameLength {
+		return "", ErrInvalidName
+	}
 	
-	// Remove from board players
-	for i, p := range r.Board.Players {
-		if p.ID == playerID {
-			r.Board.Players = append(r.Board.Players[:i], r.Board.Players[i+1:]...)
-			break
+	for id, p := range r.Players {
+		if id != playerID && p.Name == name {
+			return "", ErrNameTaken
+		}
+	}
+	for _, b := range r.Bots {
+		if b.Player.Name == name {
+			return "", ErrNameTaken
 		}
 	}
 	
-	return nil
+	player.Name = name
+	return name, nil
 }
 
-// AddBot adds a bot to the room
-func (r *Room) AddBot(botName, difficulty, creatorID string) error {
+// RemovePlayer removes a player from the room
+func (r *Room) RemovePlayer(playerID string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
-	if r.GameStarted {
-		return fmt.Errorf("game already started")
+	if r.GameStarted && !r.GameEnded {
+		return fmt.Errorf("cannot leave during game")
 	}
 	
-	if r.CreatedBy != creatorID {
-		return fmt.Errorf("only room creator can add bots")
+	if _, exists := r.Players[playerID]; !exists {
+		return fmt.Errorf("player not in room")
 	}
 	
-	if len(r.Players)+len(r.Bots) >= r.MaxPlayers {
-		return fmt.Errorf("room is full")
-	}
+	delete(r.Players, playerID)
+	delete(r.ready, playerID)
+	r.Board.RemovePlayer(playerID)
 	
-	botID := uuid.New().String()
-	colors := []string{"red", "blue", "green", "yellow", "black"}
-	usedColors := make(map[string]bool)
+	return nil
+}
+
+// Forfeit takes a human player out of the running game. Their tiles stay on
+// the board and the remaining players play on, unless at most one human or
+// one player is left, which ends the game. It reports whether the turn
+// passed to another player.
+func (r *Room) Forfeit(playerID string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	
-	// Track used colors
-	for _, p := range r.Players {
-		usedColors[p.Color] = true
-	}
-	for _, b := range r.Bots {
-		usedColors[b.Player.Color] = true
+	if !r.GameStarted || r.GameEnded {
+		return false, fmt.Errorf("no game in progress")
 	}
 	
-	// Find available color
-	var botColor string
-	for _, color := range colors {
-		if !usedColors[color] {
-			botColor = color
-			break
-		}
+	if _, exists := r.Players[playerID]; !exists {
+		return false, fmt.Errorf("player not in room")
 	}
 	
-	if botColor == "" {
-		return fmt.Errorf("no available colors for bot")
+	wasCurrent := false
+	if currentPlayer := r.Board.GetCurrentPlayer(); currentPlayer != nil {
+		wasCurrent = currentPlayer.ID == playerID
+	}
+	if wasCurrent {
+		r.stopTurnTimer()
 	}
 	
-	bot := player.NewBot(botID, botName, botColor)
-	bot.SetDifficulty(difficulty)
+	if err := r.Board.RemovePlayer(playerID); err != nil {
+		return false, err
+	}
+	delete(r.Players, playerID)
+	delete(r.disconnectedAt, playerID)
 	
-	r.Bots[botID] = bot
-	r.Board.AddPlayer(bot.Player)
+	if !r.Board.GameEnded && (len(r.Players) <= 1 || len(r.Board.Players) <= 1) {
+		r.stopTurnTimer()
+		r.Board.EndGame()
+	}
+	if r.Board.GameEnded {
+		r.GameEnded = true
+		r.finishGame()
+		return wasCurrent, nil
+	}
 	
-	return nil
+	if wasCurrent {
+		r.beginTurn()
+		r.startTurnClock()
+		r.startTurnTimer()
+	}
+	return wasCurrent, nil
 }
 
-// StartGame starts the game in the room
-func (r *Room) StartGame() error {
+// SetReady marks a human player as ready for the game to start, or not
+func (r *Room) SetReady(playerID string, ready bool) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
 	if r.GameStarted {
-		return fmt.Errorf("game already started")
+		return ErrGameStarted
 	}
 	
-	totalPlayers := len(r.Players) + len(r.Bots)
-	if totalPlayers < 2 {
-		return fmt.Errorf("need at least 2 players to start")
+	if _, exists := r.Players[playerID]; !exists {
+		return fmt.Errorf("player not in room")
 	}
 	
-	err := r.Board.StartGame()
-	if err != nil {
-		return err
+	if ready {
+		r.ready[playerID] = true
+	} else {
+		delete(r.ready, playerID)
 	}
-	
-	r.GameStarted = true
 	return nil
 }
 
-// GetPlayers returns all players (human and bot) in the room
-func (r *Room) GetPlayers() []*game.Player {
+// GetReadiness returns whether each player of the room is ready to start.
+// Bots are always ready.
+func (r *Room) GetReadiness() map[string]bool {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	players := make([]*game.Player, 0, len(r.Players)+len(r.Bots))
-	
-	for _, player := range r.Players {
-		players = append(players, player)
+	readiness := make(map[string]bool, len(r.Players)+len(r.Bots))
+	for playerID := range r.Players {
+		readiness[playerID] = r.ready[playerID]
 	}
-	
-	for _, bot := range r.Bots {
-		players = append(players, bot.Player)
+	for botID := range r.Bots {
+		readiness[botID] = true
 	}
+	return readiness
+}
+
+// AddSpectator lets a player watch the room without a seat
+func (r *Room) AddSpectator(playerID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	
-	return players
+	r.Spectators[playerID] = true
 }
 
-// GetPlayerCount returns the total number of players in the room
-func (r *Room) GetPlayerCount() int {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+// RemoveSpectator stops a player from watching the room
+func (r *Room) RemoveSpectator(playerID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	
-	return len(r.Players) + len(r.Bots)
+	delete(r.Spectators, playerID)
 }
 
-// IsCreator checks if the given player ID is the room creator
-func (r *Room) IsCreator(playerID string) bool {
+// GetSpectatorCount returns the number of players watching the room
+func (r *Room) GetSpectatorCount() int {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	return r.CreatedBy == playerID
+	return len(r.Spectators)
 }
 
-// CanStart checks if the game can be started
-func (r *Room) CanStart() bool {
+// GetPlayer returns a copy of a human player of the room, or nil
+func (r *Room) GetPlayer(playerID string) *game.Player {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	return !r.GameStarted && len(r.Players)+len(r.Bots) >= 2
+	return copyPlayer(r.Players[playerID])
 }
 
-// GetBot returns a bot by ID
-func (r *Room) GetBot(botID string) *player.Bot {
+// copyPlayer returns a copy of a player that can be read without the room
+// lock, or nil for nil
+func copyPlayer(p *game.Player) *game.Player {
+	if p == nil {
+		return nil
+	}
+	copied := *p
+	return &copied
+}
+
+// GetSeatedPlayer returns a copy of a human player seated in the running
+// game, or nil
+func (r *Room) GetSeatedPlayer(playerID string) *game.Player {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	return r.Bots[botID]
+	if !r.GameStarted || r.GameEnded {
+		return nil
+	}
+	return copyPlayer(r.Players[playerID])
 }
 
-// GetCurrentPlayer returns the current player
-func (r *Room) GetCurrentPlayer() *game.Player {
-	r.mutex.RLock()
-	defer r.mutex.RUnlock()
+// MarkDisconnected records that a seated player lost their connection
+func (r *Room) MarkDisconnected(playerID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 	
-	return r.Board.GetCurrentPlayer()
+	if _, exists := r.Players[playerID]; exists {
+		r.disconnectedAt[playerID] = time.Now()
+	}
 }
 
-// IsCurrentPlayerBot checks if the current player is a bot
-func (r *Room) IsCurrentPlayerBot() bool {
+// MarkReconnected records that a seated player is connected again
+func (r *Room) MarkReconnected(playerID string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	delete(r.disconnectedAt, playerID)
+}
+
+// GetAbandonedPlayers returns the seated players of the running game who
+// have been disconnected for longer than the grace period
+func (r *Room) GetAbandonedPlayers() []string {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	currentPlayer := r.Board.GetCurrentPlayer()
-	if currentPlayer == nil {
-		return false
+	abandoned := make([]string, 0)
+	if !r.GameStarted || r.GameEnded {
+		return abandoned
 	}
 	
-	_, isBot := r.Bots[currentPlayer.ID]
-	return isBot
+	for playerID, disconnectedAt := range r.disconnectedAt {
+		if time.Since(disconnectedAt) >= ReconnectGracePeriod {
+			abandoned = append(abandoned, playerID)
+		}
+	}
+	return abandoned
 }
 
-// ProcessBotTurn processes a bot's turn
-func (r *Room) ProcessBotTurn() (*player.BotMove, error) {
+// BanPlayer removes a player from the room and prevents them from rejoining
+func (r *Room) BanPlayer(playerID, creatorID string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
-	currentPlayer := r.Board.GetCurrentPlayer()
-	if currentPlayer == nil {
-		return nil, fmt.Errorf("no current player")
+	if r.CreatedBy != creatorID {
+		return fmt.Errorf("only room creator can ban players")
 	}
 	
-	bot, exists := r.Bots[currentPlayer.ID]
-	if !exists {
-		return nil, fmt.Errorf("current player is not a bot")
+	if playerID == creatorID {
+		return fmt.Errorf("cannot ban yourself")
 	}
 	
-	move, err := bot.MakeMove(r.Board)
-	if err != nil {
-		return nil, err
+	if r.GameStarted {
+		return fmt.Errorf("cannot ban during game")
 	}
 	
-	err = bot.ExecuteMove(r.Board, move)
-	if err != nil {
-		return nil, err
+	if _, exists := r.Players[playerID]; !exists {
+		return fmt.Errorf("player not in room")
 	}
 	
-	return &move, nil
+	delete(r.Players, playerID)
+	delete(r.ready, playerID)
+	r.Board.RemovePlayer(playerID)
+	
+	r.banned[playerID] = true
+	
+	return nil
 }
 
-// PlaceTile places a tile on the board
-func (r *Room) PlaceTile(playerID string, pos game.Position, rotation int) error {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	
-	currentPlayer := r.Board.GetCurrentPlayer()
-	if currentPlayer == nil || currentPlayer.ID != playerID {
-		return fmt.Errorf("not your turn")
-	}
+// IsBanned checks if the given player ID is banned from the room
+func (r *Room) IsBanned(playerID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
 	
-	return r.Board.PlaceTile(pos, rotation)
+	return r.banned[playerID]
 }
 
-// PlaceMeeple places a meeple on the board
-func (r *Room) PlaceMeeple(playerID string, featureID int) error {
+// AddBot adds a bot to the room
+func (r *Room) AddBot(botName, difficulty, creatorID string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
-	currentPlayer := r.Board.GetCurrentPlayer()
+	if r.GameStarted {
+		return ErrGameStarted
+	}
+	
+	if r.CreatedBy != creatorID {
+		return fmt.Errorf("only room creator can add bots")
+	}
+	
+	if len(r.Players)+len(r.Bots) >= r.MaxPlayers {
+		return ErrRoomFull
+	}
+	
+	// An empty difficulty keeps the default
+	if difficulty == "" {
+		difficulty = "easy"
+	}
+	if !player.IsValidDifficulty(difficulty) {
+		return ErrInvalidDifficulty
+	}
+	
+	botID := uuid.New().String()
+	botColor, err := r.assignColor("")
+	if err != nil {
+		return err
+	}
+	
+	bot := player.NewBot(botID, r.uniqueName(botName), botColor)
+	bot.SetDifficulty(difficulty)
+	
+	// Bots of seeded rooms play reproducibly too
+	if r.Options.Seed != 0 {
+		bot.SetRand(rand.New(rand.NewSource(r.Options.Seed + int64(len(r.Bots)) + 1)))
+	}
+	
+	r.Bots[botID] = bot
+	r.Board.AddPlayer(bot.Player)
+	
+	return nil
+}
+
+// StartGame starts the game in the room
+func (r *Room) StartGame() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if r.GameStarted {
+		return ErrGameStarted
+	}
+	
+	totalPlayers := len(r.Players) + len(r.Bots)
+	if totalPlayers < 2 {
+		return ErrNotEnoughPlayers
+	}
+	
+	if len(r.Players) == 0 && !r.Options.AllowAllBots {
+		return ErrNoHumanPlayers
+	}
+	
+	for playerID := range r.Players {
+		if !r.ready[playerID] {
+			return ErrPlayersNotReady
+		}
+	}
+	
+	err := r.Board.StartGame()
+	if err != nil {
+		return err
+	}
+	
+	for _, p := range r.Board.Players {
+		p.TimeBank = r.Options.TimeBank
+	}
+	
+	r.GameStarted = true
+	r.beginTurn()
+	r.startTurnClock()
+	r.startTurnTimer()
+	return nil
+}
+
+// GetPlayers returns a copy of all players (human and bot) in the room
+func (r *Room) GetPlayers() []*game.Player {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	players := make([]*game.Player, 0, len(r.Players)+len(r.Bots))
+	
+	for _, player := range r.Players {
+		players = append(players, copyPlayer(player))
+	}
+	
+	for _, bot := range r.Bots {
+		players = append(players, copyPlayer(bot.Player))
+	}
+	
+	return players
+}
+
+// GetPlayerCount returns the total number of players in the room
+func (r *Room) GetPlayerCount() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return len(r.Players) + len(r.Bots)
+}
+
+// isIdle checks if the room was idle for at least ttl. A lobby is idle from
+// its creation while no human player is seated. A started room is idle once
+// its game ended or no human player or spectator is left, counted from the
+// first check that finds it so.
+func (r *Room) isIdle(now time.Time, ttl time.Duration) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if !r.GameStarted {
+		return len(r.Players) == 0 && now.Sub(r.CreatedAt) >= ttl
+	}
+	
+	if !r.GameEnded && len(r.Players)+len(r.Spectators) > 0 {
+		r.idleSince = time.Time{}
+		return false
+	}
+	if r.idleSince.IsZero() {
+		r.idleSince = now
+	}
+	return now.Sub(r.idleSince) >= ttl
+}
+
+// IsCreator checks if the given player ID is the room creator
+func (r *Room) IsCreator(playerID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.CreatedBy == playerID
+}
+
+// CanStart checks if the game can be started
+func (r *Room) CanStart() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return !r.GameStarted && len(r.Players)+len(r.Bots) >= 2
+}
+
+// IsStaleCommand checks if a command sent at the given time is too old to apply
+func (r *Room) IsStaleCommand(sentAt time.Time) bool {
+	if r.Options.CommandMaxAge <= 0 {
+		return false
+	}
+	
+	return time.Since(sentAt) > r.Options.CommandMaxAge
+}
+
+// HasStarted reports whether the game was started, ended or not
+func (r *Room) HasStarted() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.GameStarted
+}
+
+// HasEnded reports whether the game ended
+func (r *Room) HasEnded() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.GameEnded
+}
+
+// IsInProgress checks if the room's game started and hasn't ended yet
+func (r *Room) IsInProgress() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.GameStarted && !r.GameEnded
+}
+
+// AtTurnBoundary checks if the game is between turns, i.e. the current
+// player hasn't placed their tile yet, or if no game is running
+func (r *Room) AtTurnBoundary() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return !r.GameStarted || r.GameEnded || r.Board.LastPlacedTile == nil
+}
+
+// GetBot returns a copy of a bot by ID, or nil. The copy shares the bot's
+// source of random choices, so it must not be used to make moves.
+func (r *Room) GetBot(botID string) *player.Bot {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	bot, exists := r.Bots[botID]
+	if !exists {
+		return nil
+	}
+	copied := *bot
+	copied.Player = copyPlayer(bot.Player)
+	return &copied
+}
+
+// GetCurrentPlayer returns a copy of the current player
+func (r *Room) GetCurrentPlayer() *game.Player {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return copyPlayer(r.Board.GetCurrentPlayer())
+}
+
+// IsCurrentPlayerBot checks if the current player is a bot
+func (r *Room) IsCurrentPlayerBot() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return false
+	}
+	
+	_, isBot := r.Bots[currentPlayer.ID]
+	return isBot
+}
+
+// BotToMove returns the difficulty of the bot whose turn it is and the
+// number of the turn. ok is false when it's no bot's turn.
+func (r *Room) BotToMove() (difficulty string, turn int, ok bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	if !r.GameStarted || r.GameEnded {
+		return "", 0, false
+	}
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return "", 0, false
+	}
+	
+	bot, isBot := r.Bots[currentPlayer.ID]
+	if !isBot {
+		return "", 0, false
+	}
+	return bot.Difficulty, r.turnNumber, true
+}
+
+// ProcessBotTurn plays the current bot's move and ends its turn
+func (r *Room) ProcessBotTurn() (*player.BotMove, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if r.botsErrored {
+		return nil, ErrBotsErrored
+	}
+	
+	move, err := r.playBotTurn()
+	if err != nil {
+		// A bot that keeps failing would be retried forever, give up on it
+		r.botFailures++
+		if r.botFailures >= maxBotFailures {
+			r.botsErrored = true
+		}
+		return nil, err
+	}
+	
+	r.botFailures = 0
+	r.nextTurn()
+	return move, nil
+}
+
+// BotsErrored checks if bot processing was paused after repeated failures
+func (r *Room) BotsErrored() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.botsErrored
+}
+
+// playBotTurn lets the current bot make its move.
+// Must be called with the room lock held.
+func (r *Room) playBotTurn() (*player.BotMove, error) {
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return nil, fmt.Errorf("no current player")
+	}
+	
+	bot, exists := r.Bots[currentPlayer.ID]
+	if !exists {
+		return nil, fmt.Errorf("current player is not a bot")
+	}
+	
+	move, err := bot.MakeMove(r.Board)
+	if errors.Is(err, player.ErrNoLegalMove) {
+		// Nothing fits, the bot passes and the tile goes back under the deck
+		r.Board.DiscardCurrentTile()
+		return &move, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	err = bot.ExecuteMove(r.Board, move)
+	if err != nil && r.Board.LastPlacedTile == nil {
+		return nil, err
+	}
+	if err != nil {
+		// The tile is down but the figure was refused. The turn can't be
+		// replayed, so it ends without the figure.
+		move.MeeplePlacement = nil
+	}
+	
+	r.phase = PhaseMeeplePlacement
+	if r.Board.MeeplePlaced() {
+		r.phase = PhaseComplete
+	}
+	return &move, nil
+}
+
+// PlaceTile places a tile on the board
+func (r *Room) PlaceTile(playerID string, pos game.Position, rotation int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return fmt.Errorf("not your turn")
+	}
+	
+	if err := r.checkPhase(PhaseTilePlacement); err != nil {
+		return err
+	}
+	
+	if err := r.Board.PlaceTile(pos, rotation); err != nil {
+		return err
+	}
+	r.phase = PhaseMeeplePlacement
+	return nil
+}
+
+// PlaceMeeple places a meeple on the board
+func (r *Room) PlaceMeeple(playerID string, featureID int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return fmt.Errorf("not your turn")
+	}
+	
+	if err := r.checkPhase(PhaseMeeplePlacement); err != nil {
+		return err
+	}
+	
+	if err := r.Board.PlaceMeeple(playerID, featureID); err != nil {
+		return err
+	}
+	r.phase = PhaseComplete
+	return nil
+}
+
+// UndoMeeple takes back the figure the player placed this turn
+func (r *Room) UndoMeeple(playerID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return fmt.Errorf("not your turn")
+	}
+	
+	if err := r.checkPhase(PhaseComplete); err != nil {
+		return err
+	}
+	
+	if err := r.Board.UndoMeeple(playerID); err != nil {
+		return err
+	}
+	r.phase = PhaseMeeplePlacement
+	return nil
+}
+
+// GetMeepleOptions returns the meeple options for the tile the player placed this turn
+func (r *Room) GetMeepleOptions(playerID string) ([]game.MeepleOption, game.Position, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return nil, game.Position{}, fmt.Errorf("not your turn")
+	}
+	
+	options, err := r.Board.GetMeepleOptions()
+	if err != nil {
+		return nil, game.Position{}, err
+	}
+	
+	return options, r.Board.LastPlacedTile.Position, nil
+}
+
+// GetClaimableFeatures returns the features a meeple could go on on the tile
+// the player placed this turn, and the tile's position
+func (r *Room) GetClaimableFeatures(playerID string) ([]int, game.Position, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return nil, game.Position{}, fmt.Errorf("not your turn")
+	}
+	
+	lastTile := r.Board.LastPlacedTile
+	if lastTile == nil {
+		return nil, game.Position{}, game.ErrNoTilePlaced
+	}
+	
+	return r.Board.GetClaimableFeatures(lastTile.Position), lastTile.Position, nil
+}
+
+// CanPlaceMeeple checks if the player has any figure left to place
+func (r *Room) CanPlaceMeeple(playerID string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.CanPlaceMeeple(playerID)
+}
+
+// PlaceBuilder places the player's builder figure on the board
+func (r *Room) PlaceBuilder(playerID string, featureID int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return fmt.Errorf("not your turn")
+	}
+	
+	if err := r.checkPhase(PhaseMeeplePlacement); err != nil {
+		return err
+	}
+	
+	if err := r.Board.PlaceBuilder(playerID, featureID); err != nil {
+		return err
+	}
+	r.phase = PhaseComplete
+	return nil
+}
+
+// TakeScoringEvents returns the features scored during the current turn,
+// each only once
+func (r *Room) TakeScoringEvents() []game.ScoringEvent {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	events := r.Board.ScoringEvents
+	r.Board.ScoringEvents = nil
+	return events
+}
+
+// TakeDrawnTile returns the tile drawn for the current turn along with the
+// number of tiles left in the deck. The tile is only returned once, and nil
+// if no tile was drawn since.
+func (r *Room) TakeDrawnTile() (*game.Tile, int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	drawn := r.Board.DrawnTile
+	r.Board.DrawnTile = nil
+	return drawn, len(r.Board.TileDeck)
+}
+
+// TakeDiscardedTiles returns the tiles discarded while drawing the current
+// tile, each only once
+func (r *Room) TakeDiscardedTiles() []*game.Tile {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	discarded := r.Board.DiscardedTiles
+	r.Board.DiscardedTiles = nil
+	return discarded
+}
+
+// EndTurn ends the player's turn once they placed their tile
+func (r *Room) EndTurn(playerID string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
 	if currentPlayer == nil || currentPlayer.ID != playerID {
 		return fmt.Errorf("not your turn")
 	}
 	
-	return r.Board.PlaceMeeple(playerID, featureID)
+	if err := r.checkPhase(PhaseComplete, PhaseMeeplePlacement); err != nil {
+		return err
+	}
+	
+	r.nextTurn()
+	return nil
 }
 
-// NextTurn advances to the next turn
-func (r *Room) NextTurn() {
+// SkipMeeple ends the player's turn after placing their tile, without
+// placing a figure
+func (r *Room) SkipMeeple(playerID string) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.ID != playerID {
+		return fmt.Errorf("not your turn")
+	}
+	
+	if err := r.checkPhase(PhaseMeeplePlacement); err != nil {
+		return err
+	}
+	
+	r.nextTurn()
+	return nil
+}
+
+// nextTurn advances the turn and restarts the game clock.
+// Must be called with the room lock held.
+func (r *Room) nextTurn() {
+	r.chargeTurnClock()
+	r.stopTurnTimer()
+	
+	summary := TurnSummary{}
+	if currentPlayer := r.Board.GetCurrentPlayer(); currentPlayer != nil {
+		summary.PlayerID = currentPlayer.ID
+	}
+	
 	r.Board.NextTurn()
+	r.turnNumber++
 	
-	if r.Board.GameEnded {
+	// Final scoring at game end counts towards the last turn
+	if player := r.Board.GetPlayer(summary.PlayerID); player != nil {
+		summary.ScoreChange = player.Score - r.turnStartScore
+	}
+	if !r.Board.GameEnded {
+		if nextPlayer := r.Board.GetCurrentPlayer(); nextPlayer != nil {
+			summary.NextPlayer = nextPlayer.ID
+		}
+	}
+	r.lastTurn = summary
+	r.beginTurn()
+	
+	if r.Board.GameEnded && !r.GameEnded {
 		r.GameEnded = true
+		r.finishGame()
+	}
+	
+	r.startTurnClock()
+	r.startTurnTimer()
+}
+
+// beginTurn remembers the current player's score at the start of their turn.
+// Must be called with the room lock held.
+func (r *Room) beginTurn() {
+	r.turnStartScore = 0
+	if currentPlayer := r.Board.GetCurrentPlayer(); currentPlayer != nil {
+		r.turnStartScore = currentPlayer.Score
+	}
+	
+	// A restored game may resume halfway through a turn
+	r.phase = PhaseTilePlacement
+	if r.Board.LastPlacedTile != nil {
+		r.phase = PhaseMeeplePlacement
+		if r.Board.MeeplePlaced() {
+			r.phase = PhaseComplete
+		}
+	}
+}
+
+// checkPhase returns why an action isn't allowed unless the turn is in one
+// of the given phases, the first being the action's main phase.
+// Must be called with the room lock held.
+func (r *Room) checkPhase(allowed ...TurnPhase) error {
+	for _, phase := range allowed {
+		if r.phase == phase {
+			return nil
+		}
+	}
+	
+	switch {
+	case r.phase == PhaseTilePlacement:
+		return game.ErrNoTilePlaced
+	case allowed[0] == PhaseTilePlacement:
+		return ErrTilePlaced
+	case r.phase == PhaseMeeplePlacement:
+		return game.ErrNoMeeplePlaced
+	default:
+		return game.ErrMeepleAlreadyPlaced
+	}
+}
+
+// TakeGameEnd reports whether the game has ended and its end wasn't taken
+// before, so the end of a game is announced exactly once
+func (r *Room) TakeGameEnd() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	if !r.GameEnded || r.endAnnounced {
+		return false
+	}
+	r.endAnnounced = true
+	return true
+}
+
+// GetWinners returns the IDs of the players with the highest score
+func (r *Room) GetWinners() []string {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.GetWinners()
+}
+
+// LastTurn returns the summary of the last finished turn
+func (r *Room) LastTurn() TurnSummary {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.lastTurn
+}
+
+// finishGame reports the final result of the game.
+// Must be called with the room lock held.
+func (r *Room) finishGame() {
+	if r.onGameEnd == nil {
+		return
+	}
+	
+	players := make([]game.Player, 0, len(r.Board.Players))
+	for _, p := range r.Board.Players {
+		players = append(players, *p)
+	}
+	
+	r.onGameEnd(GameResult{
+		RoomID:     r.ID,
+		Options:    r.Options,
+		Players:    players,
+		FinishedAt: time.Now(),
+	})
+}
+
+// SetFlagHandler sets the function called after a player ran out of time
+// and their turn was played automatically
+func (r *Room) SetFlagHandler(handler func(playerID string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.onFlag = handler
+}
+
+// GetTimeBanks returns the remaining time bank of every player in milliseconds,
+// or nil if the room is not timed
+func (r *Room) GetTimeBanks() map[string]int64 {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	if r.Options.TimeBank <= 0 {
+		return nil
+	}
+	
+	banks := make(map[string]int64, len(r.Board.Players))
+	for _, p := range r.Board.Players {
+		banks[p.ID] = p.TimeBank.Milliseconds()
+	}
+	
+	return banks
+}
+
+// startTurnClock starts counting down the current player's time bank.
+// Must be called with the room lock held.
+func (r *Room) startTurnClock() {
+	if r.Options.TimeBank <= 0 || r.GameEnded {
+		return
+	}
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.IsBot {
+		return
+	}
+	
+	playerID := currentPlayer.ID
+	turn := r.turnNumber
+	r.turnStartedAt = time.Now()
+	r.clockTimer = time.AfterFunc(currentPlayer.TimeBank, func() {
+		r.flagPlayer(playerID, turn)
+	})
+}
+
+// chargeTurnClock stops the clock and deducts the time spent this turn from
+// the current player's bank. Must be called with the room lock held.
+func (r *Room) chargeTurnClock() {
+	if r.clockTimer == nil {
+		return
+	}
+	
+	r.clockTimer.Stop()
+	r.clockTimer = nil
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return
+	}
+	
+	currentPlayer.TimeBank -= time.Since(r.turnStartedAt)
+	if currentPlayer.TimeBank < 0 {
+		currentPlayer.TimeBank = 0
+	}
+}
+
+// startTurnTimer arms the per-turn timeout of the current player.
+// Must be called with the room lock held.
+func (r *Room) startTurnTimer() {
+	if r.Options.TurnTimeout <= 0 || r.GameEnded {
+		return
+	}
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if currentPlayer == nil || currentPlayer.IsBot {
+		return
+	}
+	
+	playerID := currentPlayer.ID
+	turn := r.turnNumber
+	r.turnTimer = time.AfterFunc(r.Options.TurnTimeout, func() {
+		r.timeoutTurn(playerID, turn)
+	})
+}
+
+// stopTurnTimer disarms the per-turn timeout.
+// Must be called with the room lock held.
+func (r *Room) stopTurnTimer() {
+	if r.turnTimer == nil {
+		return
+	}
+	
+	r.turnTimer.Stop()
+	r.turnTimer = nil
+}
+
+// timeoutTurn plays the turn of a player who took too long, placing a
+// random valid tile without a meeple
+func (r *Room) timeoutTurn(playerID string, turn int) {
+	r.mutex.Lock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if r.turnNumber != turn || r.GameEnded || currentPlayer == nil || currentPlayer.ID != playerID {
+		// The player moved just before the timer fired
+		r.mutex.Unlock()
+		return
+	}
+	
+	r.turnTimer = nil
+	r.autoPlaceTile()
+	r.nextTurn()
+	
+	handler := r.onTurnTimeout
+	r.mutex.Unlock()
+	
+	if handler != nil {
+		handler(playerID)
 	}
 }
 
+// SetTurnTimeoutHandler sets the function called after a player's turn
+// timed out and was played automatically
+func (r *Room) SetTurnTimeoutHandler(handler func(playerID string)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	r.onTurnTimeout = handler
+}
+
+// flagPlayer plays the turn of a player whose time bank ran out
+func (r *Room) flagPlayer(playerID string, turn int) {
+	r.mutex.Lock()
+	
+	currentPlayer := r.Board.GetCurrentPlayer()
+	if r.turnNumber != turn || r.GameEnded || currentPlayer == nil || currentPlayer.ID != playerID {
+		// The player moved just before the clock fired
+		r.mutex.Unlock()
+		return
+	}
+	
+	currentPlayer.TimeBank = 0
+	r.clockTimer = nil
+	r.autoPlaceTile()
+	r.nextTurn()
+	
+	handler := r.onFlag
+	r.mutex.Unlock()
+	
+	if handler != nil {
+		handler(playerID)
+	}
+}
+
+// autoPlaceTile places the current tile at a random valid position unless
+// a tile was already placed this turn. Must be called with the room lock held.
+func (r *Room) autoPlaceTile() {
+	if r.Board.LastPlacedTile != nil {
+		return
+	}
+	
+	placements := r.Board.GetValidPlacements()
+	if len(placements) == 0 {
+		return
+	}
+	
+	placement := placements[r.rng.Intn(len(placements))]
+	r.Board.PlaceTile(placement.Position, placement.Rotation)
+}
+
 // GetGameState returns the current game state
 func (r *Room) GetGameState() game.GameState {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
-	return r.Board.GetGameState()
+	state := r.Board.GetGameState()
+	if r.GameStarted && !r.GameEnded {
+		state.Phase = string(r.phase)
+	}
+	return state
+}
+
+// TakeGameStateDelta returns the changes to the game state since the last
+// delta was taken, so each broadcast only carries what changed
+func (r *Room) TakeGameStateDelta() game.GameStateDelta {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	
+	delta := r.Board.StateSince(r.deltaVersion)
+	if r.GameStarted && !r.GameEnded {
+		delta.Phase = string(r.phase)
+	}
+	r.deltaVersion = delta.Version
+	return delta
+}
+
+// GetMoveHistory returns the moves played so far, oldest first
+func (r *Room) GetMoveHistory() []game.MoveRecord {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.GetMoveHistory()
+}
+
+// GetTile returns the tile placed at the given position
+func (r *Room) GetTile(pos game.Position) (game.PlacedTile, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.GetTile(pos)
+}
+
+// GetBoardGrid returns the board as a dense grid
+func (r *Room) GetBoardGrid() game.BoardGrid {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.GetBoardGrid()
 }
 
 // GetValidPlacements returns valid placements for the current tile
@@ -319,29 +1531,41 @@ func (r *Room) GetValidPlacements() []game.PlacementOption {
 	return r.Board.GetValidPlacements()
 }
 
+// GetValidMovesDetailed returns the valid placements grouped by position
+func (r *Room) GetValidMovesDetailed() []game.PositionMoves {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	
+	return r.Board.GetValidMovesDetailed()
+}
+
 // GetRoomInfo returns room information
 func (r *Room) GetRoomInfo() RoomInfo {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 	
 	return RoomInfo{
-		ID:          r.ID,
-		Name:        r.Name,
-		PlayerCount: len(r.Players) + len(r.Bots),
-		MaxPlayers:  r.MaxPlayers,
-		GameStarted: r.GameStarted,
-		CreatedBy:   r.CreatedBy,
-		CreatedAt:   r.CreatedAt,
+		ID:             r.ID,
+		Name:           r.Name,
+		PlayerCount:    len(r.Players) + len(r.Bots),
+		SpectatorCount: len(r.Spectators),
+		MaxPlayers:     r.MaxPlayers,
+		GameStarted:    r.GameStarted,
+		CreatedBy:      r.CreatedBy,
+		CreatedAt:      r.CreatedAt,
+		HasPassword:    r.Options.Password != "",
 	}
 }
 
 // RoomInfo represents room information for listing
 type RoomInfo struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	PlayerCount int       `json:"playerCount"`
-	MaxPlayers  int       `json:"maxPlayers"`
-	GameStarted bool      `json:"gameStarted"`
-	CreatedBy   string    `json:"createdBy"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ID             string    `json:"id"`
+	Name           string    `json:"name"`
+	PlayerCount    int       `json:"playerCount"`
+	SpectatorCount int       `json:"spectatorCount"`
+	MaxPlayers     int       `json:"maxPlayers"`
+	GameStarted    bool      `json:"gameStarted"`
+	CreatedBy      string    `json:"createdBy"`
+	CreatedAt      time.Time `json:"createdAt"`
+	HasPassword    bool      `json:"hasPassword"`
 }
@@ -0,0 +1,327 @@
+package room
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"carcassonne-ws/internal/game"
+	"carcassonne-ws/internal/player"
+)
+
+// newStartedRoom returns a room created by alice where alice and bob play
+func newStartedRoom(t *testing.T, options Options) *Room {
+	t.Helper()
+
+	r := NewRoom("test", "alice", 4, options)
+	seatPlayers(t, r, "alice", "bob")
+	startRoom(t, r)
+	return r
+}
+
+func TestTimeBankCharge(t *testing.T) {
+	options := testOptions()
+	options.TimeBank = time.Hour
+	r := newStartedRoom(t, options)
+	first := r.GetCurrentPlayer().ID
+
+	time.Sleep(10 * time.Millisecond)
+	passTurn(r)
+
+	for id, bank := range r.GetTimeBanks() {
+		spent := time.Hour - time.Duration(bank)*time.Millisecond
+		if id == first && (spent < 10*time.Millisecond || spent > time.Minute) {
+			t.Fatalf("%s spent %v of the time bank on a turn of 10ms", id, spent)
+		}
+		if id != first && spent != 0 {
+			t.Fatalf("%s spent %v of the time bank while waiting", id, spent)
+		}
+	}
+}
+
+func TestTimeBankFlag(t *testing.T) {
+	options := testOptions()
+	options.TimeBank = 20 * time.Millisecond
+	r := NewRoom("test", "alice", 4, options)
+	flagged := make(chan string, 1)
+	r.SetFlagHandler(func(playerID string) {
+		select {
+		case flagged <- playerID:
+		default:
+		}
+	})
+	seatPlayers(t, r, "alice", "bob")
+	startRoom(t, r)
+	first := r.GetCurrentPlayer().ID
+
+	select {
+	case playerID := <-flagged:
+		if playerID != first {
+			t.Fatalf("flagged %q, want %q", playerID, first)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%q was never flagged", first)
+	}
+
+	if bank := r.GetTimeBanks()[first]; bank != 0 {
+		t.Fatalf("time bank of the flagged player = %dms, want 0", bank)
+	}
+	for _, move := range r.GetMoveHistory() {
+		if move.PlayerID == first {
+			return
+		}
+	}
+	t.Fatalf("the flagged player's tile was not placed for them")
+}
+
+func TestNoTimeBank(t *testing.T) {
+	r := newStartedRoom(t, testOptions())
+	if banks := r.GetTimeBanks(); banks != nil {
+		t.Fatalf("GetTimeBanks of an untimed room = %v, want nil", banks)
+	}
+}
+
+func TestSeededTimeoutsReplay(t *testing.T) {
+	options := testOptions()
+	options.Seed = 42
+
+	histories := make([][]game.MoveRecord, 2)
+	for i := range histories {
+		r := newStartedRoom(t, options)
+		for turn := 0; turn < 10; turn++ {
+			r.timeoutTurn(r.GetCurrentPlayer().ID, r.turnNumber)
+		}
+		histories[i] = r.GetMoveHistory()
+	}
+	if len(histories[0]) != 10 || !reflect.DeepEqual(histories[0], histories[1]) {
+		t.Fatalf("timed out turns of rooms with the same seed placed different tiles")
+	}
+}
+
+// newBotRoom returns a room created by alice with two easy bots seated
+func newBotRoom(t *testing.T, options Options) *Room {
+	t.Helper()
+
+	r := NewRoom("bots", "alice", 4, options)
+	for _, name := range []string{"Bot 1", "Bot 2"} {
+		if err := r.AddBot(name, "easy", "alice"); err != nil {
+			t.Fatalf("AddBot: %v", err)
+		}
+	}
+	return r
+}
+
+// withoutCurrentBot runs f while the bot to move is missing from the room,
+// so its turns fail
+func withoutCurrentBot(r *Room, f func()) {
+	current := r.GetCurrentPlayer().ID
+	r.mutex.Lock()
+	bot := r.Bots[current]
+	delete(r.Bots, current)
+	r.mutex.Unlock()
+
+	f()
+
+	r.mutex.Lock()
+	r.Bots[current] = bot
+	r.mutex.Unlock()
+}
+
+func TestStartBotsOnlyGame(t *testing.T) {
+	for _, allowAllBots := range []bool{false, true} {
+		options := testOptions()
+		options.AllowAllBots = allowAllBots
+		err := newBotRoom(t, options).StartGame()
+		if allowAllBots && err != nil {
+			t.Fatalf("StartGame with allowAllBots: %v", err)
+		}
+		if !allowAllBots && err != ErrNoHumanPlayers {
+			t.Fatalf("StartGame = %v, want ErrNoHumanPlayers", err)
+		}
+	}
+}
+
+func TestBotFailuresPauseBots(t *testing.T) {
+	options := testOptions()
+	options.AllowAllBots = true
+	r := newBotRoom(t, options)
+	startRoom(t, r)
+
+	withoutCurrentBot(r, func() {
+		for i := 0; i < maxBotFailures; i++ {
+			if r.BotsErrored() {
+				t.Fatalf("bots paused after %d failures, want %d", i, maxBotFailures)
+			}
+			if _, err := r.ProcessBotTurn(); err == nil || err == ErrBotsErrored {
+				t.Fatalf("failing bot turn %d = %v", i+1, err)
+			}
+		}
+	})
+	if !r.BotsErrored() {
+		t.Fatalf("bots not paused after %d failures", maxBotFailures)
+	}
+	if _, err := r.ProcessBotTurn(); err != ErrBotsErrored {
+		t.Fatalf("ProcessBotTurn after the pause = %v, want ErrBotsErrored", err)
+	}
+}
+
+func TestBotSuccessResetsFailures(t *testing.T) {
+	options := testOptions()
+	options.AllowAllBots = true
+	r := newBotRoom(t, options)
+	startRoom(t, r)
+
+	for turn := 0; turn < 3; turn++ {
+		withoutCurrentBot(r, func() {
+			for i := 0; i < maxBotFailures-1; i++ {
+				r.ProcessBotTurn()
+			}
+		})
+		if _, err := r.ProcessBotTurn(); err != nil {
+			t.Fatalf("ProcessBotTurn: %v", err)
+		}
+	}
+	if r.BotsErrored() {
+		t.Fatalf("bots paused although every turn was played in the end")
+	}
+}
+
+// meepleCounts returns each player's meeples in supply and on the board
+func meepleCounts(r *Room) (supply, placed map[string]int) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	supply, placed = make(map[string]int), make(map[string]int)
+	for _, p := range r.Board.Players {
+		supply[p.ID] = p.Meeples
+	}
+	for _, tile := range r.Board.Tiles {
+		for _, meeple := range tile.Meeples {
+			if meeple.Type == game.NormalMeeple {
+				placed[meeple.PlayerID]++
+			}
+		}
+	}
+	return supply, placed
+}
+
+func TestBotGamesToTheEnd(t *testing.T) {
+	for i, difficulty := range player.ValidDifficulties() {
+		options := testOptions()
+		options.AllowAllBots = true
+		options.Seed = int64(i + 1)
+		r := NewRoom("bots", "alice", 4, options)
+		for _, name := range []string{"Bot 1", "Bot 2"} {
+			if err := r.AddBot(name, difficulty, "alice"); err != nil {
+				t.Fatalf("AddBot: %v", err)
+			}
+		}
+		startRoom(t, r)
+
+		returned := false
+		last, _ := meepleCounts(r)
+		for turn := 0; !r.HasEnded(); turn++ {
+			if turn > 100 {
+				t.Fatalf("%s bots: game still running after %d turns", difficulty, turn)
+			}
+			playBotTurns(t, r, 1)
+
+			supply, placed := meepleCounts(r)
+			for id, n := range supply {
+				if n < 0 || n+placed[id] != options.MeeplesPerPlayer {
+					t.Fatalf("%s bots, turn %d: %s has %d meeples in supply and %d placed", difficulty, turn, id, n, placed[id])
+				}
+				if n > last[id] {
+					returned = true
+				}
+			}
+			last = supply
+		}
+
+		claimed := false
+		for _, move := range r.GetMoveHistory() {
+			if move.FeatureID != nil {
+				claimed = true
+			}
+		}
+		if !claimed || !returned {
+			t.Fatalf("%s bots: meeples placed %v, returned %v, want both", difficulty, claimed, returned)
+		}
+		if errs := r.Board.Validate(); len(errs) > 0 {
+			t.Fatalf("%s bots: board invalid at game end: %v", difficulty, errs)
+		}
+	}
+}
+
+func TestAddBotDifficulty(t *testing.T) {
+	tests := []struct {
+		difficulty string
+		want       string // difficulty of the added bot, "" when refused
+	}{
+		{difficulty: "", want: "easy"},
+		{difficulty: "easy", want: "easy"},
+		{difficulty: "novice", want: "novice"},
+		{difficulty: "medium", want: "medium"},
+		{difficulty: "hard", want: "hard"},
+		{difficulty: "Hard"},
+		{difficulty: "expert"},
+		{difficulty: " medium"},
+	}
+
+	for _, tt := range tests {
+		r := NewRoom("bots", "alice", 4, testOptions())
+		err := r.AddBot("Bot", tt.difficulty, "alice")
+		if tt.want == "" {
+			if err != ErrInvalidDifficulty || len(r.Bots) != 0 || len(r.Board.Players) != 0 {
+				t.Fatalf("AddBot(%q) = %v with %d bots, want ErrInvalidDifficulty and no bot", tt.difficulty, err, len(r.Bots))
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("AddBot(%q): %v", tt.difficulty, err)
+		}
+		for _, bot := range r.Bots {
+			if bot.Difficulty != tt.want {
+				t.Fatalf("AddBot(%q) added a %s bot, want %s", tt.difficulty, bot.Difficulty, tt.want)
+			}
+		}
+	}
+}
+
+func TestSixPlayerGame(t *testing.T) {
+	if r := NewRoom("big", "alice", 7, testOptions()); r.MaxPlayers != game.MaxPlayers {
+		t.Fatalf("room for 7 seats %d players, want %d", r.MaxPlayers, game.MaxPlayers)
+	}
+
+	r := NewRoom("six", "p1", 6, testOptions())
+	ids := []string{"p1", "p2", "p3", "p4", "p5"}
+	seatPlayers(t, r, ids...)
+	if err := r.AddBot("Bot", "easy", "p1"); err != nil {
+		t.Fatalf("AddBot as sixth player: %v", err)
+	}
+	if err := r.AddPlayer(&game.Player{ID: "p7", Name: "p7"}); err != ErrRoomFull {
+		t.Fatalf("AddPlayer of a seventh player = %v, want ErrRoomFull", err)
+	}
+	startRoom(t, r)
+
+	colors := make(map[string]bool)
+	for _, p := range r.Board.Players {
+		if colors[p.Color] || p.Meeples != game.DefaultMeeplesPerPlayer {
+			t.Fatalf("player %s has color %q and %d meeples, want a color of its own and a full supply", p.ID, p.Color, p.Meeples)
+		}
+		colors[p.Color] = true
+	}
+	if len(colors) != 6 || !colors["pink"] {
+		t.Fatalf("colors %v, want six including pink", colors)
+	}
+
+	// Every player gets a turn before the first plays again
+	seen := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		seen[r.GetCurrentPlayer().ID] = true
+		passTurn(r)
+	}
+	if len(seen) != 6 || r.GetCurrentPlayer().ID != r.Board.TurnOrder[0] {
+		t.Fatalf("6 turns went to %d players, then to %s", len(seen), r.GetCurrentPlayer().ID)
+	}
+}
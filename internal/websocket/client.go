@@ -2,11 +2,11 @@ package websocket
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"sync"
 	"time"
 	"carcassonne-ws/internal/game"
+	"carcassonne-ws/internal/logging"
 	"github.com/gorilla/websocket"
 )
 
@@ -20,8 +20,9 @@ const (
 	// Send pings to peer with this period. Must be less than pongWait
 	pingPeriod = (pongWait * 9) / 10
 	
-	// Maximum message size allowed from peer
-	maxMessageSize = 512
+	// DefaultMaxMessageSize is the default maximum message size allowed from
+	// peer, see Hub.SetMaxMessageSize
+	DefaultMaxMessageSize = 16 * 1024
 	
 	// Latency ping interval for custom ping/pong
 	latencyPingInterval = 30 * time.Second
@@ -31,8 +32,7 @@ var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 	CheckOrigin: func(r *http.Request) bool {
-		// Allow connections from any origin for development
-		// In production, you should check the origin properly
+		// ServeWS checks the origin against the hub's allowlist
 		return true
 	},
 }
@@ -45,15 +45,37 @@ type Client struct {
 	// Buffered channel of outbound messages
 	send chan []byte
 	
+	// sendMutex guards send, which several goroutines write to, against
+	// being written to or closed again once closed
+	sendMutex sync.Mutex
+	closed    bool
+	
 	// The hub that manages this client
 	hub *Hub
 	
-	// Player information
+	// Player information. Player, RoomID and Spectator are read by other
+	// goroutines looking for recipients and only change with the hub's
+	// clients lock held, see Hub.findClients.
 	Player *game.Player
 	
+	// name is the name the player connected with. Rooms may add a suffix
+	// to Player.Name to tell players with the same name apart. Set on
+	// CONNECT with the hub's clients lock held.
+	name string
+	
+	// Token is the player's current session token, empty before CONNECT
+	Token string
+	
 	// Current room ID
 	RoomID string
 	
+	// Spectator is set when the client watches RoomID without playing
+	Spectator bool
+	
+	// stateDeltas is set when the client asked for GAME_STATE_DELTA
+	// broadcasts on CONNECT
+	stateDeltas bool
+	
 	// Latency tracking
 	latency      time.Duration
 	lastPingTime time.Time
@@ -61,6 +83,9 @@ type Client struct {
 	
 	// Client ID for ping/pong tracking
 	clientID string
+	
+	// Logger shared with the hub
+	logger *logging.Logger
 }
 
 // NewClient creates a new WebSocket client
@@ -70,6 +95,7 @@ func NewClient(hub *Hub, conn *websocket.Conn) *Client {
 		send:     make(chan []byte, 256),
 		hub:      hub,
 		clientID: generateClientID(),
+		logger:   hub.logger,
 	}
 }
 
@@ -93,29 +119,34 @@ func (c *Client) updateLatency(pingTimestamp, pongTimestamp int64) {
 	latency := time.Duration(pongTimestamp - pingTimestamp)
 	c.latency = latency
 	
-	log.Printf("Client %s latency: %v", c.clientID, latency)
+	c.logger.Debugf("Client %s latency: %v", c.clientID, latency)
 }
 
 // sendLatencyPing sends a custom ping message for latency measurement
 func (c *Client) sendLatencyPing() {
 	pingMsg, err := NewPingMessage(c.clientID)
 	if err != nil {
-		log.Printf("Error creating ping message: %v", err)
+		c.logger.Errorf("Error creating ping message: %v", err)
 		return
 	}
 	
+	c.latencyMutex.Lock()
 	c.lastPingTime = time.Now()
+	c.latencyMutex.Unlock()
 	c.SendMessage(pingMsg)
 }
 
 // readPump pumps messages from the websocket connection to the hub
 func (c *Client) readPump() {
 	defer func() {
-		c.hub.unregister <- c
+		select {
+		case c.hub.unregister <- c:
+		case <-c.hub.done:
+		}
 		c.conn.Close()
 	}()
 	
-	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadLimit(c.hub.maxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(pongWait))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(pongWait))
@@ -126,14 +157,14 @@ func (c *Client) readPump() {
 		_, messageBytes, err := c.conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.Printf("error: %v", err)
+				c.logger.Warnf("Unexpected close of client %s: %v", c.clientID, err)
 			}
 			break
 		}
 		
 		var msg Message
 		if err := json.Unmarshal(messageBytes, &msg); err != nil {
-			log.Printf("error unmarshaling message: %v", err)
+			c.logger.Warnf("Error unmarshaling message from client %s: %v", c.clientID, err)
 			continue
 		}
 		
@@ -152,7 +183,7 @@ func (c *Client) readPump() {
 func (c *Client) handlePongMessage(msg *Message) {
 	var data PongData
 	if err := ParseMessage(msg, &data); err != nil {
-		log.Printf("Error parsing pong message: %v", err)
+		c.logger.Warnf("Error parsing pong message: %v", err)
 		return
 	}
 	
@@ -168,6 +199,7 @@ func (c *Client) writePump() {
 		ticker.Stop()
 		latencyTicker.Stop()
 		c.conn.Close()
+		c.hub.writers.Done()
 	}()
 	
 	for {
@@ -180,20 +212,9 @@ func (c *Client) writePump() {
 				return
 			}
 			
-			w, err := c.conn.NextWriter(websocket.TextMessage)
-			if err != nil {
-				return
-			}
-			w.Write(message)
-			
-			// Add queued chat messages to the current websocket message
-			n := len(c.send)
-			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
-			}
-			
-			if err := w.Close(); err != nil {
+			// Every message is a JSON document of its own and gets its own
+			// frame, clients parse one message per frame
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
 				return
 			}
 			
@@ -218,23 +239,39 @@ func (c *Client) SendMessage(msg *Message) error {
 		return err
 	}
 	
+	c.sendBytes(messageBytes)
+	return nil
+}
+
+// sendBytes queues an already marshaled message for the client. A client
+// that can't keep up is disconnected; false is returned once it is.
+func (c *Client) sendBytes(messageBytes []byte) bool {
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+	
+	if c.closed {
+		return false
+	}
 	select {
 	case c.send <- messageBytes:
+		return true
 	default:
+		c.closed = true
 		close(c.send)
-		return err
+		return false
 	}
-	
-	return nil
 }
 
 // SendError sends an error message to the client
-func (c *Client) SendError(code, message string) {
+func (c *Client) SendError(replyTo *Message, code, message string) {
 	errorMsg, err := NewErrorMessage(code, message)
 	if err != nil {
-		log.Printf("error creating error message: %v", err)
+		c.logger.Errorf("error creating error message: %v", err)
 		return
 	}
+	if replyTo != nil {
+		errorMsg.ReplyTo = replyTo.MessageID
+	}
 	
 	c.SendMessage(errorMsg)
 }
@@ -258,23 +295,47 @@ func (c *Client) GetLatencyStats() map[string]interface{} {
 	}
 }
 
-// Close closes the client connection
+// Close closes the client connection. It is safe to call more than once.
 func (c *Client) Close() {
-	close(c.send)
+	c.sendMutex.Lock()
+	defer c.sendMutex.Unlock()
+	
+	if !c.closed {
+		c.closed = true
+		close(c.send)
+	}
 }
 
 // ServeWS handles websocket requests from the peer
 func ServeWS(hub *Hub, w http.ResponseWriter, r *http.Request) {
+	if hub.IsDraining() {
+		http.Error(w, "Server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	
+	if origin := r.Header.Get("Origin"); !hub.isOriginAllowed(origin) {
+		hub.logger.Warnf("Rejected WebSocket connection from origin %q", origin)
+		http.Error(w, "Origin not allowed", http.StatusForbidden)
+		return
+	}
+	
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Println(err)
+		hub.logger.Warnf("WebSocket upgrade failed: %v", err)
 		return
 	}
 	
 	client := NewClient(hub, conn)
-	client.hub.register <- client
+	hub.writers.Add(1)
+	select {
+	case hub.register <- client:
+	case <-hub.done:
+		hub.writers.Done()
+		conn.Close()
+		return
+	}
 	
-	log.Printf("New WebSocket connection established: %s", client.clientID)
+	client.logger.Debugf("New WebSocket connection established: %s", client.clientID)
 	
 	// Allow collection of memory referenced by the caller by doing all work in
 	// new goroutines
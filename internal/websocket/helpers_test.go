@@ -0,0 +1,344 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"carcassonne-ws/internal/logging"
+	"carcassonne-ws/internal/room"
+	"github.com/gorilla/websocket"
+)
+
+// testTimeout is how long tests wait for a message before failing, and
+// testBotDelay how long bots think, short but enough for clients to keep up
+const (
+	testTimeout  = 5 * time.Second
+	testBotDelay = 2 * time.Millisecond
+)
+
+// newTestHub starts a hub serving websocket connections on a test server
+// and returns it with the server's websocket URL. The configure functions
+// run before the hub does.
+func newTestHub(t *testing.T, configure ...func(*Hub)) (*Hub, string) {
+	t.Helper()
+
+	hub := NewHub(time.Hour)
+	hub.SetLogger(logging.New(logging.LevelError))
+	for difficulty := range defaultBotThinkingDelays {
+		hub.SetBotThinkingDelay(difficulty, testBotDelay)
+	}
+	for _, f := range configure {
+		f(hub)
+	}
+	go hub.Run()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ServeWS(hub, w, r)
+	}))
+	t.Cleanup(func() {
+		hub.Stop()
+		server.Close()
+	})
+
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// testClient is a websocket connection to a test hub
+type testClient struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// dial opens a websocket connection to the test hub
+func dial(t *testing.T, url string) *testClient {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &testClient{t: t, conn: conn}
+}
+
+// connect dials the test hub and sends CONNECT for the player
+func connect(t *testing.T, url, playerID, name string) *testClient {
+	t.Helper()
+
+	c := dial(t, url)
+	c.send(MessageConnect, ConnectData{PlayerID: playerID, Name: name})
+	c.expect(MessageSession)
+	return c
+}
+
+// send sends a message to the hub
+func (c *testClient) send(msgType MessageType, data interface{}) *Message {
+	c.t.Helper()
+
+	msg, err := CreateMessage(msgType, data)
+	if err != nil {
+		c.t.Fatalf("CreateMessage(%s): %v", msgType, err)
+	}
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatalf("write %s: %v", msgType, err)
+	}
+	return msg
+}
+
+// read returns the next message from the hub
+func (c *testClient) read() (*Message, error) {
+	c.conn.SetReadDeadline(time.Now().Add(testTimeout))
+	_, data, err := c.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// expect skips messages until one of the given type arrives
+func (c *testClient) expect(msgType MessageType) *Message {
+	c.t.Helper()
+
+	for {
+		msg, err := c.read()
+		if err != nil {
+			c.t.Fatalf("waiting for %s: %v", msgType, err)
+		}
+		if msg.Type == msgType {
+			return msg
+		}
+	}
+}
+
+// expectData waits for a message of the given type and decodes its data
+func (c *testClient) expectData(msgType MessageType, target interface{}) *Message {
+	c.t.Helper()
+
+	msg := c.expect(msgType)
+	if err := ParseMessage(msg, target); err != nil {
+		c.t.Fatalf("parse %s: %v", msgType, err)
+	}
+	return msg
+}
+
+// expectError waits for an ERROR and fails unless it has the given code
+func (c *testClient) expectError(code string) ErrorData {
+	c.t.Helper()
+
+	var data ErrorData
+	c.expectData(MessageError, &data)
+	if data.Code != code {
+		c.t.Fatalf("error code = %q (%s), want %q", data.Code, data.Message, code)
+	}
+	return data
+}
+
+// createRoom creates a room and returns its ID once the creator is seated
+func (c *testClient) createRoom(data CreateRoomData) string {
+	c.t.Helper()
+
+	c.send(MessageCreateRoom, data)
+	var state RoomStateData
+	c.expectData(MessageRoomState, &state)
+	return state.RoomID
+}
+
+// joinRoom joins a room and waits for its state
+func (c *testClient) joinRoom(roomID string) RoomStateData {
+	c.t.Helper()
+
+	c.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	var state RoomStateData
+	c.expectData(MessageRoomState, &state)
+	return state
+}
+
+// newLocalHub returns a hub that isn't running, for tests that hand messages
+// to it directly with localClient
+func newLocalHub(t *testing.T) *Hub {
+	t.Helper()
+
+	hub := NewHub(time.Hour)
+	hub.SetLogger(logging.New(logging.LevelError))
+	t.Cleanup(hub.Stop)
+	return hub
+}
+
+// localClient is a connected client of a hub that isn't running. Its
+// messages are handled on the test goroutine, so tests decide the order of
+// everything that happens.
+type localClient struct {
+	t      *testing.T
+	hub    *Hub
+	client *Client
+}
+
+// newLocalClient registers a client with the hub and sends CONNECT for the
+// player
+func newLocalClient(t *testing.T, hub *Hub, playerID, name string) *localClient {
+	t.Helper()
+
+	client := &Client{
+		send:     make(chan []byte, 1024),
+		hub:      hub,
+		clientID: "client_" + playerID,
+		logger:   hub.logger,
+	}
+	hub.clientsMutex.Lock()
+	hub.clients[client] = true
+	hub.clientsMutex.Unlock()
+
+	c := &localClient{t: t, hub: hub, client: client}
+	c.send(MessageConnect, ConnectData{PlayerID: playerID, Name: name})
+	return c
+}
+
+// send hands a message to the hub and returns once it was handled
+func (c *localClient) send(msgType MessageType, data interface{}) *Message {
+	c.t.Helper()
+
+	msg, err := CreateMessage(msgType, data)
+	if err != nil {
+		c.t.Fatalf("CreateMessage(%s): %v", msgType, err)
+	}
+	c.hub.handleMessage(c.client, msg)
+	return msg
+}
+
+// sendAt hands a message with the given client timestamp to the hub
+func (c *localClient) sendAt(msgType MessageType, data interface{}, timestamp time.Time) *Message {
+	c.t.Helper()
+
+	msg, err := CreateMessage(msgType, data)
+	if err != nil {
+		c.t.Fatalf("CreateMessage(%s): %v", msgType, err)
+	}
+	msg.Timestamp = timestamp
+	c.hub.handleMessage(c.client, msg)
+	return msg
+}
+
+// errors returns the ERROR replies queued for the client since the last
+// call, dropping every other message
+func (c *localClient) errors() []ErrorData {
+	c.t.Helper()
+
+	var errs []ErrorData
+	for _, msg := range c.messages() {
+		if msg.Type != MessageError {
+			continue
+		}
+		var data ErrorData
+		if err := ParseMessage(msg, &data); err != nil {
+			c.t.Fatalf("parse ERROR: %v", err)
+		}
+		errs = append(errs, data)
+	}
+	return errs
+}
+
+// messages returns the messages queued for the client since the last call
+func (c *localClient) messages() []*Message {
+	c.t.Helper()
+
+	var msgs []*Message
+	for {
+		select {
+		case data := <-c.client.send:
+			var msg Message
+			if err := json.Unmarshal(data, &msg); err != nil {
+				c.t.Fatalf("unmarshal: %v", err)
+			}
+			msgs = append(msgs, &msg)
+		default:
+			return msgs
+		}
+	}
+}
+
+// types returns the types of the messages, in order
+func types(msgs []*Message) []MessageType {
+	result := make([]MessageType, len(msgs))
+	for i, msg := range msgs {
+		result[i] = msg.Type
+	}
+	return result
+}
+
+// startLocalGame seats alice and bob in a new room without turn timer and
+// starts the game. Alice plays first.
+func startLocalGame(t *testing.T, hub *Hub, data CreateRoomData) (alice, bob *localClient, roomID string) {
+	t.Helper()
+
+	noTimeout := 0
+	if data.TurnTimeout == nil {
+		data.TurnTimeout = &noTimeout
+	}
+	if data.RoomName == "" {
+		data.RoomName = "test"
+	}
+
+	alice = newLocalClient(t, hub, "alice", "Alice")
+	bob = newLocalClient(t, hub, "bob", "Bob")
+	alice.send(MessageCreateRoom, data)
+	roomID = alice.client.RoomID
+	bob.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	alice.send(MessageSetReady, ReadyData{Ready: true})
+	bob.send(MessageSetReady, ReadyData{Ready: true})
+	alice.send(MessageStartGame, nil)
+	if room, err := hub.roomManager.GetRoom(roomID); err != nil || !room.HasStarted() {
+		t.Fatalf("game of room %q did not start", roomID)
+	}
+
+	alice.messages()
+	bob.messages()
+	return alice, bob, roomID
+}
+
+// startLocalBotGame has alice create a room with two bots, leave it and start
+// its game, so only bots play. It returns alice, who is no longer in the room.
+func startLocalBotGame(t *testing.T, hub *Hub, data CreateRoomData) (*localClient, *room.Room) {
+	t.Helper()
+
+	noTimeout := 0
+	data.TurnTimeout = &noTimeout
+	data.RoomName = "bots"
+	data.AllowAllBots = true
+
+	alice := newLocalClient(t, hub, "alice", "Alice")
+	alice.send(MessageCreateRoom, data)
+	roomID := alice.client.RoomID
+	for _, name := range []string{"Bot 1", "Bot 2"} {
+		alice.send(MessageAddBot, AddBotData{BotName: name, Difficulty: "easy"})
+	}
+	alice.send(MessageLeaveRoom, LeaveRoomData{RoomID: roomID})
+	if err := hub.StartGame(roomID, "alice"); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+	room, err := hub.roomManager.GetRoom(roomID)
+	if err != nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+	alice.messages()
+	return alice, room
+}
+
+// playNextBotTurn hands the turn the room is waiting on to the hub's bot
+// player, as if the bot was done thinking
+func playNextBotTurn(t *testing.T, hub *Hub, room *room.Room) {
+	t.Helper()
+
+	_, turn, ok := room.BotToMove()
+	if !ok {
+		t.Fatalf("no bot to move")
+	}
+	hub.playBotTurn(botTurn{roomID: room.ID, turn: turn})
+}
@@ -1,16 +1,57 @@
 package websocket
 
 import (
+	"encoding/json"
+	"errors"
 	"carcassonne-ws/internal/game"
+	"carcassonne-ws/internal/logging"
 	"carcassonne-ws/internal/room"
-	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrPlayerIDConnected is returned when a player ID seated in a room through
+// one connection tries to join it again through another
+var ErrPlayerIDConnected = errors.New("player id already connected")
+
+// drainPollInterval is how often Drain checks whether games reached a turn boundary
+const drainPollInterval = 100 * time.Millisecond
+
+// heartbeatInterval is how often Run records that it is alive, and
+// heartbeatTimeout how old the last heartbeat may be for Healthy
+const (
+	heartbeatInterval = 5 * time.Second
+	heartbeatTimeout  = 3 * heartbeatInterval
+)
+
+// DefaultBotSweepInterval is how often bot turns nobody was notified of are
+// picked up, see NewHub
+const DefaultBotSweepInterval = 10 * time.Second
+
+// defaultBotThinkingDelays is how long each bot difficulty waits before moving
+var defaultBotThinkingDelays = map[string]time.Duration{
+	"easy":   500 * time.Millisecond,
+	"novice": 750 * time.Millisecond,
+	"medium": 1 * time.Second,
+	"hard":   1500 * time.Millisecond,
+}
+
+// botTurn is a turn of a room that landed on a bot
+type botTurn struct {
+	roomID string
+	turn   int
+}
+
 // Hub maintains the set of active clients and broadcasts messages to the clients
 type Hub struct {
-	// Registered clients
-	clients map[*Client]bool
+	// Registered clients. Messages are handled on each client's own
+	// goroutine, so the set and the fields used to look clients up are only
+	// changed with clientsMutex held, see findClients.
+	clients      map[*Client]bool
+	clientsMutex sync.RWMutex
 	
 	// Inbound messages from the clients
 	broadcast chan []byte
@@ -21,87 +62,270 @@ type Hub struct {
 	// Unregister requests from clients
 	unregister chan *Client
 	
+	// Shutdown requests, answered once every client was told and closed
+	shutdown chan chan struct{}
+	
+	// Write pumps still flushing their queued messages
+	writers sync.WaitGroup
+	
+	// Closed by Stop to end Run and bot processing
+	done     chan struct{}
+	stopOnce sync.Once
+	
 	// Room manager
 	roomManager *room.Manager
 	
-	// Bot processing ticker
+	// Session tokens of connected and recently connected players
+	sessions *SessionStore
+	
+	// Maximum size of a message read from a client
+	maxMessageSize int64
+	
+	// Origins browsers may connect from, any origin when empty
+	allowedOrigins map[string]bool
+	
+	// Bot turns ready to be played
+	botTurns chan botTurn
+	
+	// Artificial thinking time before a bot moves, by difficulty
+	botDelays map[string]time.Duration
+	
+	// Safety sweep picking up bot turns no event was sent for
 	botTicker *time.Ticker
+	
+	// How often idle rooms are cleaned up, and how old they must be
+	roomSweepInterval time.Duration
+	idleRoomTTL       time.Duration
+	
+	// Set while the server drains before shutting down
+	draining atomic.Bool
+	
+	// Number of registered clients, readable outside the Run goroutine
+	clientCount atomic.Int64
+	
+	// When Run last went around its loop, in Unix nanoseconds
+	heartbeat atomic.Int64
+	
+	// Logger of the hub and its clients
+	logger *logging.Logger
+}
+
+// Metrics is a snapshot of the server's load and game activity
+type Metrics struct {
+	ConnectedClients int `json:"connectedClients"`
+	ActiveRooms      int `json:"activeRooms"`
+	TotalPlayers     int `json:"totalPlayers"`
+	GamesInProgress  int `json:"gamesInProgress"`
+	GamesStarted     int `json:"gamesStarted"`
+	GamesCompleted   int `json:"gamesCompleted"`
 }
 
-// NewHub creates a new WebSocket hub
-func NewHub() *Hub {
+// NewHub creates a new WebSocket hub. Bot turns are played when a turn lands
+// on a bot, botSweepInterval is how often rooms are also checked for bot
+// turns that were missed.
+func NewHub(botSweepInterval time.Duration) *Hub {
+	botDelays := make(map[string]time.Duration, len(defaultBotThinkingDelays))
+	for difficulty, delay := range defaultBotThinkingDelays {
+		botDelays[difficulty] = delay
+	}
+	
 	return &Hub{
-		clients:     make(map[*Client]bool),
-		broadcast:   make(chan []byte),
-		register:    make(chan *Client),
-		unregister:  make(chan *Client),
-		roomManager: room.NewManager(),
-		botTicker:   time.NewTicker(2 * time.Second), // Process bot moves every 2 seconds
+		clients:           make(map[*Client]bool),
+		broadcast:         make(chan []byte),
+		register:          make(chan *Client),
+		unregister:        make(chan *Client),
+		shutdown:          make(chan chan struct{}),
+		done:              make(chan struct{}),
+		roomManager:       room.NewManager(),
+		sessions:          NewSessionStore(nil),
+		maxMessageSize:    DefaultMaxMessageSize,
+		botTurns:          make(chan botTurn),
+		botDelays:         botDelays,
+		botTicker:         time.NewTicker(botSweepInterval),
+		roomSweepInterval: room.DefaultSweepInterval,
+		idleRoomTTL:       room.DefaultIdleRoomTTL,
+		logger:            logging.New(logging.LevelInfo),
 	}
 }
 
 // Run starts the hub
 func (h *Hub) Run() {
 	go h.processBotMoves()
+	go h.roomManager.RunSweeper(h.roomSweepInterval, h.idleRoomTTL, h.done)
+	
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	h.heartbeat.Store(time.Now().UnixNano())
 	
 	for {
 		select {
+		case now := <-heartbeat.C:
+			h.heartbeat.Store(now.UnixNano())
+			
 		case client := <-h.register:
+			h.clientsMutex.Lock()
 			h.clients[client] = true
-			log.Printf("Client connected. Total clients: %d", len(h.clients))
+			h.clientsMutex.Unlock()
+			h.clientCount.Store(int64(len(h.clients)))
+			h.logger.Infof("Client connected. Total clients: %d", len(h.clients))
 			
 		case client := <-h.unregister:
 			if _, ok := h.clients[client]; ok {
+				h.clientsMutex.Lock()
 				delete(h.clients, client)
-				close(client.send)
+				h.clientsMutex.Unlock()
+				h.clientCount.Store(int64(len(h.clients)))
+				client.Close()
 				
-				// Handle player leaving
+				// Handle player leaving. Players of a running game keep their
+				// seat for a while so they can reconnect, then forfeit.
+				h.stopSpectating(client)
 				if client.Player != nil && client.RoomID != "" {
-					h.roomManager.LeaveRoom(client.RoomID, client.Player.ID)
-					h.broadcastRoomState(client.RoomID)
+					if room, _ := h.roomManager.FindActiveGame(client.Player.ID); room != nil && room.ID == client.RoomID {
+						room.MarkDisconnected(client.Player.ID)
+						h.broadcastRoomState(client.RoomID)
+					} else if h.roomManager.LeaveRoom(client.RoomID, client.Player.ID) == nil {
+						h.broadcastPlayerEvent(MessagePlayerLeft, client.RoomID, client.Player)
+					}
 				}
 				
-				log.Printf("Client disconnected. Total clients: %d", len(h.clients))
+				h.logger.Infof("Client disconnected. Total clients: %d", len(h.clients))
 			}
 			
 		case message := <-h.broadcast:
+			h.clientsMutex.Lock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
+				if !client.sendBytes(message) {
 					delete(h.clients, client)
 				}
 			}
+			h.clientsMutex.Unlock()
+			h.clientCount.Store(int64(len(h.clients)))
+			
+		case done := <-h.shutdown:
+			h.closeClients()
+			close(done)
+			
+		case <-h.done:
+			return
+		}
+	}
+}
+
+// Stop ends Run and bot processing. Clients still connected are not told,
+// see Shutdown. It is safe to call more than once.
+func (h *Hub) Stop() {
+	h.stopOnce.Do(func() {
+		h.botTicker.Stop()
+		close(h.done)
+	})
+}
+
+// closeClients sends SERVER_SHUTDOWN to every client and closes their
+// connections once it is written
+func (h *Hub) closeClients() {
+	var data []byte
+	msg, err := CreateMessage(MessageServerShutdown, ServerShutdownData{
+		Message: "Server is shutting down",
+	})
+	if err == nil {
+		data, err = json.Marshal(msg)
+	}
+	if err != nil {
+		h.logger.Errorf("Error creating server shutdown message: %v", err)
+	}
+	
+	h.clientsMutex.Lock()
+	for client := range h.clients {
+		// Clients whose queue is full are just disconnected
+		if data != nil {
+			client.sendBytes(data)
 		}
+		client.Close()
+		delete(h.clients, client)
+	}
+	h.clientsMutex.Unlock()
+	h.clientCount.Store(0)
+}
+
+// Shutdown stops bot turns, tells every client the server is going away with
+// SERVER_SHUTDOWN and closes their connections. It returns once every queued
+// message was written, with the hub stopped.
+func (h *Hub) Shutdown() {
+	h.draining.Store(true)
+	h.botTicker.Stop()
+	
+	done := make(chan struct{})
+	select {
+	case h.shutdown <- done:
+		<-done
+	case <-h.done:
+		// Run already stopped, nobody is left to notify
 	}
+	
+	h.writers.Wait()
+	h.Stop()
 }
 
 // handleMessage handles incoming messages from clients
 func (h *Hub) handleMessage(client *Client, msg *Message) {
-	log.Printf("Handling : %s", msg.Type)
+	h.logger.Debugf("Handling %s from client %s", msg.Type, client.clientID)
 	switch msg.Type {
 	case MessageConnect:
 		h.handleConnect(client, msg)
+	case MessageLogout:
+		h.handleLogout(client, msg)
 	case MessageListRooms:
 		h.handleListRooms(client, msg)
 	case MessageCreateRoom:
 		h.handleCreateRoom(client, msg)
+	case MessageCreateDailyChallenge:
+		h.handleCreateDailyChallenge(client, msg)
+	case MessageGetLeaderboard:
+		h.handleGetLeaderboard(client, msg)
 	case MessageJoinRoom:
 		h.handleJoinRoom(client, msg)
 	case MessageLeaveRoom:
 		h.handleLeaveRoom(client, msg)
+	case MessageSpectateRoom:
+		h.handleSpectateRoom(client, msg)
 	case MessageAddBot:
 		h.handleAddBot(client, msg)
+	case MessageBanPlayer:
+		h.handleBanPlayer(client, msg)
+	case MessageSetReady:
+		h.handleSetReady(client, msg)
+	case MessageSetName:
+		h.handleSetName(client, msg)
+	case MessageStartGame:
+		h.handleStartGame(client, msg)
 	case MessagePlaceTile:
 		h.handlePlaceTile(client, msg)
 	case MessagePlaceMeeple:
 		h.handlePlaceMeeple(client, msg)
+	case MessageUndoMeeple:
+		h.handleUndoMeeple(client, msg)
+	case MessageEndTurn:
+		h.handleEndTurn(client, msg)
+	case MessageSkipMeeple:
+		h.handleSkipMeeple(client, msg)
+	case MessageGetMeepleOptions:
+		h.handleGetMeepleOptions(client, msg)
+	case MessageGetTile:
+		h.handleGetTile(client, msg)
+	case MessageGetBoardGrid:
+		h.handleGetBoardGrid(client, msg)
+	case MessageGetGameState:
+		h.handleGetGameState(client, msg)
+	case MessageGetHistory:
+		h.handleGetHistory(client, msg)
 	case MessagePing:
 		h.handlePing(client, msg)
+	case MessageGetRoomLatencies:
+		h.handleGetRoomLatencies(client, msg)
 	default:
-		log.Printf("Invalid message  : %s", msg.Type)
-		client.SendError("UNKNOWN_MESSAGE", "Unknown message type")
+		h.logger.Warnf("Unknown message type %s from client %s", msg.Type, client.clientID)
+		client.SendError(msg, "UNKNOWN_MESSAGE", "Unknown message type")
 	}
 }
 
@@ -109,7 +333,23 @@ func (h *Hub) handleMessage(client *Client, msg *Message) {
 func (h *Hub) handleConnect(client *Client, msg *Message) {
 	var data ConnectData
 	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid connect data")
+		client.SendError(msg, "INVALID_DATA", "Invalid connect data")
+		return
+	}
+	
+	// A seat in a running game is only given back to the player holding its
+	// token, even when the session was lost in a restart
+	if data.Token == "" {
+		if room, _ := h.roomManager.FindActiveGame(data.PlayerID); room != nil {
+			client.SendError(msg, "INVALID_TOKEN", "session token required to rejoin a running game")
+			return
+		}
+	}
+	
+	// Each connect rotates the session token, so a stale token can't take the seat
+	token, expiresAt, err := h.sessions.Authenticate(data.PlayerID, data.Token)
+	if err != nil {
+		client.SendError(msg, "INVALID_TOKEN", err.Error())
 		return
 	}
 	
@@ -118,17 +358,131 @@ func (h *Hub) handleConnect(client *Client, msg *Message) {
 		ID:      data.PlayerID,
 		Name:    data.Name,
 		Color:   data.Color,
-		Meeples: 7,
+		Meeples: game.DefaultMeeplesPerPlayer,
 		IsBot:   false,
 		Score:   0,
 	}
 	
+	h.clientsMutex.Lock()
 	client.Player = player
+	client.name = data.Name
+	h.clientsMutex.Unlock()
+	client.Token = token
+	client.stateDeltas = data.StateDeltas
+	
+	sessionMsg, err := CreateMessage(MessageSession, SessionData{
+		PlayerID:  player.ID,
+		Token:     token,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create session")
+		return
+	}
+	client.SendMessage(sessionMsg)
+	
+	// A player dropped from a running game takes their seat back
+	if room, seat := h.roomManager.FindActiveGame(player.ID); room != nil {
+		h.reattachClient(client, room, seat)
+		return
+	}
 	
 	// Send room list
 	h.handleListRooms(client, msg)
 }
 
+// reattachClient seats a reconnecting client back in its running game and
+// brings it up to date
+func (h *Hub) reattachClient(client *Client, room *room.Room, seat *game.Player) {
+	// A connection of the same player that is still open loses the seat
+	others := h.findClients(func(other *Client) bool {
+		return other != client && other.RoomID == room.ID && !other.Spectator && other.Player != nil && other.Player.ID == seat.ID
+	})
+	for _, other := range others {
+		h.moveClient(other, "", false)
+	}
+	
+	h.setClientPlayer(client, seat)
+	h.moveClient(client, room.ID, false)
+	room.MarkReconnected(seat.ID)
+	
+	h.sendRoomState(client, room)
+	
+	stateMsg, err := NewGameStateMessage(room.GetGameState())
+	if err != nil {
+		h.logger.Errorf("Error creating game state message: %v", err)
+		return
+	}
+	client.SendMessage(stateMsg)
+	h.sendCurrentTurn(client, room)
+	
+	h.broadcastRoomState(room.ID)
+	
+	// A game paused while nobody was watching picks up where it stopped
+	if room.Options.PauseWhenEmpty {
+		h.scheduleBotTurn(room)
+	}
+}
+
+// sendCurrentTurn sends the TURN_START of the running turn to one client
+func (h *Hub) sendCurrentTurn(client *Client, room *room.Room) {
+	currentPlayer := room.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return
+	}
+	
+	validPlacements := room.GetValidPlacements()
+	validMoves := room.GetValidMovesDetailed()
+	if room.Options.HidePlacements && (client.Spectator || currentPlayer.ID != client.Player.ID) {
+		validPlacements = nil
+		validMoves = nil
+	}
+	
+	turnMsg, err := NewTurnStartMessage(currentPlayer.ID, room.GetGameState().CurrentTile, validPlacements, validMoves, room.GetTimeBanks(), room.CanPlaceMeeple(currentPlayer.ID))
+	if err != nil {
+		h.logger.Errorf("Error creating turn start message: %v", err)
+		return
+	}
+	client.SendMessage(turnMsg)
+}
+
+// handleLogout invalidates the player's session token and removes them from their room
+func (h *Hub) handleLogout(client *Client, msg *Message) {
+	if client.Player == nil {
+		client.SendError(msg, "NOT_CONNECTED", "Must connect first")
+		return
+	}
+	
+	h.sessions.Revoke(client.Player.ID)
+	client.Token = ""
+	
+	h.stopSpectating(client)
+	roomID := client.RoomID
+	if roomID != "" {
+		h.moveClient(client, "", false)
+	}
+	
+	// Without a token the player can't come back to a running game, so
+	// logging out forfeits it
+	if room, _ := h.roomManager.FindActiveGame(client.Player.ID); room != nil {
+		if err := h.forfeitPlayer(room.ID, client.Player.ID); err != nil {
+			h.logger.Errorf("Error forfeiting the game of %s on logout: %v", client.Player.ID, err)
+		}
+	} else if roomID != "" && h.roomManager.LeaveRoom(roomID, client.Player.ID) == nil {
+		h.broadcastPlayerEvent(MessagePlayerLeft, roomID, client.Player)
+	}
+	
+	h.setClientPlayer(client, nil)
+	
+	response, err := CreateMessage(MessageLogout, struct{}{})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create logout message")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
 // handleListRooms handles room listing request
 func (h *Hub) handleListRooms(client *Client, msg *Message) {
 	roomInfos := h.roomManager.GetActiveRooms()
@@ -143,6 +497,7 @@ func (h *Hub) handleListRooms(client *Client, msg *Message) {
 			MaxPlayers:  roomInfo.MaxPlayers,
 			GameStarted: roomInfo.GameStarted,
 			CreatedBy:   roomInfo.CreatedBy,
+			HasPassword: roomInfo.HasPassword,
 		}
 	}
 	
@@ -150,7 +505,7 @@ func (h *Hub) handleListRooms(client *Client, msg *Message) {
 		Rooms: rooms,
 	})
 	if err != nil {
-		client.SendError("INTERNAL_ERROR", "Failed to create room list")
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create room list")
 		return
 	}
 	
@@ -160,298 +515,1793 @@ func (h *Hub) handleListRooms(client *Client, msg *Message) {
 // handleCreateRoom handles room creation
 func (h *Hub) handleCreateRoom(client *Client, msg *Message) {
 	if client.Player == nil {
-		client.SendError("NOT_CONNECTED", "Must connect first")
+		client.SendError(msg, "NOT_CONNECTED", "Must connect first")
+		return
+	}
+	
+	if h.IsDraining() {
+		client.SendError(msg, "SERVER_DRAINING", "Server is shutting down, no new rooms")
 		return
 	}
 	
 	var data CreateRoomData
 	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid create room data")
+		client.SendError(msg, "INVALID_DATA", "Invalid create room data")
 		return
 	}
 	
-	room, err := h.roomManager.CreateRoom(data.RoomName, client.Player.ID, data.MaxPlayers)
-	if err != nil {
-		client.SendError("CREATE_FAILED", err.Error())
-		return
+	options := room.DefaultOptions()
+	if data.StrictPlacement != nil {
+		options.StrictPlacement = *data.StrictPlacement
+	}
+	options.PauseWhenEmpty = data.PauseWhenEmpty
+	options.CommandMaxAge = time.Duration(data.CommandMaxAge) * time.Second
+	options.TimeBank = time.Duration(data.TimeBank) * time.Second
+	if data.TurnTimeout != nil {
+		options.TurnTimeout = time.Duration(*data.TurnTimeout) * time.Second
+	}
+	options.AllowAllBots = data.AllowAllBots
+	options.Builders = data.Builders
+	options.RandomizeSeating = data.RandomizeSeating
+	options.HidePlacements = options.TimeBank > 0
+	if data.HidePlacements != nil {
+		options.HidePlacements = *data.HidePlacements
 	}
+	if data.MeeplesPerPlayer > 0 {
+		options.MeeplesPerPlayer = data.MeeplesPerPlayer
+	}
+	options.Password = data.Password
 	
-	// Add creator to room
-	err = room.AddPlayer(client.Player)
+	room, err := h.roomManager.CreateRoom(data.RoomName, client.Player.ID, data.MaxPlayers, options)
 	if err != nil {
-		client.SendError("JOIN_FAILED", err.Error())
+		client.SendError(msg, createErrorCode(err), err.Error())
 		return
 	}
 	
-	client.RoomID = room.ID
-	
-	// Send room state
-	h.sendRoomState(client, room)
+	h.enterCreatedRoom(client, msg, room)
 }
 
-// handleJoinRoom handles joining a room
-func (h *Hub) handleJoinRoom(client *Client, msg *Message) {
+// handleCreateDailyChallenge handles creating a room with today's shared deck
+func (h *Hub) handleCreateDailyChallenge(client *Client, msg *Message) {
 	if client.Player == nil {
-		client.SendError("NOT_CONNECTED", "Must connect first")
+		client.SendError(msg, "NOT_CONNECTED", "Must connect first")
 		return
 	}
 	
-	var data JoinRoomData
+	if h.IsDraining() {
+		client.SendError(msg, "SERVER_DRAINING", "Server is shutting down, no new rooms")
+		return
+	}
+	
+	var data CreateRoomData
 	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid join room data")
+		client.SendError(msg, "INVALID_DATA", "Invalid create room data")
 		return
 	}
 	
-	err := h.roomManager.JoinRoom(data.RoomID, client.Player)
+	room, err := h.roomManager.CreateDailyChallenge(data.RoomName, client.Player.ID, data.MaxPlayers, time.Now())
 	if err != nil {
-		client.SendError("JOIN_FAILED", err.Error())
+		client.SendError(msg, createErrorCode(err), err.Error())
 		return
 	}
 	
-	client.RoomID = data.RoomID
-	
-	// Broadcast room state to all players in room
-	h.broadcastRoomState(data.RoomID)
+	h.enterCreatedRoom(client, msg, room)
 }
 
-// handleLeaveRoom handles leaving a room
-func (h *Hub) handleLeaveRoom(client *Client, msg *Message) {
-	if client.RoomID == "" {
-		client.SendError("NOT_IN_ROOM", "Not in any room")
-		return
+// createErrorCode maps a room creation error to the error code sent to the client
+func createErrorCode(err error) string {
+	if errors.Is(err, room.ErrRoomLimit) {
+		return "ROOM_LIMIT_REACHED"
 	}
+	return "CREATE_FAILED"
+}
+
+// enterCreatedRoom wires up a newly created room and seats its creator
+func (h *Hub) enterCreatedRoom(client *Client, msg *Message, room *room.Room) {
+	h.registerRoomHandlers(room)
 	
-	err := h.roomManager.LeaveRoom(client.RoomID, client.Player.ID)
+	// Add creator to room
+	h.resetPlayerName(client)
+	requestedName, requestedColor := client.Player.Name, client.Player.Color
+	err := room.AddPlayer(client.Player)
 	if err != nil {
-		client.SendError("LEAVE_FAILED", err.Error())
+		client.SendError(msg, "JOIN_FAILED", err.Error())
 		return
 	}
 	
-	roomID := client.RoomID
-	client.RoomID = ""
-	
-	// Broadcast room state
-	h.broadcastRoomState(roomID)
+	h.moveClient(client, room.ID, false)
+	h.sendPlayerChange(client, requestedName, requestedColor)
 	
-	// Send updated room list to client
-	h.handleListRooms(client, msg)
+	// Send room state
+	h.sendRoomState(client, room)
 }
 
-// handleAddBot handles adding a bot to a room
-func (h *Hub) handleAddBot(client *Client, msg *Message) {
-	if client.RoomID == "" {
-		client.SendError("NOT_IN_ROOM", "Not in any room")
-		return
-	}
-	
-	var data AddBotData
-	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid add bot data")
-		return
+// registerRoomHandlers hooks the room's timed events up to the hub
+func (h *Hub) registerRoomHandlers(room *room.Room) {
+	roomID := room.ID
+	room.SetFlagHandler(func(playerID string) {
+		h.handlePlayerFlagged(roomID, playerID)
+	})
+	room.SetTurnTimeoutHandler(func(playerID string) {
+		h.handleTurnTimeout(roomID, playerID)
+	})
+}
+
+// SetLogger sets the logger of the hub and the clients it serves. Must be
+// called before Run.
+func (h *Hub) SetLogger(logger *logging.Logger) {
+	h.logger = logger
+}
+
+// SetSessionSecret sets the secret session tokens are signed with. Tokens
+// stay valid across restarts as long as the secret doesn't change. Must be
+// called before Run.
+func (h *Hub) SetSessionSecret(secret []byte) {
+	h.sessions = NewSessionStore(secret)
+}
+
+// SetBotThinkingDelay sets how long bots of a difficulty wait before making
+// their move. Must be called before Run.
+func (h *Hub) SetBotThinkingDelay(difficulty string, delay time.Duration) {
+	h.botDelays[difficulty] = delay
+}
+
+// SetRoomSweep sets how often rooms are checked for being idle, and how long
+// a room may stay idle before it is removed, see
+// room.Manager.CleanupIdleRooms. Must be called before Run.
+func (h *Hub) SetRoomSweep(interval, ttl time.Duration) {
+	h.roomSweepInterval = interval
+	h.idleRoomTTL = ttl
+}
+
+// SetRoomLimits sets how many rooms may exist at once and how many rooms a
+// player may create per minute
+func (h *Hub) SetRoomLimits(maxRooms, roomsPerMinute int) {
+	h.roomManager.SetRoomLimits(maxRooms, roomsPerMinute)
+}
+
+// SetAllowedOrigins restricts the origins browsers may open a WebSocket
+// from, e.g. "https://play.example.com". An empty list allows any origin,
+// which is only meant for development. Must be called before Run.
+func (h *Hub) SetAllowedOrigins(origins []string) {
+	h.allowedOrigins = make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			h.allowedOrigins[strings.ToLower(origin)] = true
+		}
 	}
-	
-	err := h.roomManager.AddBot(client.RoomID, data.BotName, data.Difficulty, client.Player.ID)
-	if err != nil {
-		client.SendError("ADD_BOT_FAILED", err.Error())
-		return
+}
+
+// isOriginAllowed checks the Origin header of an upgrade request against
+// the allowlist. Clients that aren't browsers send no origin and are let in.
+func (h *Hub) isOriginAllowed(origin string) bool {
+	if len(h.allowedOrigins) == 0 || origin == "" {
+		return true
 	}
-	
-	// Broadcast room state
-	h.broadcastRoomState(client.RoomID)
+	return h.allowedOrigins[strings.ToLower(origin)]
 }
 
-// handlePlaceTile handles tile placement
-func (h *Hub) handlePlaceTile(client *Client, msg *Message) {
-	if client.RoomID == "" {
-		client.SendError("NOT_IN_ROOM", "Not in any room")
-		return
+// SetMaxMessageSize sets the maximum size of a message read from a client.
+// Larger messages close the connection. Messages sent to clients have no
+// limit. Must be called before Run.
+func (h *Hub) SetMaxMessageSize(size int64) {
+	h.maxMessageSize = size
+}
+
+// SaveRooms writes a snapshot of every unfinished room to dir
+func (h *Hub) SaveRooms(dir string) error {
+	return h.roomManager.SaveAll(dir)
+}
+
+// LoadRooms restores the rooms saved to dir. Players get them back by
+// reconnecting.
+func (h *Hub) LoadRooms(dir string) (int, error) {
+	rooms, err := h.roomManager.LoadAll(dir)
+	for _, room := range rooms {
+		h.registerRoomHandlers(room)
 	}
-	
-	var data PlaceTileData
+	return len(rooms), err
+}
+
+// handleGetLeaderboard replies with the daily challenge leaderboard of a date
+func (h *Hub) handleGetLeaderboard(client *Client, msg *Message) {
+	var data GetLeaderboardData
 	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid place tile data")
+		client.SendError(msg, "INVALID_DATA", "Invalid leaderboard data")
 		return
 	}
 	
-	room, err := h.roomManager.GetRoom(client.RoomID)
-	if err != nil {
-		client.SendError("ROOM_NOT_FOUND", "Room not found")
-		return
+	date := data.Date
+	if date == "" {
+		date = room.DailyChallengeDate(time.Now())
 	}
 	
-	err = room.PlaceTile(client.Player.ID, data.Position, data.Rotation)
+	response, err := CreateMessage(MessageGetLeaderboard, LeaderboardData{
+		Date:    date,
+		Entries: h.roomManager.GetLeaderboard(date),
+	})
 	if err != nil {
-		client.SendError("PLACE_TILE_FAILED", err.Error())
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create leaderboard")
 		return
 	}
 	
-	// Broadcast game state
-	h.broadcastGameState(client.RoomID)
+	client.SendMessage(response)
 }
 
-// handlePlaceMeeple handles meeple placement
-func (h *Hub) handlePlaceMeeple(client *Client, msg *Message) {
-	if client.RoomID == "" {
-		client.SendError("NOT_IN_ROOM", "Not in any room")
+// handleJoinRoom handles joining a room
+func (h *Hub) handleJoinRoom(client *Client, msg *Message) {
+	if client.Player == nil {
+		client.SendError(msg, "NOT_CONNECTED", "Must connect first")
 		return
 	}
 	
-	var data PlaceMeepleData
+	var data JoinRoomData
 	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid place meeple data")
-		return
-	}
-	
-	room, err := h.roomManager.GetRoom(client.RoomID)
-	if err != nil {
-		client.SendError("ROOM_NOT_FOUND", "Room not found")
+		client.SendError(msg, "INVALID_DATA", "Invalid join room data")
 		return
 	}
 	
-	err = room.PlaceMeeple(client.Player.ID, data.FeatureID)
-	if err != nil {
-		client.SendError("PLACE_MEEPLE_FAILED", err.Error())
+	h.resetPlayerName(client)
+	requestedName, requestedColor := client.Player.Name, client.Player.Color
+	err := h.roomManager.JoinRoom(data.RoomID, client.Player, data.Password)
+	if errors.Is(err, room.ErrAlreadyInRoom) && client.RoomID != data.RoomID {
+		// The seat belongs to another connection with the same player ID.
+		// Only a reconnect with the session token may take it over.
+		client.SendError(msg, "PLAYER_ID_CONNECTED", ErrPlayerIDConnected.Error())
 		return
 	}
-	
-	// End turn and broadcast state
-	room.NextTurn()
-	h.broadcastGameState(client.RoomID)
-	h.sendTurnStart(client.RoomID)
-}
-
-// handlePing handles ping messages for latency calculation
-func (h *Hub) handlePing(client *Client, msg *Message) {
-	var data PingData
-	if err := ParseMessage(msg, &data); err != nil {
-		client.SendError("INVALID_DATA", "Invalid ping data")
+	if errors.Is(err, room.ErrGameStarted) {
+		// Offer to watch instead, the client opts in with SPECTATE_ROOM
+		h.sendSpectateAvailable(client, msg, data.RoomID)
 		return
 	}
-	
-	// Create pong response with original timestamp
-	pongMsg, err := NewPongMessage(data.Timestamp, data.ClientID)
 	if err != nil {
-		log.Printf("Error creating pong message: %v", err)
+		client.SendError(msg, joinErrorCode(err), err.Error())
 		return
 	}
 	
-	// Send pong response back to client
-	client.SendMessage(pongMsg)
+	h.moveClient(client, data.RoomID, false)
+	h.sendPlayerChange(client, requestedName, requestedColor)
 	
-	log.Printf("Ping/Pong: Client %s latency measurement", data.ClientID)
+	// The new player gets the full room state, everybody else just hears
+	// who joined
+	if room, err := h.roomManager.GetRoom(data.RoomID); err == nil {
+		h.sendRoomState(client, room)
+	}
+	h.broadcastPlayerEventExcept(MessagePlayerJoined, data.RoomID, client.Player, client.Player.ID)
 }
 
-// sendRoomState sends room state to a specific client
-func (h *Hub) sendRoomState(client *Client, room *room.Room) {
-	players := room.GetPlayers()
-	
-	msg, err := NewRoomStateMessage(room.ID, players, room.GameStarted, room.GameEnded)
-	if err != nil {
-		log.Printf("Error creating room state message: %v", err)
-		return
+// resetPlayerName gives a client outside of any room back the name they
+// connected with, dropping the suffix a previous room added to it
+func (h *Hub) resetPlayerName(client *Client) {
+	if client.RoomID == "" {
+		client.Player.Name = client.name
 	}
-	
-	client.SendMessage(msg)
 }
 
-// broadcastRoomState broadcasts room state to all clients in a room
-func (h *Hub) broadcastRoomState(roomID string) {
-	room, err := h.roomManager.GetRoom(roomID)
-	if err != nil {
+// sendPlayerChange tells a client which name and color they got if the
+// room gave them others than they asked for
+func (h *Hub) sendPlayerChange(client *Client, requestedName, requestedColor string) {
+	if client.Player.Name == requestedName && client.Player.Color == requestedColor {
 		return
 	}
 	
-	players := room.GetPlayers()
-	msg, err := NewRoomStateMessage(roomID, players, room.GameStarted, room.GameEnded)
+	msg, err := CreateMessage(MessagePlayerUpdate, PlayerUpdateData{Player: client.Player})
 	if err != nil {
-		log.Printf("Error creating room state message: %v", err)
+		h.logger.Errorf("Error creating player update message: %v", err)
 		return
 	}
-	
-	h.broadcastToRoom(roomID, msg)
+	client.SendMessage(msg)
 }
 
-// broadcastGameState broadcasts game state to all clients in a room
-func (h *Hub) broadcastGameState(roomID string) {
+// sendSpectateAvailable offers a client to watch a room whose game already started
+func (h *Hub) sendSpectateAvailable(client *Client, msg *Message, roomID string) {
 	room, err := h.roomManager.GetRoom(roomID)
 	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
 		return
 	}
 	
-	gameState := room.GetGameState()
-	msg, err := NewGameStateMessage(gameState)
+	offer, err := CreateMessage(MessageSpectateAvailable, SpectateAvailableData{
+		RoomID:   room.ID,
+		RoomName: room.Name,
+	})
 	if err != nil {
-		log.Printf("Error creating game state message: %v", err)
+		h.logger.Errorf("Error creating spectate available message: %v", err)
 		return
 	}
 	
-	h.broadcastToRoom(roomID, msg)
+	client.SendMessage(offer)
 }
 
-// sendTurnStart sends turn start message to all clients in a room
-func (h *Hub) sendTurnStart(roomID string) {
-	room, err := h.roomManager.GetRoom(roomID)
-	if err != nil {
+// handleSpectateRoom handles watching a room without playing
+func (h *Hub) handleSpectateRoom(client *Client, msg *Message) {
+	if client.Player == nil {
+		client.SendError(msg, "NOT_CONNECTED", "Must connect first")
 		return
 	}
 	
-	currentPlayer := room.GetCurrentPlayer()
-	if currentPlayer == nil {
+	if client.RoomID != "" {
+		client.SendError(msg, "ALREADY_IN_ROOM", "Leave your current room first")
 		return
 	}
 	
-	gameState := room.GetGameState()
-	validPlacements := room.GetValidPlacements()
+	var data SpectateRoomData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid spectate room data")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(data.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if err := room.CheckPassword(data.Password); err != nil {
+		client.SendError(msg, "INCORRECT_PASSWORD", err.Error())
+		return
+	}
+	
+	h.moveClient(client, room.ID, true)
+	room.AddSpectator(client.Player.ID)
+	
+	// Bring the spectator up to date
+	h.sendRoomState(client, room)
+	if !room.HasStarted() {
+		return
+	}
+	
+	if !data.Compress && data.ChunkSize == 0 {
+		stateMsg, err := NewGameStateMessage(room.GetGameState())
+		if err != nil {
+			h.logger.Errorf("Error creating game state message: %v", err)
+			return
+		}
+		client.SendMessage(stateMsg)
+		return
+	}
+	
+	// Large boards are sent in pieces so they don't hit client buffer limits
+	chunks, err := NewGameStateChunkMessages(room.GetGameState(), data.Compress, data.ChunkSize)
+	if err != nil {
+		h.logger.Errorf("Error creating game state chunks: %v", err)
+		return
+	}
+	for _, chunk := range chunks {
+		client.SendMessage(chunk)
+	}
+}
+
+// stopSpectating takes a spectating client out of the room it watches
+func (h *Hub) stopSpectating(client *Client) {
+	if !client.Spectator {
+		return
+	}
+	
+	if room, err := h.roomManager.GetRoom(client.RoomID); err == nil && client.Player != nil {
+		room.RemoveSpectator(client.Player.ID)
+	}
+	h.moveClient(client, "", false)
+}
+
+// joinErrorCode maps a room join error to the error code sent to the client
+func joinErrorCode(err error) string {
+	switch {
+	case errors.Is(err, room.ErrPlayerBanned):
+		return "BANNED"
+	case errors.Is(err, room.ErrGameStarted):
+		return "GAME_ALREADY_STARTED"
+	case errors.Is(err, room.ErrRoomFull):
+		return "ROOM_FULL"
+	case errors.Is(err, room.ErrAlreadyInRoom):
+		return "ALREADY_IN_ROOM"
+	case errors.Is(err, room.ErrRoomNotFound):
+		return "ROOM_NOT_FOUND"
+	case errors.Is(err, room.ErrNoFreeColor):
+		return "NO_FREE_COLOR"
+	case errors.Is(err, room.ErrIncorrectPassword):
+		return "INCORRECT_PASSWORD"
+	default:
+		return "JOIN_FAILED"
+	}
+}
+
+// handleLeaveRoom handles leaving a room
+func (h *Hub) handleLeaveRoom(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		h.stopSpectating(client)
+		h.handleListRooms(client, msg)
+		return
+	}
+	
+	roomID := client.RoomID
+	
+	// Leaving a running game forfeits it
+	if room, _ := h.roomManager.FindActiveGame(client.Player.ID); room != nil && room.ID == roomID {
+		h.moveClient(client, "", false)
+		if err := h.forfeitPlayer(roomID, client.Player.ID); err != nil {
+			client.SendError(msg, "LEAVE_FAILED", err.Error())
+			return
+		}
+		h.handleListRooms(client, msg)
+		return
+	}
+	
+	err := h.roomManager.LeaveRoom(roomID, client.Player.ID)
+	if err != nil {
+		client.SendError(msg, "LEAVE_FAILED", err.Error())
+		return
+	}
+	
+	h.moveClient(client, "", false)
+	
+	h.broadcastPlayerEvent(MessagePlayerLeft, roomID, client.Player)
+	
+	// Send updated room list to client
+	h.handleListRooms(client, msg)
+}
+
+// forfeitPlayer takes a player out of a room's running game and lets the
+// remaining players know. The game ends if too few players are left.
+func (h *Hub) forfeitPlayer(roomID, playerID string) error {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return err
+	}
+	leaving := room.GetPlayer(playerID)
+	
+	turnPassed, err := h.roomManager.ForfeitRoom(roomID, playerID)
+	if err != nil {
+		return err
+	}
+	
+	h.logger.Infof("Player %s forfeited the game in room %s", playerID, roomID)
+	
+	h.broadcastPlayerEvent(MessagePlayerLeft, roomID, leaving)
+	h.broadcastGameState(roomID)
+	if turnPassed && room.IsInProgress() {
+		h.sendTurnStart(roomID)
+	}
+	return nil
+}
+
+// handleAddBot handles adding a bot to a room
+func (h *Hub) handleAddBot(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	var data AddBotData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid add bot data")
+		return
+	}
+	
+	err := h.roomManager.AddBot(client.RoomID, data.BotName, data.Difficulty, client.Player.ID)
+	if err != nil {
+		code := "ADD_BOT_FAILED"
+		if errors.Is(err, room.ErrInvalidDifficulty) {
+			code = "INVALID_DIFFICULTY"
+		}
+		client.SendError(msg, code, err.Error())
+		return
+	}
+	
+	// Broadcast room state
+	h.broadcastRoomState(client.RoomID)
+}
+
+// handleSetReady handles a player marking themselves ready to start, or not
+func (h *Hub) handleSetReady(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "Spectators have no seat to get ready")
+		return
+	}
+	
+	var data ReadyData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid ready data")
+		return
+	}
+	
+	err := h.roomManager.SetReady(client.RoomID, client.Player.ID, data.Ready)
+	if err != nil {
+		code := "SET_READY_FAILED"
+		switch {
+		case errors.Is(err, room.ErrGameStarted):
+			code = "GAME_ALREADY_STARTED"
+		case errors.Is(err, room.ErrRoomNotFound):
+			code = "ROOM_NOT_FOUND"
+		}
+		client.SendError(msg, code, err.Error())
+		return
+	}
+	
+	h.broadcastRoomState(client.RoomID)
+}
+
+// handleStartGame handles the room creator starting the game
+func (h *Hub) handleStartGame(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "Spectators cannot start the game")
+		return
+	}
+	
+	if h.IsDraining() {
+		client.SendError(msg, "SERVER_DRAINING", "Server is shutting down, no new games")
+		return
+	}
+	
+	if err := h.StartGame(client.RoomID, client.Player.ID); err != nil {
+		client.SendError(msg, startErrorCode(err), err.Error())
+	}
+}
+
+// turnErrorCode maps an error of a turn action to its protocol error code,
+// or fallback for errors without a code of their own
+func turnErrorCode(err error, fallback string) string {
+	switch {
+	case errors.Is(err, room.ErrTilePlaced):
+		return "TILE_ALREADY_PLACED"
+	case errors.Is(err, game.ErrInvalidRotation):
+		return "INVALID_ROTATION"
+	case errors.Is(err, game.ErrNoTilePlaced):
+		return "NO_TILE_PLACED"
+	case errors.Is(err, game.ErrMeepleAlreadyPlaced):
+		return "MEEPLE_ALREADY_PLACED"
+	case errors.Is(err, game.ErrNoMeeplePlaced):
+		return "NO_MEEPLE_PLACED"
+	case errors.Is(err, game.ErrFeatureClaimed):
+		return "FEATURE_CLAIMED"
+	case errors.Is(err, game.ErrInvalidFeature):
+		return "INVALID_FEATURE"
+	default:
+		return fallback
+	}
+}
+
+// startErrorCode maps a game start error to its protocol error code
+func startErrorCode(err error) string {
+	switch {
+	case errors.Is(err, room.ErrNotRoomCreator):
+		return "NOT_ROOM_CREATOR"
+	case errors.Is(err, room.ErrNotEnoughPlayers):
+		return "NOT_ENOUGH_PLAYERS"
+	case errors.Is(err, room.ErrNoHumanPlayers):
+		return "NO_HUMAN_PLAYERS"
+	case errors.Is(err, room.ErrPlayersNotReady):
+		return "PLAYERS_NOT_READY"
+	case errors.Is(err, room.ErrGameStarted):
+		return "GAME_ALREADY_STARTED"
+	case errors.Is(err, room.ErrRoomNotFound):
+		return "ROOM_NOT_FOUND"
+	default:
+		return "START_FAILED"
+	}
+}
+
+// handleBanPlayer handles banning a player from a room
+func (h *Hub) handleBanPlayer(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	var data BanPlayerData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid ban player data")
+		return
+	}
+	
+	roomID := client.RoomID
+	var banned *game.Player
+	if room, err := h.roomManager.GetRoom(roomID); err == nil {
+		banned = room.GetPlayer(data.PlayerID)
+	}
+	
+	err := h.roomManager.BanPlayer(roomID, data.PlayerID, client.Player.ID)
+	if err != nil {
+		client.SendError(msg, "BAN_FAILED", err.Error())
+		return
+	}
+	
+	// Kick the banned player's client out of the room
+	kicked := h.findClients(func(c *Client) bool {
+		return c.RoomID == roomID && c.Player != nil && c.Player.ID == data.PlayerID
+	})
+	for _, c := range kicked {
+		h.moveClient(c, "", false)
+		c.SendError(nil, "BANNED", "You have been banned from the room")
+		h.handleListRooms(c, msg)
+	}
+	
+	h.broadcastPlayerEvent(MessagePlayerLeft, roomID, banned)
+}
+
+// handleSetName handles a player renaming themselves before the game starts
+func (h *Hub) handleSetName(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "Spectators have no seat to rename")
+		return
+	}
+	
+	var data SetNameData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid set name data")
+		return
+	}
+	
+	name, err := h.roomManager.SetPlayerName(client.RoomID, client.Player.ID, data.Name)
+	if err != nil {
+		code := "SET_NAME_FAILED"
+		switch {
+		case errors.Is(err, room.ErrGameStarted):
+			code = "GAME_ALREADY_STARTED"
+		case errors.Is(err, room.ErrRoomNotFound):
+			code = "ROOM_NOT_FOUND"
+		case errors.Is(err, room.ErrInvalidName):
+			code = "INVALID_NAME"
+		case errors.Is(err, room.ErrNameTaken):
+			code = "NAME_TAKEN"
+		}
+		client.SendError(msg, code, err.Error())
+		return
+	}
+	
+	// The new name sticks after leaving the room
+	h.clientsMutex.Lock()
+	client.name = name
+	h.clientsMutex.Unlock()
+	
+	h.broadcastRoomState(client.RoomID)
+}
+
+// handlePlaceTile handles tile placement
+func (h *Hub) handlePlaceTile(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "spectators cannot play")
+		return
+	}
+	
+	var data PlaceTileData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid place tile data")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if room.IsStaleCommand(msg.Timestamp) {
+		client.SendError(msg, "STALE_COMMAND", "Command is too old")
+		return
+	}
+	
+	// Games are held at the turn boundary while draining
+	if h.IsDraining() {
+		client.SendError(msg, "SERVER_DRAINING", "Server is shutting down, no new turns")
+		return
+	}
+	
+	err = room.PlaceTile(client.Player.ID, data.Position, data.Rotation)
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "PLACE_TILE_FAILED"), err.Error())
+		return
+	}
+	h.broadcastScoringEvents(room)
+	
+	// The meeple phase is skipped for players with nothing left to place.
+	// A turn that timed out meanwhile was already ended and announced.
+	if !room.CanPlaceMeeple(client.Player.ID) {
+		if err := room.EndTurn(client.Player.ID); err != nil {
+			return
+		}
+		h.broadcastTurnEnd(client.RoomID)
+		h.broadcastGameState(client.RoomID)
+		h.sendTurnStart(client.RoomID)
+		return
+	}
+	
+	// Broadcast game state
+	h.broadcastGameState(client.RoomID)
+	
+	// Tell the player where their meeple can go
+	featureIDs, pos, err := room.GetClaimableFeatures(client.Player.ID)
+	if err != nil {
+		return
+	}
+	optionsMsg, err := CreateMessage(MessageMeepleOptions, ClaimableFeaturesData{
+		Position:   pos,
+		FeatureIDs: featureIDs,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating meeple options message: %v", err)
+		return
+	}
+	client.SendMessage(optionsMsg)
+}
+
+// handlePlaceMeeple handles meeple placement
+func (h *Hub) handlePlaceMeeple(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "spectators cannot play")
+		return
+	}
+	
+	var data PlaceMeepleData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid place meeple data")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if room.IsStaleCommand(msg.Timestamp) {
+		client.SendError(msg, "STALE_COMMAND", "Command is too old")
+		return
+	}
+	
+	if data.Builder {
+		err = room.PlaceBuilder(client.Player.ID, data.FeatureID)
+	} else {
+		err = room.PlaceMeeple(client.Player.ID, data.FeatureID)
+	}
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "PLACE_MEEPLE_FAILED"), err.Error())
+		return
+	}
+	
+	// The player may still take the figure back before ending the turn
+	if data.KeepTurn {
+		h.broadcastGameState(client.RoomID)
+		return
+	}
+	
+	// End turn and broadcast state, unless it timed out meanwhile and was
+	// already ended and announced
+	if err := room.EndTurn(client.Player.ID); err != nil {
+		return
+	}
+	h.broadcastTurnEnd(client.RoomID)
+	h.broadcastGameState(client.RoomID)
+	h.sendTurnStart(client.RoomID)
+}
+
+// handleUndoMeeple takes back the figure placed this turn
+func (h *Hub) handleUndoMeeple(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "spectators cannot play")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if room.IsStaleCommand(msg.Timestamp) {
+		client.SendError(msg, "STALE_COMMAND", "Command is too old")
+		return
+	}
+	
+	err = room.UndoMeeple(client.Player.ID)
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "UNDO_MEEPLE_FAILED"), err.Error())
+		return
+	}
+	
+	h.broadcastGameState(client.RoomID)
+}
+
+// handleEndTurn ends a turn left open by PLACE_MEEPLE with keepTurn
+func (h *Hub) handleEndTurn(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "spectators cannot play")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if room.IsStaleCommand(msg.Timestamp) {
+		client.SendError(msg, "STALE_COMMAND", "Command is too old")
+		return
+	}
+	
+	err = room.EndTurn(client.Player.ID)
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "END_TURN_FAILED"), err.Error())
+		return
+	}
+	
+	h.broadcastTurnEnd(client.RoomID)
+	h.broadcastGameState(client.RoomID)
+	h.sendTurnStart(client.RoomID)
+}
+
+// handleSkipMeeple ends the turn after the tile was placed without placing
+// a figure
+func (h *Hub) handleSkipMeeple(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	if client.Spectator {
+		client.SendError(msg, "SPECTATOR", "spectators cannot play")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if room.IsStaleCommand(msg.Timestamp) {
+		client.SendError(msg, "STALE_COMMAND", "Command is too old")
+		return
+	}
+	
+	err = room.SkipMeeple(client.Player.ID)
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "SKIP_MEEPLE_FAILED"), err.Error())
+		return
+	}
+	
+	h.broadcastTurnEnd(client.RoomID)
+	h.broadcastGameState(client.RoomID)
+	h.sendTurnStart(client.RoomID)
+}
+
+// handleGetMeepleOptions replies with the meeple options for the tile placed this turn
+func (h *Hub) handleGetMeepleOptions(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	options, pos, err := room.GetMeepleOptions(client.Player.ID)
+	if err != nil {
+		client.SendError(msg, turnErrorCode(err, "MEEPLE_OPTIONS_FAILED"), err.Error())
+		return
+	}
+	
+	response, err := CreateMessage(MessageGetMeepleOptions, MeepleOptionsData{
+		Position: pos,
+		Options:  options,
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create meeple options")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
+// handleGetTile replies with the details of a single placed tile
+func (h *Hub) handleGetTile(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	var data GetTileData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid get tile data")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	tile, err := room.GetTile(data.Position)
+	if err != nil {
+		client.SendError(msg, "TILE_NOT_FOUND", err.Error())
+		return
+	}
+	
+	response, err := CreateMessage(MessageGetTile, TileData{
+		Tile: tile,
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create tile data")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
+// handleGetBoardGrid replies with the board as a dense grid
+func (h *Hub) handleGetBoardGrid(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	response, err := CreateMessage(MessageGetBoardGrid, BoardGridData{
+		Grid: room.GetBoardGrid(),
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create board grid")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
+// handleGetHistory sends the moves played so far in the client's room
+func (h *Hub) handleGetHistory(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	response, err := CreateMessage(MessageGetHistory, HistoryData{
+		Moves: room.GetMoveHistory(),
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create move history")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
+// handleGetGameState resends the current game state to a client that missed
+// broadcasts, along with the TURN_START if it is their turn
+func (h *Hub) handleGetGameState(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	stateMsg, err := NewGameStateMessage(room.GetGameState())
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create game state")
+		return
+	}
+	client.SendMessage(stateMsg)
+	
+	if !room.IsInProgress() || client.Spectator {
+		return
+	}
+	if currentPlayer := room.GetCurrentPlayer(); currentPlayer != nil && currentPlayer.ID == client.Player.ID {
+		h.sendCurrentTurn(client, room)
+	}
+}
+
+// handlePing handles ping messages for latency calculation
+func (h *Hub) handlePing(client *Client, msg *Message) {
+	var data PingData
+	if err := ParseMessage(msg, &data); err != nil {
+		client.SendError(msg, "INVALID_DATA", "Invalid ping data")
+		return
+	}
+	
+	// Create pong response with original timestamp
+	pongMsg, err := NewPongMessage(data.Timestamp, data.ClientID)
+	if err != nil {
+		h.logger.Errorf("Error creating pong message: %v", err)
+		return
+	}
+	
+	// Send pong response back to client
+	client.SendMessage(pongMsg)
+	
+	h.logger.Debugf("Ping/Pong: Client %s latency measurement", data.ClientID)
+}
+
+// handleGetRoomLatencies replies to the room creator with every player's latency
+func (h *Hub) handleGetRoomLatencies(client *Client, msg *Message) {
+	if client.RoomID == "" {
+		client.SendError(msg, "NOT_IN_ROOM", "Not in any room")
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(client.RoomID)
+	if err != nil {
+		client.SendError(msg, "ROOM_NOT_FOUND", "Room not found")
+		return
+	}
+	
+	if !room.IsCreator(client.Player.ID) {
+		client.SendError(msg, "NOT_CREATOR", "Only room creator can view latencies")
+		return
+	}
+	
+	latencies := make(map[string]float64)
+	h.clientsMutex.RLock()
+	for c := range h.clients {
+		if c.RoomID == room.ID && c.Player != nil {
+			latencies[c.Player.ID] = float64(c.GetLatency().Nanoseconds()) / 1e6
+		}
+	}
+	h.clientsMutex.RUnlock()
+	
+	response, err := CreateMessage(MessageGetRoomLatencies, RoomLatenciesData{
+		Latencies: latencies,
+	})
+	if err != nil {
+		client.SendError(msg, "INTERNAL_ERROR", "Failed to create room latencies")
+		return
+	}
+	
+	client.SendMessage(response)
+}
+
+// sendRoomState sends room state to a specific client
+func (h *Hub) sendRoomState(client *Client, room *room.Room) {
+	players := room.GetPlayers()
+	
+	msg, err := NewRoomStateMessage(room.ID, players, room.GetReadiness(), room.HasStarted(), room.HasEnded())
+	if err != nil {
+		h.logger.Errorf("Error creating room state message: %v", err)
+		return
+	}
+	
+	client.SendMessage(msg)
+}
+
+// broadcastRoomState broadcasts room state to all clients in a room
+func (h *Hub) broadcastRoomState(roomID string) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	
+	// Nobody is listening, don't bother building the message
+	if !h.hasClientsInRoom(roomID) {
+		return
+	}
+	
+	players := room.GetPlayers()
+	msg, err := NewRoomStateMessage(roomID, players, room.GetReadiness(), room.HasStarted(), room.HasEnded())
+	if err != nil {
+		h.logger.Errorf("Error creating room state message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoom(roomID, msg)
+}
+
+// broadcastPlayerEvent tells all clients in a room that a player joined or
+// left, without resending the whole room state
+func (h *Hub) broadcastPlayerEvent(msgType MessageType, roomID string, player *game.Player) {
+	h.broadcastPlayerEventExcept(msgType, roomID, player, "")
+}
+
+// broadcastPlayerEventExcept is broadcastPlayerEvent skipping the clients
+// of one player
+func (h *Hub) broadcastPlayerEventExcept(msgType MessageType, roomID string, player *game.Player, exceptID string) {
+	if player == nil {
+		return
+	}
+	
+	msg, err := CreateMessage(msgType, PlayerEventData{
+		RoomID: roomID,
+		Player: player,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating player event message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoomExcept(roomID, exceptID, msg)
+}
+
+// broadcastGameState broadcasts game state to all clients in a room
+func (h *Hub) broadcastGameState(roomID string) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	
+	// Nobody is listening, don't bother building the message
+	if !h.hasClientsInRoom(roomID) {
+		return
+	}
+	
+	gameState := room.GetGameState()
+	msg, err := NewGameStateMessage(gameState)
+	if err != nil {
+		h.logger.Errorf("Error creating game state message: %v", err)
+		return
+	}
+	
+	// Clients that asked for deltas get only what changed since the last
+	// broadcast, everyone else the full state
+	deltaMsg, err := NewGameStateDeltaMessage(room.TakeGameStateDelta())
+	if err != nil {
+		h.logger.Errorf("Error creating game state delta message: %v", err)
+		deltaMsg = msg
+	}
+	
+	h.broadcastToRoomSplit(roomID, msg, deltaMsg)
+	
+	if room.TakeGameEnd() {
+		h.broadcastGameEnd(roomID)
+	}
+}
+
+// broadcastGameEnd announces the winners and final scores to a room
+func (h *Hub) broadcastGameEnd(roomID string) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	
+	winners := room.GetWinners()
+	gameState := room.GetGameState()
+	msg, err := NewGameEndMessage(winners, gameState.Scores, &gameState)
+	if err != nil {
+		h.logger.Errorf("Error creating game end message: %v", err)
+		return
+	}
+	
+	// Clients that asked for deltas got the final state with the last delta
+	deltaMsg, err := NewGameEndMessage(winners, gameState.Scores, nil)
+	if err != nil {
+		h.logger.Errorf("Error creating game end message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoomSplit(roomID, msg, deltaMsg)
+}
+
+// broadcastTurnEnd sends the summary of the turn that just finished to all
+// clients in a room
+func (h *Hub) broadcastTurnEnd(roomID string) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	
+	// Nobody is listening, don't bother building the message
+	if !h.hasClientsInRoom(roomID) {
+		return
+	}
+	
+	turn := room.LastTurn()
+	gameState := room.GetGameState()
+	msg, err := NewTurnEndMessage(turn.PlayerID, turn.ScoreChange, turn.NextPlayer, &gameState)
+	if err != nil {
+		h.logger.Errorf("Error creating turn end message: %v", err)
+		return
+	}
 	
-	msg, err := NewTurnStartMessage(currentPlayer.ID, gameState.CurrentTile, validPlacements)
+	// Clients that asked for deltas get the state from the delta that follows
+	deltaMsg, err := NewTurnEndMessage(turn.PlayerID, turn.ScoreChange, turn.NextPlayer, nil)
 	if err != nil {
-		log.Printf("Error creating turn start message: %v", err)
+		h.logger.Errorf("Error creating turn end message: %v", err)
 		return
 	}
 	
-	h.broadcastToRoom(roomID, msg)
+	h.broadcastToRoomSplit(roomID, msg, deltaMsg)
+}
+
+// sendTurnStart sends turn start message to all clients in a room, after
+// announcing the features scored last turn, any tiles discarded while
+// drawing the turn's tile and the tile drawn. Once the game ended only the
+// features scored are announced, no turn starts.
+func (h *Hub) sendTurnStart(roomID string) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return
+	}
+	
+	h.broadcastScoringEvents(room)
+	
+	// The last turn was played, GAME_END went out instead
+	if room.HasEnded() {
+		return
+	}
+	
+	h.broadcastDiscardedTiles(room)
+	h.broadcastDrawnTile(room)
+	
+	// Bots move whether or not anybody is listening
+	h.scheduleBotTurn(room)
+	
+	// Nobody is listening, don't bother building the message
+	if !h.hasClientsInRoom(roomID) {
+		return
+	}
+	
+	currentPlayer := room.GetCurrentPlayer()
+	if currentPlayer == nil {
+		return
+	}
+	
+	gameState := room.GetGameState()
+	validPlacements := room.GetValidPlacements()
+	validMoves := room.GetValidMovesDetailed()
+	
+	canPlaceMeeple := room.CanPlaceMeeple(currentPlayer.ID)
+	
+	timeBanks := room.GetTimeBanks()
+	
+	msg, err := NewTurnStartMessage(currentPlayer.ID, gameState.CurrentTile, validPlacements, validMoves, timeBanks, canPlaceMeeple)
+	if err != nil {
+		h.logger.Errorf("Error creating turn start message: %v", err)
+		return
+	}
+	
+	if !room.Options.HidePlacements {
+		h.broadcastToRoom(roomID, msg)
+		return
+	}
+	
+	// Only the current player gets to see where the tile fits
+	publicMsg, err := NewTurnStartMessage(currentPlayer.ID, gameState.CurrentTile, nil, nil, timeBanks, canPlaceMeeple)
+	if err != nil {
+		h.logger.Errorf("Error creating turn start message: %v", err)
+		return
+	}
+	
+	h.sendToPlayer(roomID, currentPlayer.ID, msg)
+	h.broadcastToRoomExcept(roomID, currentPlayer.ID, publicMsg)
+}
+
+// sendToPlayer sends a message to the clients of a player seated in a room
+func (h *Hub) sendToPlayer(roomID, playerID string, msg *Message) {
+	clients := h.findClients(func(c *Client) bool {
+		return c.RoomID == roomID && !c.Spectator && c.Player != nil && c.Player.ID == playerID
+	})
+	for _, client := range clients {
+		client.SendMessage(msg)
+	}
+}
+
+// broadcastToRoomExcept broadcasts a message to every client in a room but
+// the given player's, spectators included
+func (h *Hub) broadcastToRoomExcept(roomID, playerID string, msg *Message) {
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Errorf("Error marshaling broadcast message: %v", err)
+		return
+	}
+	
+	clients := h.findClients(func(c *Client) bool {
+		return c.RoomID == roomID && (c.Spectator || c.Player == nil || c.Player.ID != playerID)
+	})
+	for _, client := range clients {
+		client.sendBytes(messageBytes)
+	}
+}
+
+// broadcastToRoomSplit broadcasts deltaMsg to the clients in a room that
+// asked for state deltas and msg to the others
+func (h *Hub) broadcastToRoomSplit(roomID string, msg, deltaMsg *Message) {
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Errorf("Error marshaling broadcast message: %v", err)
+		return
+	}
+	deltaBytes, err := json.Marshal(deltaMsg)
+	if err != nil {
+		h.logger.Errorf("Error marshaling broadcast message: %v", err)
+		return
+	}
+	
+	for _, client := range h.clientsInRoom(roomID) {
+		if client.stateDeltas {
+			client.sendBytes(deltaBytes)
+		} else {
+			client.sendBytes(messageBytes)
+		}
+	}
 }
 
 // broadcastToRoom broadcasts a message to all clients in a specific room
 func (h *Hub) broadcastToRoom(roomID string, msg *Message) {
+	// Marshal once and share the bytes between all recipients
+	messageBytes, err := json.Marshal(msg)
+	if err != nil {
+		h.logger.Errorf("Error marshaling broadcast message: %v", err)
+		return
+	}
+	
+	for _, client := range h.clientsInRoom(roomID) {
+		client.sendBytes(messageBytes)
+	}
+}
+
+// hasClientsInRoom checks if any connected client is in the given room
+func (h *Hub) hasClientsInRoom(roomID string) bool {
+	h.clientsMutex.RLock()
+	defer h.clientsMutex.RUnlock()
+	
 	for client := range h.clients {
 		if client.RoomID == roomID {
-			client.SendMessage(msg)
+			return true
+		}
+	}
+	return false
+}
+
+// clientsInRoom returns the clients in a room, spectators included
+func (h *Hub) clientsInRoom(roomID string) []*Client {
+	return h.findClients(func(c *Client) bool {
+		return c.RoomID == roomID
+	})
+}
+
+// findClients returns the registered clients matching the filter. The filter
+// runs with the clients lock held, messages are sent after it is released.
+func (h *Hub) findClients(match func(c *Client) bool) []*Client {
+	h.clientsMutex.RLock()
+	defer h.clientsMutex.RUnlock()
+	
+	var clients []*Client
+	for client := range h.clients {
+		if match(client) {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// moveClient puts a client in a room, or out of any room for an empty
+// roomID. The room is read by other goroutines through findClients, so it is
+// changed with the clients lock held.
+func (h *Hub) moveClient(client *Client, roomID string, spectator bool) {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	
+	client.RoomID = roomID
+	client.Spectator = spectator
+}
+
+// setClientPlayer sets the player a client plays as, with the clients lock
+// held like moveClient
+func (h *Hub) setClientPlayer(client *Client, player *game.Player) {
+	h.clientsMutex.Lock()
+	defer h.clientsMutex.Unlock()
+	
+	client.Player = player
+}
+
+// handlePlayerFlagged notifies a room that a player ran out of time and
+// their turn was played automatically
+func (h *Hub) handlePlayerFlagged(roomID, playerID string) {
+	msg, err := CreateMessage(MessagePlayerFlagged, PlayerFlaggedData{
+		PlayerID: playerID,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating player flagged message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoom(roomID, msg)
+	h.broadcastTurnEnd(roomID)
+	h.broadcastGameState(roomID)
+	h.sendTurnStart(roomID)
+}
+
+// handleTurnTimeout notifies a room that a player's turn timed out and was
+// played automatically
+func (h *Hub) handleTurnTimeout(roomID, playerID string) {
+	msg, err := CreateMessage(MessageTurnTimeout, TurnTimeoutData{
+		PlayerID: playerID,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating turn timeout message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoom(roomID, msg)
+	h.broadcastTurnEnd(roomID)
+	h.broadcastGameState(roomID)
+	h.sendTurnStart(roomID)
+}
+
+// broadcastScoringEvents tells a room about the features scored during the
+// turn that weren't announced yet
+func (h *Hub) broadcastScoringEvents(room *room.Room) {
+	for _, event := range room.TakeScoringEvents() {
+		msg, err := CreateMessage(MessageScoringEvent, ScoringEventData{ScoringEvent: event})
+		if err != nil {
+			h.logger.Errorf("Error creating scoring event message: %v", err)
+			return
+		}
+		
+		h.broadcastToRoom(room.ID, msg)
+	}
+}
+
+// broadcastDrawnTile tells a room about the tile drawn for the new turn,
+// unless it was already announced
+func (h *Hub) broadcastDrawnTile(room *room.Room) {
+	tile, tilesLeft := room.TakeDrawnTile()
+	if tile == nil {
+		return
+	}
+	
+	msg, err := CreateMessage(MessageDrawTile, DrawTileData{
+		Tile:      tile,
+		TilesLeft: tilesLeft,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating draw tile message: %v", err)
+		return
+	}
+	
+	h.broadcastToRoom(room.ID, msg)
+}
+
+// broadcastDiscardedTiles tells a room about tiles that fit nowhere and went
+// back under the deck
+func (h *Hub) broadcastDiscardedTiles(room *room.Room) {
+	discarded := room.TakeDiscardedTiles()
+	if len(discarded) == 0 {
+		return
+	}
+	
+	tilesLeft := room.GetGameState().TilesLeft
+	for _, tile := range discarded {
+		msg, err := CreateMessage(MessageTileDiscarded, TileDiscardedData{
+			Tile:      tile,
+			TilesLeft: tilesLeft,
+		})
+		if err != nil {
+			h.logger.Errorf("Error creating tile discarded message: %v", err)
+			return
 		}
+		
+		h.broadcastToRoom(room.ID, msg)
+	}
+}
+
+// broadcastGameError notifies a room that its game can't continue normally
+func (h *Hub) broadcastGameError(roomID, code, message string) {
+	msg, err := CreateMessage(MessageGameError, GameErrorData{
+		RoomID:  roomID,
+		Code:    code,
+		Message: message,
+	})
+	if err != nil {
+		h.logger.Errorf("Error creating game error message: %v", err)
+		return
 	}
+	
+	h.broadcastToRoom(roomID, msg)
 }
 
-// processBotMoves processes bot moves periodically
+// processBotMoves plays bot turns as they are scheduled and periodically
+// sweeps the rooms for abandoned seats and bot turns that were missed
 func (h *Hub) processBotMoves() {
-	for range h.botTicker.C {
-		rooms := h.roomManager.ListRooms()
+	for {
+		select {
+		case <-h.done:
+			return
+		case turn := <-h.botTurns:
+			h.playBotTurn(turn)
+		case <-h.botTicker.C:
+			h.sweepBotRooms()
+		}
+	}
+}
+
+// sweepBotRooms forfeits seats left empty past the grace period and
+// schedules the bot turns of rooms that are waiting on a bot
+func (h *Hub) sweepBotRooms() {
+	if h.IsDraining() {
+		return
+	}
+	
+	for _, roomInfo := range h.roomManager.ListRooms() {
+		if !roomInfo.GameStarted {
+			continue
+		}
 		
-		for _, roomInfo := range rooms {
-			if !roomInfo.GameStarted {
-				continue
-			}
-			
-			room, err := h.roomManager.GetRoom(roomInfo.ID)
-			if err != nil {
-				continue
-			}
-			
-			if room.IsCurrentPlayerBot() {
-				move, err := room.ProcessBotTurn()
-				if err != nil {
-					log.Printf("Error processing bot turn: %v", err)
-					continue
-				}
-				
-				// Broadcast the bot's move
-				room.NextTurn()
-				h.broadcastGameState(room.ID)
-				h.sendTurnStart(room.ID)
-				
-				log.Printf("Bot made move: %+v", move)
-			}
+		room, err := h.roomManager.GetRoom(roomInfo.ID)
+		if err != nil {
+			continue
+		}
+		
+		// Rooms nobody is watching either keep playing silently or wait
+		if room.Options.PauseWhenEmpty && !h.hasClientsInRoom(room.ID) {
+			continue
+		}
+		
+		for _, playerID := range room.GetAbandonedPlayers() {
+			h.forfeitPlayer(room.ID, playerID)
+		}
+		
+		// A turn that is already scheduled is only played once
+		h.scheduleBotTurn(room)
+	}
+}
+
+// scheduleBotTurn queues the current turn of a room to be played once the
+// bot to move is done thinking. It does nothing if no bot is to move.
+func (h *Hub) scheduleBotTurn(room *room.Room) {
+	difficulty, turn, ok := room.BotToMove()
+	if !ok {
+		return
+	}
+	
+	delay, ok := h.botDelays[difficulty]
+	if !ok {
+		delay = defaultBotThinkingDelays["medium"]
+	}
+	
+	next := botTurn{roomID: room.ID, turn: turn}
+	time.AfterFunc(delay, func() {
+		select {
+		case h.botTurns <- next:
+		case <-h.done:
+		}
+	})
+}
+
+// playBotTurn makes the bot move if the turn is still waiting on it
+func (h *Hub) playBotTurn(next botTurn) {
+	if h.IsDraining() {
+		return
+	}
+	
+	room, err := h.roomManager.GetRoom(next.roomID)
+	if err != nil {
+		return
+	}
+	
+	// Rooms nobody is watching either keep playing silently or wait
+	if room.Options.PauseWhenEmpty && !h.hasClientsInRoom(room.ID) {
+		return
+	}
+	
+	if room.BotsErrored() {
+		return
+	}
+	
+	// The turn was already played or skipped
+	if _, turn, ok := room.BotToMove(); !ok || turn != next.turn {
+		return
+	}
+	
+	move, err := room.ProcessBotTurn()
+	if err != nil {
+		h.logger.Errorf("Error processing bot turn: %v", err)
+		if room.BotsErrored() {
+			h.broadcastGameError(room.ID, "BOT_FAILED", "Bot keeps failing to move, bot turns are paused")
+		} else {
+			// Give it another go rather than waiting for the sweep
+			h.scheduleBotTurn(room)
+		}
+		return
+	}
+	
+	// Broadcast the bot's move
+	h.broadcastTurnEnd(room.ID)
+	h.broadcastGameState(room.ID)
+	h.sendTurnStart(room.ID)
+	
+	h.logger.Debugf("Bot made move: %+v", move)
+}
+
+// Healthy reports whether Run is running and went around its loop recently.
+// A hub whose Run stopped or is stuck stops being healthy.
+func (h *Hub) Healthy() bool {
+	select {
+	case <-h.done:
+		return false
+	default:
+	}
+	
+	last := h.heartbeat.Load()
+	return last != 0 && time.Since(time.Unix(0, last)) < heartbeatTimeout
+}
+
+// IsDraining reports whether the hub stopped accepting new connections and rooms
+func (h *Hub) IsDraining() bool {
+	return h.draining.Load()
+}
+
+// Drain stops accepting new connections, rooms and turns, then waits until
+// every running game is between turns so it can be stopped without losing a
+// half-played turn. It returns false if the timeout expired first.
+func (h *Hub) Drain(timeout time.Duration) bool {
+	h.draining.Store(true)
+	
+	deadline := time.Now().Add(timeout)
+	for {
+		if h.gamesAtTurnBoundary() {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// gamesAtTurnBoundary checks if no game is in the middle of a turn
+func (h *Hub) gamesAtTurnBoundary() bool {
+	for _, roomInfo := range h.roomManager.ListRooms() {
+		room, err := h.roomManager.GetRoom(roomInfo.ID)
+		if err != nil {
+			continue
+		}
+		if !room.AtTurnBoundary() {
+			return false
+		}
+	}
+	return true
+}
+
+// ListActiveRooms returns the rooms whose game hasn't ended, newest first
+func (h *Hub) ListActiveRooms() []room.RoomInfo {
+	return h.roomManager.GetLiveRooms()
+}
+
+// RoomSnapshot is the state of a single room as served over HTTP
+type RoomSnapshot struct {
+	Room      room.RoomInfo   `json:"room"`
+	Players   []*game.Player  `json:"players"`
+	GameState *game.GameState `json:"gameState,omitempty"` // nil until the game started
+}
+
+// GetRoomSnapshot returns a room's info and players, along with its game
+// state once the game started. Private rooms need their password.
+func (h *Hub) GetRoomSnapshot(roomID, password string) (RoomSnapshot, error) {
+	room, err := h.roomManager.GetRoom(roomID)
+	if err != nil {
+		return RoomSnapshot{}, err
+	}
+	if err := room.CheckPassword(password); err != nil {
+		return RoomSnapshot{}, err
+	}
+	
+	snapshot := RoomSnapshot{
+		Room:    room.GetRoomInfo(),
+		Players: room.GetPlayers(),
+	}
+	if snapshot.Room.GameStarted {
+		state := room.GetGameState()
+		snapshot.GameState = &state
+	}
+	return snapshot, nil
+}
+
+// GetMetrics returns the current server metrics. Every count is read
+// without touching the game boards, so it is cheap to call often.
+func (h *Hub) GetMetrics() Metrics {
+	started, ended := h.roomManager.GetGameCounts()
+	return Metrics{
+		ConnectedClients: int(h.clientCount.Load()),
+		ActiveRooms:      h.roomManager.GetRoomCount(),
+		TotalPlayers:     h.roomManager.GetTotalPlayers(),
+		GamesInProgress:  h.roomManager.GetGamesInProgress(),
+		GamesStarted:     started,
+		GamesCompleted:   ended,
+	}
+}
+
+// ClientStats describes a connected client for debugging
+type ClientStats struct {
+	ClientID   string                 `json:"clientId"`
+	RoomID     string                 `json:"roomId,omitempty"`
+	PlayerID   string                 `json:"playerId,omitempty"`
+	PlayerName string                 `json:"playerName,omitempty"`
+	Spectator  bool                   `json:"spectator,omitempty"`
+	Latency    map[string]interface{} `json:"latency"`
+}
+
+// ClientStats returns the connected clients with their latency, ordered by
+// client ID
+func (h *Hub) ClientStats() []ClientStats {
+	h.clientsMutex.RLock()
+	defer h.clientsMutex.RUnlock()
+	
+	stats := make([]ClientStats, 0, len(h.clients))
+	for client := range h.clients {
+		entry := ClientStats{
+			ClientID:  client.GetClientID(),
+			RoomID:    client.RoomID,
+			Spectator: client.Spectator,
+			Latency:   client.GetLatencyStats(),
 		}
+		// Player.Name belongs to the room, the name the client connected
+		// with can be read safely
+		if client.Player != nil {
+			entry.PlayerID = client.Player.ID
+			entry.PlayerName = client.name
+		}
+		stats = append(stats, entry)
 	}
+	
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].ClientID < stats[j].ClientID
+	})
+	return stats
+}
+
+// GetPlayerGames returns a page of a player's finished games, newest first,
+// and the total number of games kept for that player
+func (h *Hub) GetPlayerGames(playerID string, offset, limit int) ([]room.GameSummary, int) {
+	return h.roomManager.GetPlayerGames(playerID, offset, limit)
 }
 
 // StartGame starts a game in a room
@@ -466,8 +2316,9 @@ func (h *Hub) StartGame(roomID, playerID string) error {
 	players := room.GetPlayers()
 	
 	msg, err := CreateMessage(MessageGameStart, GameStartData{
-		RoomID:  roomID,
-		Players: players,
+		RoomID:    roomID,
+		Players:   players,
+		TurnOrder: room.GetGameState().TurnOrder,
 	})
 	if err != nil {
 		return err
@@ -0,0 +1,957 @@
+package websocket
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"carcassonne-ws/internal/game"
+	"carcassonne-ws/internal/room"
+	"github.com/gorilla/websocket"
+)
+
+// playerNames returns the names in a room state by player ID
+func playerNames(state RoomStateData) map[string]string {
+	names := make(map[string]string, len(state.Players))
+	for _, p := range state.Players {
+		names[p.ID] = p.Name
+	}
+	return names
+}
+
+func TestSetName(t *testing.T) {
+	_, url := newTestHub(t)
+
+	alice := connect(t, url, "alice", "Alice")
+	roomID := alice.createRoom(CreateRoomData{RoomName: "rename", MaxPlayers: 4})
+	bob := connect(t, url, "bob", "Bob")
+	bob.joinRoom(roomID)
+
+	alice.send(MessageSetName, SetNameData{Name: "  Alicia  "})
+	for _, c := range []*testClient{alice, bob} {
+		var state RoomStateData
+		c.expectData(MessageRoomState, &state)
+		if names := playerNames(state); names["alice"] != "Alicia" || names["bob"] != "Bob" {
+			t.Fatalf("room state names = %v, want alice renamed to Alicia", names)
+		}
+	}
+
+	bob.send(MessageSetName, SetNameData{Name: "Alicia"})
+	bob.expectError("NAME_TAKEN")
+	bob.send(MessageSetName, SetNameData{Name: "   "})
+	bob.expectError("INVALID_NAME")
+
+	alice.send(MessageSetReady, ReadyData{Ready: true})
+	bob.send(MessageSetReady, ReadyData{Ready: true})
+	alice.send(MessageStartGame, nil)
+	alice.expect(MessageGameStart)
+
+	alice.send(MessageSetName, SetNameData{Name: "Al"})
+	alice.expectError("GAME_ALREADY_STARTED")
+}
+
+func TestNoTurnStartAfterGameEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(alice *localClient, featureID int)
+	}{
+		{
+			name: "skip meeple",
+			finish: func(alice *localClient, featureID int) {
+				alice.send(MessageSkipMeeple, nil)
+			},
+		},
+		{
+			name: "place meeple",
+			finish: func(alice *localClient, featureID int) {
+				alice.send(MessagePlaceMeeple, PlaceMeepleData{FeatureID: featureID})
+			},
+		},
+		{
+			name: "end turn",
+			finish: func(alice *localClient, featureID int) {
+				alice.send(MessagePlaceMeeple, PlaceMeepleData{FeatureID: featureID, KeepTurn: true})
+				alice.send(MessageEndTurn, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newLocalHub(t)
+			alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+			room, _ := hub.roomManager.GetRoom(roomID)
+
+			// Alice places the last tile
+			room.Board.TileDeck = nil
+			placement := room.GetValidPlacements()[0]
+			alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+			featureIDs, _, err := room.GetClaimableFeatures("alice")
+			if err != nil || len(featureIDs) == 0 {
+				t.Fatalf("GetClaimableFeatures = %v, %v", featureIDs, err)
+			}
+			tt.finish(alice, featureIDs[0])
+
+			msgs := types(bob.messages())
+			end := -1
+			for i, msgType := range msgs {
+				if msgType == MessageGameEnd {
+					end = i
+				}
+			}
+			if end < 0 {
+				t.Fatalf("messages = %v, want GAME_END", msgs)
+			}
+			for _, msgType := range msgs[end:] {
+				switch msgType {
+				case MessageTurnStart, MessageDrawTile, MessageTileDiscarded:
+					t.Fatalf("messages = %v, want no %s after GAME_END", msgs, msgType)
+				}
+			}
+		})
+	}
+}
+
+// TestBotGameWithSpectator plays a bots-only game to the end while a
+// spectator keeps asking for the game state, for the race detector to check
+// the hub and rooms share state safely
+func TestBotGameWithSpectator(t *testing.T) {
+	hub, url := newTestHub(t)
+
+	alice := connect(t, url, "alice", "Alice")
+	noTimeout := 0
+	roomID := alice.createRoom(CreateRoomData{RoomName: "bots", MaxPlayers: 4, AllowAllBots: true, TurnTimeout: &noTimeout})
+	for _, name := range []string{"Bot 1", "Bot 2"} {
+		alice.send(MessageAddBot, AddBotData{BotName: name, Difficulty: "medium"})
+		alice.expect(MessageRoomState)
+	}
+	alice.send(MessageLeaveRoom, LeaveRoomData{RoomID: roomID})
+	alice.expect(MessageListRooms)
+
+	carol := connect(t, url, "carol", "Carol")
+	carol.send(MessageSpectateRoom, SpectateRoomData{RoomID: roomID})
+	carol.expect(MessageRoomState)
+
+	if err := hub.StartGame(roomID, "alice"); err != nil {
+		t.Fatalf("StartGame: %v", err)
+	}
+
+	// Carol asks for the state while the bots play, and reads everything
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(testBotDelay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				msg, _ := CreateMessage(MessageGetGameState, nil)
+				if carol.conn.WriteJSON(msg) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	turns := 0
+	for {
+		msg, err := carol.read()
+		if err != nil {
+			t.Fatalf("waiting for GAME_END after %d turns: %v", turns, err)
+		}
+		if msg.Type == MessageTurnEnd {
+			turns++
+		}
+		if msg.Type == MessageGameEnd {
+			break
+		}
+	}
+	if turns == 0 {
+		t.Fatalf("game ended without any turn")
+	}
+}
+
+// claimableField returns a field of the tile alice placed this turn that a
+// meeple can go on. Fields only score at the end of the game, so the meeple
+// stays on the board.
+func claimableField(t *testing.T, room *room.Room) int {
+	t.Helper()
+
+	options, _, err := room.GetMeepleOptions("alice")
+	if err != nil {
+		t.Fatalf("GetMeepleOptions: %v", err)
+	}
+	for _, option := range options {
+		if option.Claimable && option.Type == game.FieldFeature {
+			return option.FeatureID
+		}
+	}
+	t.Skip("the placed tile has no claimable field")
+	return -1
+}
+
+func TestStaleCommands(t *testing.T) {
+	// placeTile places the current tile for alice, placeMeeple then keeps
+	// the turn open with a meeple on it
+	placeTile := func(t *testing.T, alice *localClient, room *room.Room) {
+		placement := room.GetValidPlacements()[0]
+		alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	}
+	placeMeeple := func(t *testing.T, alice *localClient, room *room.Room) {
+		placeTile(t, alice, room)
+		alice.send(MessagePlaceMeeple, PlaceMeepleData{FeatureID: claimableField(t, room), KeepTurn: true})
+	}
+
+	tests := []struct {
+		msgType MessageType
+		setup   func(t *testing.T, alice *localClient, room *room.Room)
+		data    func(room *room.Room) interface{}
+	}{
+		{
+			msgType: MessagePlaceTile,
+			setup:   func(t *testing.T, alice *localClient, room *room.Room) {},
+			data: func(room *room.Room) interface{} {
+				placement := room.GetValidPlacements()[0]
+				return PlaceTileData{Position: placement.Position, Rotation: placement.Rotation}
+			},
+		},
+		{
+			msgType: MessagePlaceMeeple,
+			setup:   placeTile,
+			data: func(room *room.Room) interface{} {
+				featureIDs, _, _ := room.GetClaimableFeatures("alice")
+				return PlaceMeepleData{FeatureID: featureIDs[0]}
+			},
+		},
+		{msgType: MessageSkipMeeple, setup: placeTile},
+		{msgType: MessageUndoMeeple, setup: placeMeeple},
+		{msgType: MessageEndTurn, setup: placeMeeple},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.msgType), func(t *testing.T) {
+			hub := newLocalHub(t)
+			alice, _, roomID := startLocalGame(t, hub, CreateRoomData{CommandMaxAge: 60})
+			room, _ := hub.roomManager.GetRoom(roomID)
+			tt.setup(t, alice, room)
+			if errs := alice.errors(); len(errs) > 0 {
+				t.Fatalf("setup errors: %v", errs)
+			}
+
+			var data interface{}
+			if tt.data != nil {
+				data = tt.data(room)
+			}
+			version := room.GetGameState().Version
+
+			stale := alice.sendAt(tt.msgType, data, time.Now().Add(-2*time.Minute))
+			msgs := alice.messages()
+			if len(msgs) != 1 || msgs[0].Type != MessageError || msgs[0].ReplyTo != stale.MessageID {
+				t.Fatalf("reply to stale %s = %v, want one ERROR", tt.msgType, types(msgs))
+			}
+			var errData ErrorData
+			ParseMessage(msgs[0], &errData)
+			if errData.Code != "STALE_COMMAND" {
+				t.Fatalf("error code = %q, want STALE_COMMAND", errData.Code)
+			}
+			if got := room.GetGameState().Version; got != version {
+				t.Fatalf("stale %s changed the game, version %d -> %d", tt.msgType, version, got)
+			}
+
+			alice.sendAt(tt.msgType, data, time.Now())
+			if errs := alice.errors(); len(errs) > 0 {
+				t.Fatalf("fresh %s: %v", tt.msgType, errs)
+			}
+		})
+	}
+}
+
+// errorCodes returns the codes of the errors, in order
+func errorCodes(errs []ErrorData) []string {
+	codes := make([]string, len(errs))
+	for i, err := range errs {
+		codes[i] = err.Code
+	}
+	return codes
+}
+
+func TestBanPlayer(t *testing.T) {
+	hub := newLocalHub(t)
+	alice := newLocalClient(t, hub, "alice", "Alice")
+	bob := newLocalClient(t, hub, "bob", "Bob")
+	carol := newLocalClient(t, hub, "carol", "Carol")
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "bans", MaxPlayers: 4})
+	roomID := alice.client.RoomID
+	bob.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	carol.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	alice.messages()
+	bob.messages()
+	carol.messages()
+
+	// Only the creator bans
+	carol.send(MessageBanPlayer, BanPlayerData{PlayerID: "bob"})
+	if codes := errorCodes(carol.errors()); len(codes) != 1 || codes[0] != "BAN_FAILED" {
+		t.Fatalf("ban by carol: errors %v, want BAN_FAILED", codes)
+	}
+
+	alice.send(MessageBanPlayer, BanPlayerData{PlayerID: "bob"})
+	if codes := errorCodes(bob.errors()); len(codes) != 1 || codes[0] != "BANNED" {
+		t.Fatalf("banned bob got errors %v, want BANNED", codes)
+	}
+	if bob.client.RoomID != "" {
+		t.Fatalf("banned bob is still in room %q", bob.client.RoomID)
+	}
+	room, _ := hub.roomManager.GetRoom(roomID)
+	if room.GetPlayer("bob") != nil || !room.IsBanned("bob") {
+		t.Fatalf("bob is seated or not banned after the ban")
+	}
+	if msgs := types(carol.messages()); len(msgs) != 1 || msgs[0] != MessagePlayerLeft {
+		t.Fatalf("carol got %v, want PLAYER_LEFT", msgs)
+	}
+
+	bob.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	if codes := errorCodes(bob.errors()); len(codes) != 1 || codes[0] != "BANNED" {
+		t.Fatalf("rejoin errors %v, want BANNED", codes)
+	}
+}
+
+func TestPauseWhenEmpty(t *testing.T) {
+	for _, pause := range []bool{true, false} {
+		hub := newLocalHub(t)
+		alice, room := startLocalBotGame(t, hub, CreateRoomData{PauseWhenEmpty: pause})
+
+		version := room.GetGameState().Version
+		playNextBotTurn(t, hub, room)
+		if played := room.GetGameState().Version != version; played == pause {
+			t.Fatalf("pauseWhenEmpty %v: bot played with nobody watching = %v", pause, played)
+		}
+		if !pause {
+			continue
+		}
+
+		// A spectator wakes the game up
+		alice.send(MessageSpectateRoom, SpectateRoomData{RoomID: room.ID})
+		playNextBotTurn(t, hub, room)
+		if room.GetGameState().Version == version {
+			t.Fatalf("bot did not play once a spectator came")
+		}
+	}
+}
+
+func TestGetTile(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+	placement := room.GetValidPlacements()[0]
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	bob.messages()
+
+	bob.send(MessageGetTile, GetTileData{Position: placement.Position})
+	msgs := bob.messages()
+	if len(msgs) != 1 || msgs[0].Type != MessageGetTile {
+		t.Fatalf("reply = %v, want GET_TILE", types(msgs))
+	}
+	var data TileData
+	if err := ParseMessage(msgs[0], &data); err != nil {
+		t.Fatalf("parse GET_TILE: %v", err)
+	}
+	if data.Tile.Position != placement.Position || data.Tile.Rotation != placement.Rotation {
+		t.Fatalf("tile at %v rotated %d, want the tile alice placed at %v rotated %d",
+			data.Tile.Position, data.Tile.Rotation, placement.Position, placement.Rotation)
+	}
+
+	bob.send(MessageGetTile, GetTileData{Position: game.Position{X: 50, Y: 50}})
+	if codes := errorCodes(bob.errors()); len(codes) != 1 || codes[0] != "TILE_NOT_FOUND" {
+		t.Fatalf("empty position errors %v, want TILE_NOT_FOUND", codes)
+	}
+}
+
+func TestSkipMeeplePhaseWithoutMeeples(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+	room.Board.GetPlayer("alice").Meeples = 0
+
+	placement := room.GetValidPlacements()[0]
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	if errs := alice.errors(); len(errs) > 0 {
+		t.Fatalf("PLACE_TILE: %v", errs)
+	}
+	if current := room.GetCurrentPlayer().ID; current != "bob" {
+		t.Fatalf("current player = %q, want the turn to pass to bob", current)
+	}
+
+	var turnStart TurnStartData
+	for _, msg := range bob.messages() {
+		if msg.Type == MessageTurnStart {
+			ParseMessage(msg, &turnStart)
+		}
+	}
+	if turnStart.CurrentPlayer != "bob" || !turnStart.CanPlaceMeeple {
+		t.Fatalf("TURN_START = %+v, want bob's turn with a meeple to place", turnStart)
+	}
+}
+
+func TestStateDeltaClientsGetTurnAndGameEndWithoutState(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+	alice.client.stateDeltas = true
+
+	// Alice's tile is the last one, her turn ends the game
+	room, _ := hub.roomManager.GetRoom(roomID)
+	room.Board.TileDeck = nil
+	placement := room.GetValidPlacements()[0]
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	alice.send(MessageSkipMeeple, nil)
+
+	for _, c := range []*localClient{alice, bob} {
+		ended := 0
+		for _, msg := range c.messages() {
+			if msg.Type != MessageTurnEnd && msg.Type != MessageGameEnd {
+				continue
+			}
+			ended++
+			var data struct {
+				GameState *game.GameState `json:"gameState"`
+			}
+			if err := ParseMessage(msg, &data); err != nil {
+				t.Fatalf("parse %s: %v", msg.Type, err)
+			}
+			if (data.GameState == nil) != c.client.stateDeltas {
+				t.Fatalf("%s got %s with game state %v, want one only without state deltas", c.client.Player.ID, msg.Type, data.GameState != nil)
+			}
+		}
+		if ended != 2 {
+			t.Fatalf("%s got %d of TURN_END and GAME_END, want both", c.client.Player.ID, ended)
+		}
+	}
+}
+
+func TestJoinErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{room.ErrPlayerBanned, "BANNED"},
+		{room.ErrGameStarted, "GAME_ALREADY_STARTED"},
+		{room.ErrRoomFull, "ROOM_FULL"},
+		{room.ErrAlreadyInRoom, "ALREADY_IN_ROOM"},
+		{fmt.Errorf("join %q: %w", "room", room.ErrRoomNotFound), "ROOM_NOT_FOUND"},
+		{errors.New("something else"), "JOIN_FAILED"},
+	}
+
+	for _, tt := range tests {
+		if got := joinErrorCode(tt.err); got != tt.want {
+			t.Errorf("joinErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestJoinRoomErrors(t *testing.T) {
+	hub := newLocalHub(t)
+	alice := newLocalClient(t, hub, "alice", "Alice")
+	bob := newLocalClient(t, hub, "bob", "Bob")
+	carol := newLocalClient(t, hub, "carol", "Carol")
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "pair", MaxPlayers: 2})
+	roomID := alice.client.RoomID
+	bob.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+
+	carol.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	if codes := errorCodes(carol.errors()); len(codes) != 1 || codes[0] != "ROOM_FULL" {
+		t.Fatalf("joining a full room: errors %v, want ROOM_FULL", codes)
+	}
+	carol.send(MessageJoinRoom, JoinRoomData{RoomID: "missing"})
+	if codes := errorCodes(carol.errors()); len(codes) != 1 || codes[0] != "ROOM_NOT_FOUND" {
+		t.Fatalf("joining a missing room: errors %v, want ROOM_NOT_FOUND", codes)
+	}
+}
+
+func TestJoinStartedRoomOffersSpectating(t *testing.T) {
+	hub := newLocalHub(t)
+	_, _, roomID := startLocalGame(t, hub, CreateRoomData{RoomName: "started"})
+	carol := newLocalClient(t, hub, "carol", "Carol")
+	carol.messages()
+
+	carol.send(MessageJoinRoom, JoinRoomData{RoomID: roomID})
+	msgs := carol.messages()
+	if len(msgs) != 1 || msgs[0].Type != MessageSpectateAvailable {
+		t.Fatalf("reply = %v, want SPECTATE_AVAILABLE", types(msgs))
+	}
+	var offer SpectateAvailableData
+	ParseMessage(msgs[0], &offer)
+	if offer.RoomID != roomID || offer.RoomName != "started" {
+		t.Fatalf("offer = %+v, want room %q", offer, roomID)
+	}
+	if carol.client.RoomID != "" {
+		t.Fatalf("carol was put in room %q before accepting", carol.client.RoomID)
+	}
+
+	carol.send(MessageSpectateRoom, SpectateRoomData{RoomID: offer.RoomID})
+	if !carol.client.Spectator || carol.client.RoomID != roomID {
+		t.Fatalf("carol is not watching the room after SPECTATE_ROOM")
+	}
+}
+
+func TestPlaceMeepleBeforeTile(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, _, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+
+	// The starting tile is on the board, but alice placed no tile yet
+	alice.send(MessagePlaceMeeple, PlaceMeepleData{FeatureID: 0})
+	if codes := errorCodes(alice.errors()); len(codes) != 1 || codes[0] != "NO_TILE_PLACED" {
+		t.Fatalf("errors %v, want NO_TILE_PLACED", codes)
+	}
+	for _, tile := range room.GetGameState().Tiles {
+		if len(tile.Meeples) > 0 {
+			t.Fatalf("a meeple went on the tile at %v", tile.Position)
+		}
+	}
+}
+
+func TestPlaceMeepleInvalidFeature(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, _, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+	placement := room.GetValidPlacements()[0]
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	alice.messages()
+
+	// No tile has that many features
+	alice.send(MessagePlaceMeeple, PlaceMeepleData{FeatureID: 99})
+	if codes := errorCodes(alice.errors()); len(codes) != 1 || codes[0] != "INVALID_FEATURE" {
+		t.Fatalf("errors %v, want INVALID_FEATURE", codes)
+	}
+}
+
+func TestPlaceTileInvalidRotation(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, _, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+	placement := room.GetValidPlacements()[0]
+
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: 45})
+	if codes := errorCodes(alice.errors()); len(codes) != 1 || codes[0] != "INVALID_ROTATION" {
+		t.Fatalf("errors %v, want INVALID_ROTATION", codes)
+	}
+
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation + 360})
+	if errs := alice.errors(); len(errs) > 0 {
+		t.Fatalf("placing with a full turn added: %v", errs)
+	}
+	if tile := room.GetGameState().Tiles[placement.Position]; tile == nil || tile.Rotation != placement.Rotation {
+		t.Fatalf("placed tile = %+v, want rotation %d", tile, placement.Rotation)
+	}
+}
+
+func TestAddBotInvalidDifficulty(t *testing.T) {
+	hub := newLocalHub(t)
+	alice := newLocalClient(t, hub, "alice", "Alice")
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "bots", MaxPlayers: 4})
+	alice.messages()
+
+	alice.send(MessageAddBot, AddBotData{BotName: "Bot", Difficulty: "impossible"})
+	if codes := errorCodes(alice.errors()); len(codes) != 1 || codes[0] != "INVALID_DIFFICULTY" {
+		t.Fatalf("errors %v, want INVALID_DIFFICULTY", codes)
+	}
+
+	alice.send(MessageAddBot, AddBotData{BotName: "Bot", Difficulty: "hard"})
+	if errs := alice.errors(); len(errs) > 0 {
+		t.Fatalf("adding a hard bot: %v", errs)
+	}
+}
+
+func TestRandomizeSeating(t *testing.T) {
+	const games = 50
+
+	noTimeout := 0
+	bobFirst := false
+	for i := 0; i < games && !bobFirst; i++ {
+		hub := newLocalHub(t)
+		alice := newLocalClient(t, hub, "alice", "Alice")
+		bob := newLocalClient(t, hub, "bob", "Bob")
+		alice.send(MessageCreateRoom, CreateRoomData{RoomName: "seats", MaxPlayers: 4, TurnTimeout: &noTimeout, RandomizeSeating: true})
+		bob.send(MessageJoinRoom, JoinRoomData{RoomID: alice.client.RoomID})
+		alice.send(MessageSetReady, ReadyData{Ready: true})
+		bob.send(MessageSetReady, ReadyData{Ready: true})
+		alice.messages()
+		alice.send(MessageStartGame, nil)
+
+		var start GameStartData
+		var turn TurnStartData
+		for _, msg := range alice.messages() {
+			switch msg.Type {
+			case MessageGameStart:
+				ParseMessage(msg, &start)
+			case MessageTurnStart:
+				ParseMessage(msg, &turn)
+			}
+		}
+		if len(start.TurnOrder) != 2 || turn.CurrentPlayer != start.TurnOrder[0] {
+			t.Fatalf("GAME_START turn order %v, then TURN_START for %q", start.TurnOrder, turn.CurrentPlayer)
+		}
+		bobFirst = start.TurnOrder[0] == "bob"
+	}
+	if !bobFirst {
+		t.Fatalf("alice played first in all %d games with randomized seating", games)
+	}
+}
+
+func TestRoomLimitReached(t *testing.T) {
+	hub := newLocalHub(t)
+	hub.SetRoomLimits(room.DefaultMaxRooms, 1)
+	alice := newLocalClient(t, hub, "alice", "Alice")
+
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "first", MaxPlayers: 4})
+	alice.send(MessageLeaveRoom, LeaveRoomData{RoomID: alice.client.RoomID})
+	alice.messages()
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "second", MaxPlayers: 4})
+	if codes := errorCodes(alice.errors()); len(codes) != 1 || codes[0] != "ROOM_LIMIT_REACHED" {
+		t.Fatalf("errors %v, want ROOM_LIMIT_REACHED", codes)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+	room, _ := hub.roomManager.GetRoom(roomID)
+
+	// Alice is in the middle of her turn when draining starts
+	placement := room.GetValidPlacements()[0]
+	alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	if hub.Drain(time.Millisecond) {
+		t.Fatalf("Drain reported a game mid-turn as drained")
+	}
+	if !hub.IsDraining() {
+		t.Fatalf("hub is not draining")
+	}
+
+	// No new rooms, but the turn may finish
+	carol := newLocalClient(t, hub, "carol", "Carol")
+	carol.send(MessageCreateRoom, CreateRoomData{RoomName: "late"})
+	if codes := errorCodes(carol.errors()); len(codes) != 1 || codes[0] != "SERVER_DRAINING" {
+		t.Fatalf("CREATE_ROOM while draining: errors %v, want SERVER_DRAINING", codes)
+	}
+	alice.send(MessageSkipMeeple, nil)
+	if errs := alice.errors(); len(errs) > 0 {
+		t.Fatalf("SKIP_MEEPLE while draining: %v", errs)
+	}
+	if !hub.Drain(time.Millisecond) {
+		t.Fatalf("Drain did not report the game at its turn boundary")
+	}
+
+	// The next turn is held
+	placement = room.GetValidPlacements()[0]
+	bob.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+	if codes := errorCodes(bob.errors()); len(codes) != 1 || codes[0] != "SERVER_DRAINING" {
+		t.Fatalf("PLACE_TILE while draining: errors %v, want SERVER_DRAINING", codes)
+	}
+}
+
+// lastTurnStart returns the last TURN_START queued for the client
+func lastTurnStart(t *testing.T, c *localClient) TurnStartData {
+	t.Helper()
+
+	var data TurnStartData
+	found := false
+	for _, msg := range c.messages() {
+		if msg.Type == MessageTurnStart {
+			ParseMessage(msg, &data)
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("no TURN_START")
+	}
+	return data
+}
+
+func TestHidePlacements(t *testing.T) {
+	for _, hide := range []bool{true, false} {
+		hub := newLocalHub(t)
+		alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{HidePlacements: &hide})
+		carol := newLocalClient(t, hub, "carol", "Carol")
+		carol.send(MessageSpectateRoom, SpectateRoomData{RoomID: roomID})
+		room, _ := hub.roomManager.GetRoom(roomID)
+
+		placement := room.GetValidPlacements()[0]
+		alice.send(MessagePlaceTile, PlaceTileData{Position: placement.Position, Rotation: placement.Rotation})
+		alice.send(MessageSkipMeeple, nil)
+
+		if placements := lastTurnStart(t, bob).ValidPlacements; len(placements) == 0 {
+			t.Fatalf("hidePlacements %v: bob got no placements for the turn", hide)
+		}
+		for name, c := range map[string]*localClient{"alice": alice, "carol": carol} {
+			if placements := lastTurnStart(t, c).ValidPlacements; (len(placements) == 0) != hide {
+				t.Fatalf("hidePlacements %v: %s got %d placements of bob's tile", hide, name, len(placements))
+			}
+		}
+	}
+}
+
+func TestLogout(t *testing.T) {
+	hub := newLocalHub(t)
+	alice := newLocalClient(t, hub, "alice", "Alice")
+	var session SessionData
+	for _, msg := range alice.messages() {
+		if msg.Type == MessageSession {
+			ParseMessage(msg, &session)
+		}
+	}
+	alice.send(MessageCreateRoom, CreateRoomData{RoomName: "logout"})
+	roomID := alice.client.RoomID
+	alice.messages()
+
+	alice.send(MessageLogout, nil)
+	if msgs := types(alice.messages()); len(msgs) != 1 || msgs[0] != MessageLogout {
+		t.Fatalf("reply = %v, want LOGOUT", msgs)
+	}
+	if alice.client.RoomID != "" || alice.client.Player != nil {
+		t.Fatalf("client still has a player or room after LOGOUT")
+	}
+	if room, err := hub.roomManager.GetRoom(roomID); err == nil && room.GetPlayer("alice") != nil {
+		t.Fatalf("alice is still seated after LOGOUT")
+	}
+
+	// The token of the session is revoked
+	if _, _, err := hub.sessions.Authenticate("alice", session.Token); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with the token after LOGOUT = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestLogoutForfeitsRunningGame(t *testing.T) {
+	hub := newLocalHub(t)
+	alice, bob, roomID := startLocalGame(t, hub, CreateRoomData{})
+
+	alice.send(MessageLogout, nil)
+	left := false
+	for _, msgType := range types(bob.messages()) {
+		left = left || msgType == MessagePlayerLeft
+	}
+	if !left {
+		t.Fatalf("bob wasn't told alice left")
+	}
+
+	room, err := hub.roomManager.GetRoom(roomID)
+	if err != nil {
+		t.Fatalf("GetRoom: %v", err)
+	}
+	for _, p := range room.GetGameState().Players {
+		if p.ID == "alice" {
+			t.Fatalf("alice still plays after LOGOUT")
+		}
+	}
+	if game, _ := hub.roomManager.FindActiveGame("alice"); game != nil {
+		t.Fatalf("alice is still seated in a running game after LOGOUT")
+	}
+}
+
+func TestMaxMessageSize(t *testing.T) {
+	const limit = 1024
+	_, url := newTestHub(t, func(hub *Hub) { hub.SetMaxMessageSize(limit) })
+
+	alice := connect(t, url, "alice", "Alice")
+	roomID := alice.createRoom(CreateRoomData{RoomName: "limits", MaxPlayers: 4})
+	bob := connect(t, url, "bob", "Bob")
+	bob.joinRoom(roomID)
+	alice.send(MessageSetReady, ReadyData{Ready: true})
+	bob.send(MessageSetReady, ReadyData{Ready: true})
+	alice.send(MessageStartGame, nil)
+	alice.expect(MessageGameStart)
+
+	// Messages to clients aren't limited
+	alice.send(MessageGetGameState, nil)
+	for {
+		alice.conn.SetReadDeadline(time.Now().Add(testTimeout))
+		_, data, err := alice.conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("waiting for GAME_STATE: %v", err)
+		}
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			t.Fatalf("unmarshal a message of %d bytes: %v", len(data), err)
+		}
+		if msg.Type != MessageGameState {
+			continue
+		}
+		if len(data) <= limit {
+			t.Fatalf("GAME_STATE of %d bytes is within the %d byte limit", len(data), limit)
+		}
+		break
+	}
+
+	// A client message over the limit closes the connection
+	bob.send(MessageSetName, SetNameData{Name: strings.Repeat("x", limit)})
+	for {
+		_, err := bob.read()
+		if err == nil {
+			continue
+		}
+		if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+			t.Fatalf("reading after an oversized message: %v, want the connection closed", err)
+		}
+		break
+	}
+}
+
+func TestStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	hub := newLocalHub(t)
+	for difficulty := range defaultBotThinkingDelays {
+		hub.SetBotThinkingDelay(difficulty, testBotDelay)
+	}
+	_, room := startLocalBotGame(t, hub, CreateRoomData{})
+	stopped := make(chan struct{})
+	go func() {
+		hub.Run()
+		close(stopped)
+	}()
+
+	// Stop while the bots are playing
+	deadline := time.Now().Add(testTimeout)
+	for len(room.GetMoveHistory()) < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("bots made %d moves", len(room.GetMoveHistory()))
+		}
+		time.Sleep(testBotDelay)
+	}
+	hub.Stop()
+	hub.Stop()
+
+	select {
+	case <-stopped:
+	case <-time.After(testTimeout):
+		t.Fatalf("Run did not return after Stop")
+	}
+	if hub.Healthy() {
+		t.Fatalf("stopped hub is healthy")
+	}
+
+	// Bot processing, the room sweeper and scheduled bot turns exit too
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines running after Stop, %d before the hub started", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConcurrentClients(t *testing.T) {
+	const spectators = 20
+	_, url := newTestHub(t)
+
+	alice := connect(t, url, "alice", "Alice")
+	roomID := alice.createRoom(CreateRoomData{RoomName: "busy", MaxPlayers: 4})
+
+	// Spectators come and go while alice's changes are broadcast to them
+	var wg sync.WaitGroup
+	for i := 0; i < spectators; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+			if err != nil {
+				t.Errorf("dial: %v", err)
+				return
+			}
+			defer conn.Close()
+
+			id := fmt.Sprintf("spectator%d", i)
+			for _, msgType := range []MessageType{MessageConnect, MessageSpectateRoom} {
+				var data interface{} = ConnectData{PlayerID: id, Name: id}
+				if msgType == MessageSpectateRoom {
+					data = SpectateRoomData{RoomID: roomID}
+				}
+				msg, _ := CreateMessage(msgType, data)
+				if err := conn.WriteJSON(msg); err != nil {
+					t.Errorf("%s: write %s: %v", id, msgType, err)
+					return
+				}
+			}
+
+			// The state sent on spectating and a few broadcasts
+			for states := 0; states < 3; {
+				conn.SetReadDeadline(time.Now().Add(testTimeout))
+				var msg Message
+				if err := conn.ReadJSON(&msg); err != nil {
+					t.Errorf("%s: waiting for ROOM_STATE %d: %v", id, states+1, err)
+					return
+				}
+				if msg.Type == MessageRoomState {
+					states++
+				}
+			}
+		}(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for ready := true; ; ready = !ready {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		alice.send(MessageSetReady, ReadyData{Ready: ready})
+		alice.expect(MessageRoomState)
+	}
+}
+
+func TestOriginCheck(t *testing.T) {
+	_, url := newTestHub(t, func(hub *Hub) {
+		hub.SetAllowedOrigins([]string{" https://play.example.com ", ""})
+	})
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{origin: "https://play.example.com", want: true},
+		{origin: "HTTPS://Play.Example.com", want: true},
+		{origin: "", want: true}, // not a browser
+		{origin: "https://evil.example.com", want: false},
+		{origin: "http://play.example.com", want: false},
+	}
+
+	for _, tt := range tests {
+		header := http.Header{}
+		if tt.origin != "" {
+			header.Set("Origin", tt.origin)
+		}
+		conn, resp, err := websocket.DefaultDialer.Dial(url, header)
+		if tt.want {
+			if err != nil {
+				t.Fatalf("origin %q: dial: %v", tt.origin, err)
+			}
+			conn.Close()
+			continue
+		}
+		if err == nil {
+			conn.Close()
+			t.Fatalf("origin %q: connection accepted", tt.origin)
+		}
+		if resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("origin %q: dial = %v, want 403 Forbidden", tt.origin, err)
+		}
+	}
+
+	// Without an allowlist every origin is accepted
+	_, openURL := newTestHub(t)
+	conn, _, err := websocket.DefaultDialer.Dial(openURL, http.Header{"Origin": {"https://evil.example.com"}})
+	if err != nil {
+		t.Fatalf("dial without allowlist: %v", err)
+	}
+	conn.Close()
+}
@@ -1,10 +1,18 @@
 package websocket
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
+	"strconv"
+	"sync/atomic"
 	"time"
 	"carcassonne-ws/internal/game"
 	"carcassonne-ws/internal/player"
+	"carcassonne-ws/internal/room"
+	"github.com/google/uuid"
 )
 
 // MessageType represents the type of WebSocket message
@@ -13,28 +21,59 @@ type MessageType string
 const (
 	// Connection & Room Management
 	MessageConnect    MessageType = "CONNECT"
+	MessageSession    MessageType = "SESSION"
+	MessageLogout     MessageType = "LOGOUT"
 	MessageListRooms  MessageType = "LIST_ROOMS"
 	MessageCreateRoom MessageType = "CREATE_ROOM"
+	MessageCreateDailyChallenge MessageType = "CREATE_DAILY_CHALLENGE"
+	MessageGetLeaderboard       MessageType = "GET_LEADERBOARD"
 	MessageJoinRoom   MessageType = "JOIN_ROOM"
 	MessageLeaveRoom  MessageType = "LEAVE_ROOM"
 	MessageAddBot     MessageType = "ADD_BOT"
+	MessageBanPlayer  MessageType = "BAN_PLAYER"
+	MessageSetReady          MessageType = "SET_READY"
+	MessageSetName           MessageType = "SET_NAME"
+	MessageSpectateRoom      MessageType = "SPECTATE_ROOM"
+	MessageSpectateAvailable MessageType = "SPECTATE_AVAILABLE"
+	MessagePlayerJoined      MessageType = "PLAYER_JOINED"
+	MessagePlayerLeft        MessageType = "PLAYER_LEFT"
 	
 	// Game Flow
+	MessageStartGame MessageType = "START_GAME"
 	MessageGameStart MessageType = "GAME_START"
 	MessageTurnStart MessageType = "TURN_START"
+	MessageTileDiscarded MessageType = "TILE_DISCARDED"
+	MessageScoringEvent MessageType = "SCORING_EVENT"
+	MessageDrawTile MessageType = "DRAW_TILE"
 	MessagePlaceTile MessageType = "PLACE_TILE"
 	MessagePlaceMeeple MessageType = "PLACE_MEEPLE"
+	MessageUndoMeeple  MessageType = "UNDO_MEEPLE"
+	MessageEndTurn     MessageType = "END_TURN"
+	MessageSkipMeeple  MessageType = "SKIP_MEEPLE"
+	MessageGetMeepleOptions MessageType = "GET_MEEPLE_OPTIONS"
+	MessageMeepleOptions    MessageType = "MEEPLE_OPTIONS"
 	MessageTurnEnd   MessageType = "TURN_END"
+	MessagePlayerFlagged MessageType = "PLAYER_FLAGGED"
+	MessageTurnTimeout   MessageType = "TURN_TIMEOUT"
 	MessageGameEnd   MessageType = "GAME_END"
+	MessageGameError MessageType = "GAME_ERROR"
 	
 	// State Synchronization
 	MessageRoomState   MessageType = "ROOM_STATE"
 	MessageGameState   MessageType = "GAME_STATE"
+	MessageGetGameState MessageType = "GET_GAME_STATE"
+	MessageGameStateChunk MessageType = "GAME_STATE_CHUNK"
+	MessageGameStateDelta MessageType = "GAME_STATE_DELTA"
 	MessagePlayerUpdate MessageType = "PLAYER_UPDATE"
+	MessageGetTile      MessageType = "GET_TILE"
+	MessageGetBoardGrid MessageType = "GET_BOARD_GRID"
+	MessageGetHistory   MessageType = "GET_HISTORY"
 	
 	// System Messages
 	MessagePing  MessageType = "PING"
 	MessagePong  MessageType = "PONG"
+	MessageGetRoomLatencies MessageType = "GET_ROOM_LATENCIES"
+	MessageServerShutdown   MessageType = "SERVER_SHUTDOWN"
 	
 	// Error handling
 	MessageError MessageType = "ERROR"
@@ -46,6 +85,7 @@ type Message struct {
 	Data      json.RawMessage `json:"data"`
 	Timestamp time.Time       `json:"timestamp"`
 	MessageID string          `json:"messageId"`
+	ReplyTo   string          `json:"replyTo,omitempty"` // messageId of the request an error answers
 }
 
 // ConnectData represents connection message data
@@ -53,6 +93,17 @@ type ConnectData struct {
 	PlayerID string `json:"playerId"`
 	Name     string `json:"name"`
 	Color    string `json:"color"`
+	Token    string `json:"token,omitempty"` // session token from a previous connection
+	
+	// StateDeltas asks for GAME_STATE_DELTA instead of GAME_STATE broadcasts
+	StateDeltas bool `json:"stateDeltas,omitempty"`
+}
+
+// SessionData represents the session token issued on connect
+type SessionData struct {
+	PlayerID  string    `json:"playerId"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
 }
 
 // ListRoomsData represents list rooms response data
@@ -68,17 +119,61 @@ type RoomInfo struct {
 	MaxPlayers  int    `json:"maxPlayers"`
 	GameStarted bool   `json:"gameStarted"`
 	CreatedBy   string `json:"createdBy"`
+	HasPassword bool   `json:"hasPassword"`
 }
 
 // CreateRoomData represents create room message data
 type CreateRoomData struct {
-	RoomName   string `json:"roomName"`
-	MaxPlayers int    `json:"maxPlayers"`
+	RoomName        string `json:"roomName"`
+	MaxPlayers      int    `json:"maxPlayers"`
+	StrictPlacement *bool  `json:"strictPlacement,omitempty"` // defaults to true
+	PauseWhenEmpty  bool   `json:"pauseWhenEmpty,omitempty"`
+	CommandMaxAge   int    `json:"commandMaxAge,omitempty"` // seconds, 0 disables
+	TimeBank        int    `json:"timeBank,omitempty"`      // seconds per player, 0 disables
+	TurnTimeout     *int   `json:"turnTimeout,omitempty"`   // seconds per turn, defaults to 90, 0 disables
+	AllowAllBots    bool   `json:"allowAllBots,omitempty"`
+	Builders        bool   `json:"builders,omitempty"`
+	RandomizeSeating bool  `json:"randomizeSeating,omitempty"`
+	HidePlacements  *bool  `json:"hidePlacements,omitempty"` // defaults to true for timed rooms
+	MeeplesPerPlayer int   `json:"meeplesPerPlayer,omitempty"` // defaults to 7
+	Password        string `json:"password,omitempty"` // makes the room private
+}
+
+// GetLeaderboardData represents leaderboard request data
+type GetLeaderboardData struct {
+	Date string `json:"date,omitempty"` // YYYY-MM-DD, defaults to today (UTC)
+}
+
+// LeaderboardData represents leaderboard response data
+type LeaderboardData struct {
+	Date    string                  `json:"date"`
+	Entries []room.LeaderboardEntry `json:"entries"`
 }
 
 // JoinRoomData represents join room message data
 type JoinRoomData struct {
-	RoomID string `json:"roomId"`
+	RoomID   string `json:"roomId"`
+	Password string `json:"password,omitempty"` // required by private rooms
+}
+
+// SpectateRoomData represents spectate room message data
+type SpectateRoomData struct {
+	RoomID    string `json:"roomId"`
+	Password  string `json:"password,omitempty"`  // required by private rooms
+	Compress  bool   `json:"compress,omitempty"`  // gzip the initial game state
+	ChunkSize int    `json:"chunkSize,omitempty"` // split the initial game state into chunks of this many bytes
+}
+
+// SpectateAvailableData represents the offer to watch a room that can't be joined
+type SpectateAvailableData struct {
+	RoomID   string `json:"roomId"`
+	RoomName string `json:"roomName"`
+}
+
+// PlayerEventData represents a player joining or leaving a room
+type PlayerEventData struct {
+	RoomID string       `json:"roomId"`
+	Player *game.Player `json:"player"`
 }
 
 // LeaveRoomData represents leave room message data
@@ -92,10 +187,21 @@ type AddBotData struct {
 	Difficulty string `json:"difficulty"`
 }
 
+// BanPlayerData represents ban player message data
+type BanPlayerData struct {
+	PlayerID string `json:"playerId"`
+}
+
+// SetNameData represents set name message data
+type SetNameData struct {
+	Name string `json:"name"`
+}
+
 // GameStartData represents game start message data
 type GameStartData struct {
-	RoomID  string         `json:"roomId"`
-	Players []*game.Player `json:"players"`
+	RoomID    string         `json:"roomId"`
+	Players   []*game.Player `json:"players"`
+	TurnOrder []string       `json:"turnOrder"` // player IDs in the order they take turns
 }
 
 // TurnStartData represents turn start message data
@@ -103,6 +209,21 @@ type TurnStartData struct {
 	CurrentPlayer string     `json:"currentPlayer"`
 	CurrentTile   *game.Tile `json:"currentTile"`
 	ValidPlacements []game.PlacementOption `json:"validPlacements"`
+	TimeBanks     map[string]int64 `json:"timeBanks,omitempty"` // remaining milliseconds per player
+	CanPlaceMeeple bool `json:"canPlaceMeeple"`
+	Orientations  []game.TileOrientation `json:"orientations,omitempty"` // edges of the current tile for each rotation in validPlacements
+	ValidMovesDetailed []game.PositionMoves `json:"validMovesDetailed,omitempty"` // validPlacements grouped by position
+}
+
+// TurnTimeoutData represents a turn that was played automatically after the
+// player took too long
+type TurnTimeoutData struct {
+	PlayerID string `json:"playerId"`
+}
+
+// PlayerFlaggedData represents player flagged message data
+type PlayerFlaggedData struct {
+	PlayerID string `json:"playerId"`
 }
 
 // PlaceTileData represents place tile message data
@@ -113,7 +234,40 @@ type PlaceTileData struct {
 
 // PlaceMeepleData represents place meeple message data
 type PlaceMeepleData struct {
-	FeatureID int `json:"featureId"`
+	FeatureID int  `json:"featureId"`
+	Builder   bool `json:"builder,omitempty"` // place the builder instead of a meeple
+	KeepTurn  bool `json:"keepTurn,omitempty"` // leave the turn open for UNDO_MEEPLE until END_TURN
+}
+
+// MeepleOptionsData represents meeple options response data
+type MeepleOptionsData struct {
+	Position game.Position       `json:"position"`
+	Options  []game.MeepleOption `json:"options"`
+}
+
+// ClaimableFeaturesData lists the features of the tile just placed that a
+// meeple could go on
+type ClaimableFeaturesData struct {
+	Position   game.Position `json:"position"`
+	FeatureIDs []int         `json:"featureIds"`
+}
+
+// TileDiscardedData represents a drawn tile that fit nowhere and went back
+// under the deck
+type TileDiscardedData struct {
+	Tile      *game.Tile `json:"tile"`
+	TilesLeft int        `json:"tilesLeft"`
+}
+
+// DrawTileData represents the tile drawn for a new turn
+type DrawTileData struct {
+	Tile      *game.Tile `json:"tile"`
+	TilesLeft int        `json:"tilesLeft"`
+}
+
+// ScoringEventData represents a feature scored during a turn
+type ScoringEventData struct {
+	game.ScoringEvent
 }
 
 // TurnEndData represents turn end message data
@@ -121,22 +275,36 @@ type TurnEndData struct {
 	PlayerID    string            `json:"playerId"`
 	ScoreChange int               `json:"scoreChange"`
 	NextPlayer  string            `json:"nextPlayer"`
-	GameState   game.GameState    `json:"gameState"`
+	GameState   *game.GameState   `json:"gameState,omitempty"` // nil for clients that get state deltas
 }
 
 // GameEndData represents game end message data
 type GameEndData struct {
 	Winner     string         `json:"winner"`
+	Winners    []string       `json:"winners"` // every player tied for the highest score
 	FinalScore map[string]int `json:"finalScore"`
-	GameState  game.GameState `json:"gameState"`
+	GameState  *game.GameState `json:"gameState,omitempty"` // nil for clients that get state deltas
+}
+
+// GameErrorData represents game error message data
+type GameErrorData struct {
+	RoomID  string `json:"roomId"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 // RoomStateData represents room state message data
 type RoomStateData struct {
-	RoomID      string         `json:"roomId"`
-	Players     []*game.Player `json:"players"`
-	GameStarted bool           `json:"gameStarted"`
-	GameEnded   bool           `json:"gameEnded"`
+	RoomID      string          `json:"roomId"`
+	Players     []*game.Player  `json:"players"`
+	Ready       map[string]bool `json:"ready"` // by player ID, bots are always ready
+	GameStarted bool            `json:"gameStarted"`
+	GameEnded   bool            `json:"gameEnded"`
+}
+
+// ReadyData represents set ready message data
+type ReadyData struct {
+	Ready bool `json:"ready"`
 }
 
 // GameStateData represents game state message data
@@ -144,6 +312,51 @@ type GameStateData struct {
 	GameState game.GameState `json:"gameState"`
 }
 
+// GameStateDeltaData represents the game state changes since fromVersion
+type GameStateDeltaData struct {
+	Delta game.GameStateDelta `json:"delta"`
+}
+
+// Encodings of a chunked game state payload
+const (
+	SyncEncodingJSON = "json"
+	SyncEncodingGzip = "gzip+base64"
+)
+
+// minSyncChunkSize keeps spectators from asking for a flood of tiny chunks
+const minSyncChunkSize = 1024
+
+// GameStateChunkData represents one part of a chunked game state. Clients
+// concatenate the payloads in index order and decode them once complete is set.
+type GameStateChunkData struct {
+	SyncID   string `json:"syncId"`
+	Index    int    `json:"index"`
+	Total    int    `json:"total"`
+	Encoding string `json:"encoding"`
+	Payload  string `json:"payload"`
+	Complete bool   `json:"complete"`
+}
+
+// GetTileData represents get tile request data
+type GetTileData struct {
+	Position game.Position `json:"position"`
+}
+
+// TileData represents get tile response data
+type TileData struct {
+	Tile game.PlacedTile `json:"tile"`
+}
+
+// BoardGridData represents board grid response data
+type BoardGridData struct {
+	Grid game.BoardGrid `json:"grid"`
+}
+
+// HistoryData represents move history response data
+type HistoryData struct {
+	Moves []game.MoveRecord `json:"moves"`
+}
+
 // PlayerUpdateData represents player update message data
 type PlayerUpdateData struct {
 	Player *game.Player `json:"player"`
@@ -161,6 +374,11 @@ type BotMoveData struct {
 	Move  player.BotMove   `json:"move"`
 }
 
+// ServerShutdownData represents server shutdown message data
+type ServerShutdownData struct {
+	Message string `json:"message"`
+}
+
 // PingData represents ping message data for latency calculation
 type PingData struct {
 	Timestamp int64  `json:"timestamp"`
@@ -174,6 +392,11 @@ type PongData struct {
 	ClientID      string `json:"clientId,omitempty"`
 }
 
+// RoomLatenciesData represents room latencies response data
+type RoomLatenciesData struct {
+	Latencies map[string]float64 `json:"latencies"` // milliseconds per player ID
+}
+
 // CreateMessage creates a new message with the given type and data
 func CreateMessage(msgType MessageType, data interface{}) (*Message, error) {
 	dataBytes, err := json.Marshal(data)
@@ -194,17 +417,35 @@ func ParseMessage(msg *Message, target interface{}) error {
 	return json.Unmarshal(msg.Data, target)
 }
 
-// generateMessageID generates a unique message ID
+// messageCounter makes message IDs unique within a server run
+var messageCounter uint64
+
+// generateMessageID generates a unique message ID from a monotonic counter
+// and a random suffix, so IDs don't repeat across server restarts either
 func generateMessageID() string {
-	return time.Now().Format("20060102150405") + "-" + randomString(6)
+	n := atomic.AddUint64(&messageCounter, 1)
+	return strconv.FormatUint(n, 10) + "-" + uuid.New().String()[:8]
 }
 
-// randomString generates a random string of given length
+// randomString generates a random alphanumeric string of given length
 func randomString(length int) string {
 	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[len(charset)/2] // Simplified for demo
+	
+	// Bytes past the largest multiple of the charset size are skipped, so
+	// every character is equally likely
+	const limit = 256 - 256%len(charset)
+	
+	b := make([]byte, 0, length)
+	buf := make([]byte, length)
+	for len(b) < length {
+		if _, err := rand.Read(buf); err != nil {
+			panic("crypto/rand unavailable: " + err.Error())
+		}
+		for _, r := range buf {
+			if int(r) < limit && len(b) < length {
+				b = append(b, charset[int(r)%len(charset)])
+			}
+		}
 	}
 	return string(b)
 }
@@ -253,23 +494,137 @@ func NewGameStateMessage(gameState game.GameState) (*Message, error) {
 	})
 }
 
-func NewRoomStateMessage(roomID string, players []*game.Player, gameStarted, gameEnded bool) (*Message, error) {
+func NewGameStateDeltaMessage(delta game.GameStateDelta) (*Message, error) {
+	return CreateMessage(MessageGameStateDelta, GameStateDeltaData{
+		Delta: delta,
+	})
+}
+
+// NewGameStateChunkMessages encodes a game state as a series of
+// GAME_STATE_CHUNK messages. The payload is the GAME_STATE data, gzipped and
+// base64 encoded when compress is set. A chunkSize of 0 sends a single chunk.
+func NewGameStateChunkMessages(gameState game.GameState, compress bool, chunkSize int) ([]*Message, error) {
+	payload, err := json.Marshal(GameStateData{GameState: gameState})
+	if err != nil {
+		return nil, err
+	}
+	
+	encoding := SyncEncodingJSON
+	if compress {
+		var buf bytes.Buffer
+		writer := gzip.NewWriter(&buf)
+		if _, err := writer.Write(payload); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		payload = []byte(base64.StdEncoding.EncodeToString(buf.Bytes()))
+		encoding = SyncEncodingGzip
+	}
+	
+	if chunkSize <= 0 || chunkSize > len(payload) {
+		chunkSize = len(payload)
+	} else if chunkSize < minSyncChunkSize {
+		chunkSize = minSyncChunkSize
+	}
+	
+	total := 1
+	if chunkSize > 0 {
+		total = (len(payload) + chunkSize - 1) / chunkSize
+	}
+	
+	syncID := generateMessageID()
+	messages := make([]*Message, 0, total)
+	for i := 0; i < total; i++ {
+		start := i * chunkSize
+		end := start + chunkSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		
+		msg, err := CreateMessage(MessageGameStateChunk, GameStateChunkData{
+			SyncID:   syncID,
+			Index:    i,
+			Total:    total,
+			Encoding: encoding,
+			Payload:  string(payload[start:end]),
+			Complete: i == total-1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msg)
+	}
+	
+	return messages, nil
+}
+
+func NewTurnEndMessage(playerID string, scoreChange int, nextPlayer string, gameState *game.GameState) (*Message, error) {
+	return CreateMessage(MessageTurnEnd, TurnEndData{
+		PlayerID:    playerID,
+		ScoreChange: scoreChange,
+		NextPlayer:  nextPlayer,
+		GameState:   gameState,
+	})
+}
+
+func NewGameEndMessage(winners []string, finalScore map[string]int, gameState *game.GameState) (*Message, error) {
+	winner := ""
+	if len(winners) > 0 {
+		winner = winners[0]
+	}
+	
+	return CreateMessage(MessageGameEnd, GameEndData{
+		Winner:     winner,
+		Winners:    winners,
+		FinalScore: finalScore,
+		GameState:  gameState,
+	})
+}
+
+func NewRoomStateMessage(roomID string, players []*game.Player, ready map[string]bool, gameStarted, gameEnded bool) (*Message, error) {
 	return CreateMessage(MessageRoomState, RoomStateData{
 		RoomID:      roomID,
 		Players:     players,
+		Ready:       ready,
 		GameStarted: gameStarted,
 		GameEnded:   gameEnded,
 	})
 }
 
-func NewTurnStartMessage(currentPlayer string, currentTile *game.Tile, validPlacements []game.PlacementOption) (*Message, error) {
+func NewTurnStartMessage(currentPlayer string, currentTile *game.Tile, validPlacements []game.PlacementOption, validMoves []game.PositionMoves, timeBanks map[string]int64, canPlaceMeeple bool) (*Message, error) {
 	return CreateMessage(MessageTurnStart, TurnStartData{
-		CurrentPlayer:   currentPlayer,
-		CurrentTile:     currentTile,
-		ValidPlacements: validPlacements,
+		CurrentPlayer:      currentPlayer,
+		CurrentTile:        currentTile,
+		ValidPlacements:    validPlacements,
+		TimeBanks:          timeBanks,
+		CanPlaceMeeple:     canPlaceMeeple,
+		Orientations:       placementOrientations(currentTile, validPlacements),
+		ValidMovesDetailed: validMoves,
 	})
 }
 
+// placementOrientations returns the rotated edges of the tile for every
+// distinct rotation among the placements
+func placementOrientations(tile *game.Tile, placements []game.PlacementOption) []game.TileOrientation {
+	if tile == nil {
+		return nil
+	}
+	
+	seen := make(map[int]bool)
+	orientations := make([]game.TileOrientation, 0, 4)
+	for _, placement := range placements {
+		if seen[placement.Rotation] {
+			continue
+		}
+		seen[placement.Rotation] = true
+		orientations = append(orientations, tile.Orientation(placement.Rotation))
+	}
+	
+	return orientations
+}
+
 // NewPingMessage creates a new ping message for latency measurement
 func NewPingMessage(clientID string) (*Message, error) {
 	return CreateMessage(MessagePing, PingData{
@@ -0,0 +1,175 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"carcassonne-ws/internal/game"
+)
+
+func TestGenerateMessageID(t *testing.T) {
+	const goroutines, perGoroutine = 8, 500
+
+	var mutex sync.Mutex
+	seen := make(map[string]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			last := uint64(0)
+			for i := 0; i < perGoroutine; i++ {
+				id := generateMessageID()
+				counter, suffix, found := strings.Cut(id, "-")
+				n, err := strconv.ParseUint(counter, 10, 64)
+				if !found || err != nil || len(suffix) != 8 {
+					t.Errorf("message ID %q is not a counter and a random suffix", id)
+					return
+				}
+				if n <= last {
+					t.Errorf("counter went from %d to %d", last, n)
+				}
+				last = n
+
+				mutex.Lock()
+				if seen[id] {
+					t.Errorf("message ID %q generated twice", id)
+				}
+				seen[id] = true
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRandomString(t *testing.T) {
+	const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+	for _, length := range []int{0, 1, 8, 100} {
+		if s := randomString(length); len(s) != length || strings.Trim(s, charset) != "" {
+			t.Fatalf("randomString(%d) = %q, want %d alphanumeric characters", length, s, length)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		id := generateClientID()
+		if seen[id] {
+			t.Fatalf("client ID %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestPlacementOrientations(t *testing.T) {
+	tile := game.CreateStandardTileSet()[0]
+	placements := []game.PlacementOption{
+		{Position: game.Position{X: 1, Y: 0}, Rotation: 90},
+		{Position: game.Position{X: 0, Y: 1}, Rotation: 90},
+		{Position: game.Position{X: 0, Y: 1}, Rotation: 270},
+	}
+
+	orientations := placementOrientations(tile, placements)
+	if len(orientations) != 2 || orientations[0] != tile.Orientation(90) || orientations[1] != tile.Orientation(270) {
+		t.Fatalf("orientations = %+v, want rotations 90 and 270 once each", orientations)
+	}
+	if orientations := placementOrientations(nil, placements); orientations != nil {
+		t.Fatalf("orientations without a tile = %+v, want nil", orientations)
+	}
+}
+
+// decodeChunks reassembles a chunked game state
+func decodeChunks(t *testing.T, msgs []*Message) GameStateData {
+	t.Helper()
+
+	var payload strings.Builder
+	var encoding, syncID string
+	for i, msg := range msgs {
+		var chunk GameStateChunkData
+		if err := ParseMessage(msg, &chunk); err != nil {
+			t.Fatalf("parse chunk %d: %v", i, err)
+		}
+		if i == 0 {
+			encoding, syncID = chunk.Encoding, chunk.SyncID
+		}
+		if chunk.Index != i || chunk.Total != len(msgs) || chunk.SyncID != syncID || chunk.Complete != (i == len(msgs)-1) {
+			t.Fatalf("chunk %d of %d = %+v", i, len(msgs), chunk)
+		}
+		payload.WriteString(chunk.Payload)
+	}
+
+	data := []byte(payload.String())
+	if encoding == SyncEncodingGzip {
+		compressed, err := base64.StdEncoding.DecodeString(payload.String())
+		if err != nil {
+			t.Fatalf("base64: %v", err)
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatalf("gzip: %v", err)
+		}
+		if data, err = io.ReadAll(reader); err != nil {
+			t.Fatalf("gunzip: %v", err)
+		}
+	}
+
+	var state GameStateData
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("unmarshal game state: %v", err)
+	}
+	return state
+}
+
+func TestGameStateChunks(t *testing.T) {
+	board := game.NewBoardWithSeed(1)
+	for _, id := range []string{"alice", "bob"} {
+		board.AddPlayer(&game.Player{ID: id, Name: id})
+	}
+	board.StartGame()
+	for turn := 0; turn < 30; turn++ {
+		if placements := board.GetValidPlacements(); len(placements) > 0 {
+			board.PlaceTile(placements[0].Position, placements[0].Rotation)
+		}
+		board.NextTurn()
+	}
+	gameState := board.GetGameState()
+	full, _ := json.Marshal(GameStateData{GameState: gameState})
+	want, _ := json.Marshal(gameState)
+	if len(full) <= 2*minSyncChunkSize {
+		t.Fatalf("game state of %d bytes is too small to be chunked", len(full))
+	}
+
+	tests := []struct {
+		compress   bool
+		chunkSize  int
+		wantChunks int
+	}{
+		{compress: false, chunkSize: 0, wantChunks: 1},
+		{compress: false, chunkSize: 1, wantChunks: (len(full) + minSyncChunkSize - 1) / minSyncChunkSize},
+		{compress: true, chunkSize: 0, wantChunks: 1},
+		{compress: true, chunkSize: minSyncChunkSize},
+	}
+
+	for _, tt := range tests {
+		msgs, err := NewGameStateChunkMessages(gameState, tt.compress, tt.chunkSize)
+		if err != nil {
+			t.Fatalf("NewGameStateChunkMessages(%v, %d): %v", tt.compress, tt.chunkSize, err)
+		}
+		if tt.wantChunks > 0 && len(msgs) != tt.wantChunks {
+			t.Fatalf("compress %v, chunk size %d: %d chunks, want %d", tt.compress, tt.chunkSize, len(msgs), tt.wantChunks)
+		}
+
+		got, _ := json.Marshal(decodeChunks(t, msgs).GameState)
+		if !bytes.Equal(got, want) {
+			t.Fatalf("compress %v, chunk size %d: reassembled state differs", tt.compress, tt.chunkSize)
+		}
+	}
+}
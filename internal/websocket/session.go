@@ -0,0 +1,135 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sessionTokenTTL is how long a session token stays valid without reconnecting
+const sessionTokenTTL = 24 * time.Hour
+
+// ErrInvalidToken is returned when a session token is unknown, expired or revoked
+var ErrInvalidToken = errors.New("invalid session token")
+
+// session represents the active session token of a player
+type session struct {
+	token     string
+	expiresAt time.Time
+}
+
+// SessionStore keeps one active session token per player ID. Tokens are
+// signed with the store's secret, so a token issued before a restart still
+// proves who the player is as long as the secret stays the same.
+type SessionStore struct {
+	sessions map[string]session
+	secret   []byte
+	mutex    sync.Mutex
+}
+
+// NewSessionStore creates an empty session store signing tokens with the
+// given secret. Without a secret a random one is used, and tokens don't
+// survive a restart.
+func NewSessionStore(secret []byte) *SessionStore {
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+	}
+	
+	return &SessionStore{
+		sessions: make(map[string]session),
+		secret:   secret,
+	}
+}
+
+// Authenticate checks the token presented on connect and returns a freshly
+// issued token that replaces it. A player without an active session gets a
+// new one without presenting a token, or with a correctly signed one issued
+// before a restart. A token that was rotated, revoked or expired can never
+// be used again.
+func (s *SessionStore) Authenticate(playerID, token string) (string, time.Time, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	current, exists := s.sessions[playerID]
+	if exists && time.Now().After(current.expiresAt) {
+		delete(s.sessions, playerID)
+		exists = false
+	}
+	
+	if token != "" && !s.verifyToken(playerID, token) {
+		return "", time.Time{}, ErrInvalidToken
+	}
+	if exists && token != current.token {
+		return "", time.Time{}, ErrInvalidToken
+	}
+	
+	expiresAt := time.Now().Add(sessionTokenTTL)
+	newToken, err := s.signToken(playerID, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	
+	s.sessions[playerID] = session{token: newToken, expiresAt: expiresAt}
+	
+	return newToken, expiresAt, nil
+}
+
+// Revoke invalidates the active token of a player. The player's session is
+// kept empty until the token would have expired, so the revoked token stays
+// rejected even though its signature is valid.
+func (s *SessionStore) Revoke(playerID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	
+	s.sessions[playerID] = session{expiresAt: time.Now().Add(sessionTokenTTL)}
+}
+
+// signToken issues a token for a player, made of the player ID, the expiry
+// and a random nonce, followed by their HMAC
+func (s *SessionStore) signToken(playerID string, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	
+	payload := playerID + "|" + strconv.FormatInt(expiresAt.Unix(), 10) + "|" + hex.EncodeToString(nonce)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + s.sign(payload), nil
+}
+
+// verifyToken checks that a token was signed by the store, belongs to the
+// player and hasn't expired
+func (s *SessionStore) verifyToken(playerID, token string) bool {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found {
+		return false
+	}
+	
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || !hmac.Equal([]byte(signature), []byte(s.sign(string(payload)))) {
+		return false
+	}
+	
+	// The player ID may contain the separator, the expiry and nonce can't
+	fields := strings.Split(string(payload), "|")
+	if len(fields) < 3 || strings.Join(fields[:len(fields)-2], "|") != playerID {
+		return false
+	}
+	
+	expiresAt, err := strconv.ParseInt(fields[len(fields)-2], 10, 64)
+	return err == nil && time.Now().Unix() < expiresAt
+}
+
+// sign returns the HMAC of a token payload
+func (s *SessionStore) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
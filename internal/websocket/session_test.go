@@ -0,0 +1,54 @@
+package websocket
+
+import "testing"
+
+func TestSessionTokenRotation(t *testing.T) {
+	s := NewSessionStore([]byte("secret"))
+
+	first, _, err := s.Authenticate("alice", "")
+	if err != nil {
+		t.Fatalf("first Authenticate: %v", err)
+	}
+	if _, _, err := s.Authenticate("alice", ""); err != ErrInvalidToken {
+		t.Fatalf("Authenticate without token while a session is active = %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := s.Authenticate("bob", first); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with another player's token = %v, want ErrInvalidToken", err)
+	}
+
+	second, _, err := s.Authenticate("alice", first)
+	if err != nil {
+		t.Fatalf("Authenticate with the token: %v", err)
+	}
+	if second == first {
+		t.Fatalf("token was not rotated")
+	}
+	if _, _, err := s.Authenticate("alice", first); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with the rotated token = %v, want ErrInvalidToken", err)
+	}
+
+	s.Revoke("alice")
+	if _, _, err := s.Authenticate("alice", second); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with a revoked token = %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := s.Authenticate("alice", ""); err != nil {
+		t.Fatalf("Authenticate after logging out: %v", err)
+	}
+}
+
+func TestSessionTokenSurvivesRestart(t *testing.T) {
+	token, _, err := NewSessionStore([]byte("secret")).Authenticate("alice", "")
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	if _, _, err := NewSessionStore([]byte("other secret")).Authenticate("alice", token); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with a token of another secret = %v, want ErrInvalidToken", err)
+	}
+	if _, _, err := NewSessionStore([]byte("secret")).Authenticate("alice", token); err != nil {
+		t.Fatalf("Authenticate after a restart: %v", err)
+	}
+	if _, _, err := NewSessionStore([]byte("secret")).Authenticate("alice", token+"x"); err != ErrInvalidToken {
+		t.Fatalf("Authenticate with a tampered token = %v, want ErrInvalidToken", err)
+	}
+}